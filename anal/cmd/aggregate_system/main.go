@@ -0,0 +1,397 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+var yearPattern = regexp.MustCompile(`wages_(\d{4})\.json$`)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/system", "Output directory for system-wide aggregates")
+	sumsDir := flag.String("sums", "", "Merge summary sketches from this directory instead of loading raw data (summaries only)")
+	groupsFile := flag.String("groups", "", "JSON file of location groups (defaults to the standard groups)")
+	topN := flag.Int("top", 100, "Number of top titles to include")
+	workers := flag.Int("workers", 2, "Number of years processed concurrently")
+	cpiSeries := flag.String("cpi", "", "CPI series for real-dollar fields (cpi-u, california, sf-bay, los-angeles, regional or a .json file)")
+	baseYear := flag.Int("base-year", 2024, "Base year for real-dollar fields")
+	population := flag.String("population", "", "Population filter (all, no-students, salaried, full-time or a .json file); unfiltered when empty")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file for bracket categories and category filters (defaults to the bundled taxonomy)")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	dpEpsilon := flag.Float64("dp-epsilon", 0, "Differential privacy epsilon to spend on this release (exact values when 0)")
+	dpDelta := flag.Float64("dp-delta", 1e-6, "Differential privacy delta to spend on choosing which titles to list")
+	dpBound := flag.Float64("dp-bound", 500000, "Pay each record is clipped to for private sums and percentiles")
+	dpLedger := flag.String("dp-ledger", "./output/privacy_budget.json", "Privacy budget ledger shared by the commands of a run")
+	dpBudget := flag.Float64("dp-budget", 1, "Total epsilon of the run when starting a new ledger")
+	flag.Parse()
+
+	// Load location groups
+	groups := calculator.GetLocationGroups()
+	var err error
+	if *groupsFile != "" {
+		groups, err = parser.LoadLocationGroups(*groupsFile)
+		if err != nil {
+			log.Fatal("Error loading location groups:", err)
+		}
+	}
+
+	// Load CPI series if real-dollar output was requested
+	var adjuster *inflation.Adjuster
+	if *cpiSeries != "" {
+		adjuster, err = inflation.NewAdjuster(*cpiSeries, *baseYear)
+		if err != nil {
+			log.Fatal("Error loading CPI series:", err)
+		}
+		fmt.Printf("Adjusting for inflation to %d dollars using %s\n", *baseYear, *cpiSeries)
+	}
+
+	// Fast path: merge stored sketches instead of raw records
+	if *sumsDir != "" && *dpEpsilon > 0 {
+		log.Fatal("Summaries merged from sketches cannot be released with differential privacy")
+	}
+	if *sumsDir != "" {
+		if err := mergeSummaryFiles(*sumsDir, groups, *outputDir, adjuster); err != nil {
+			log.Fatal("Error merging summaries:", err)
+		}
+		fmt.Println("\n✅ All system-wide summaries merged successfully!")
+		return
+	}
+
+	// Resolve population filter
+	filter, err := parser.OpenPopulationFilter(*population, calculator.GetPopulationFilters())
+	if err != nil {
+		log.Fatal("Error loading population filter:", err)
+	}
+
+	// Load title taxonomy for bracket categories and category filters
+	tax, err := taxonomy.Open(*taxonomyFile)
+	if err != nil {
+		log.Fatal("Error loading taxonomy:", err)
+	}
+	if filter != nil {
+		fmt.Printf("Restricting to the %s population\n", filter.Name)
+	}
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
+	// Charge the privacy budget before releasing anything
+	var privacy *calculator.PrivacyOptions
+	if *dpEpsilon > 0 {
+		budget, err := calculator.ChargePrivacyLedger(*dpLedger, *dpBudget, "aggregate_system", *dpEpsilon, *dpDelta)
+		if err != nil {
+			log.Fatal("Error charging privacy budget:", err)
+		}
+		// Groups overlap, so every group takes an even share, split
+		// between its outputs in processGroup; the pyramid and titles
+		// share delta
+		privacy = &calculator.PrivacyOptions{
+			Epsilon:  *dpEpsilon / float64(len(groups)),
+			Delta:    *dpDelta / float64(2*len(groups)),
+			PayBound: *dpBound,
+			Taxonomy: tax,
+			Budget:   budget.Epsilon,
+		}
+		fmt.Printf("Releasing with differential privacy (epsilon %g, %g per group; %g of the run's %g spent)\n", *dpEpsilon, privacy.Epsilon, budget.Spent, budget.Epsilon)
+	}
+
+	// Get all JSON files grouped by year
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	filesByYear := make(map[int][]string)
+	for _, file := range files {
+		match := yearPattern.FindStringSubmatch(file)
+		if match == nil {
+			continue
+		}
+		year, _ := strconv.Atoi(match[1])
+		filesByYear[year] = append(filesByYear[year], file)
+	}
+
+	fmt.Printf("Found %d wage files across %d years\n", len(files), len(filesByYear))
+
+	// Create output directories
+	for _, dir := range []string{"sums", "pyramid", "titles"} {
+		if err := os.MkdirAll(fmt.Sprintf("%s/%s", *outputDir, dir), 0755); err != nil {
+			log.Fatal("Error creating output directory:", err)
+		}
+	}
+
+	// Process years concurrently
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(filesByYear))
+
+	for year, yearFiles := range filesByYear {
+		wg.Add(1)
+		go func(year int, yearFiles []string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := processYear(year, yearFiles, groups, *outputDir, *topN, adjuster, filter, tax, policy, privacy); err != nil {
+				errorsChan <- fmt.Errorf("error processing %d: %w", year, err)
+			} else {
+				fmt.Printf("✓ Aggregated %d locations for %d\n", len(yearFiles), year)
+			}
+		}(year, yearFiles)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All system-wide aggregates generated successfully!")
+	}
+}
+
+func processYear(year int, files []string, groups []models.LocationGroup, outputDir string, topN int, adjuster *inflation.Adjuster, filter *models.PopulationFilter, tax *taxonomy.Taxonomy, policy *models.SuppressionPolicy, privacy *calculator.PrivacyOptions) error {
+	// Load every location for the year, restricted to the selected population
+	var datasets []*models.WageData
+	populations := make(map[string]*models.PopulationFilter)
+	for _, file := range files {
+		data, err := parser.LoadWageData(file)
+		if err != nil {
+			return err
+		}
+		if filter != nil {
+			data, populations[data.Location], err = calculator.FilterPopulation(data, *filter, tax)
+			if err != nil {
+				return err
+			}
+		}
+		datasets = append(datasets, data)
+	}
+
+	sort.Slice(datasets, func(i, j int) bool {
+		return datasets[i].Location < datasets[j].Location
+	})
+
+	for _, group := range groups {
+		merged, locations := calculator.MergeWageData(group, year, datasets)
+		if merged == nil {
+			continue
+		}
+
+		// Combine the filter counts of the group's locations
+		var groupPopulations []*models.PopulationFilter
+		for _, location := range locations {
+			if population, ok := populations[location]; ok {
+				groupPopulations = append(groupPopulations, population)
+			}
+		}
+		population, err := calculator.MergePopulations(groupPopulations)
+		if err != nil {
+			return fmt.Errorf("%s: %w", group.Name, err)
+		}
+
+		if err := processGroup(merged, locations, population, outputDir, topN, adjuster, tax, policy, privacy); err != nil {
+			return fmt.Errorf("%s: %w", group.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func processGroup(data *models.WageData, locations []string, population *models.PopulationFilter, outputDir string, topN int, adjuster *inflation.Adjuster, tax *taxonomy.Taxonomy, policy *models.SuppressionPolicy, privacy *calculator.PrivacyOptions) error {
+	summary, err := calculator.CalculateSummary(data)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		return fmt.Errorf("no valid wage data found")
+	}
+
+	pyramid, err := calculator.CalculatePyramid(data, tax)
+	if err != nil {
+		return err
+	}
+
+	titles, err := calculator.AnalyzeTitles(data, topN)
+	if err != nil {
+		return err
+	}
+
+	summary.Locations = locations
+	pyramid.Locations = locations
+	titles.Locations = locations
+	summary.Population = population
+	pyramid.Population = population
+	titles.Population = population
+
+	// Replace exact values with noisy ones, splitting the group's epsilon
+	// as a run splits it between the commands releasing each output
+	if privacy != nil {
+		shares := calculator.PrivacyRunShares()
+		outputs := shares["calculate_sums"] + shares["generate_pyramid"] + shares["analyze_titles"]
+		share := func(command string) calculator.PrivacyOptions {
+			opts := *privacy
+			opts.Epsilon *= shares[command] / outputs
+			return opts
+		}
+
+		if err := calculator.ApplyPrivacyToSummary(summary, data, share("calculate_sums")); err != nil {
+			return err
+		}
+		if err := calculator.ApplyPrivacyToPyramid(pyramid, data, share("generate_pyramid")); err != nil {
+			return err
+		}
+		if err := calculator.ApplyPrivacyToTitles(titles, data, calculator.TitleOptions{TopN: topN}, share("analyze_titles")); err != nil {
+			return err
+		}
+	}
+
+	// Add real-dollar fields
+	if adjuster != nil {
+		if err := calculator.ApplyInflationToSummary(summary, adjuster); err != nil {
+			return err
+		}
+		if err := calculator.ApplyInflationToPyramid(pyramid, adjuster); err != nil {
+			return err
+		}
+		if err := calculator.ApplyInflationToTitles(titles, adjuster); err != nil {
+			return err
+		}
+	}
+
+	// Withhold small cells
+	if policy != nil {
+		if err := calculator.ApplySuppressionToPyramid(pyramid, *policy); err != nil {
+			return err
+		}
+		if err := calculator.ApplySuppressionToTitles(titles, *policy); err != nil {
+			return err
+		}
+	}
+
+	// Generate output filename
+	filename := fmt.Sprintf("%s_%d.json",
+		strings.ReplaceAll(data.Location, " ", "_"),
+		data.Year)
+
+	outputs := map[string]interface{}{
+		"sums":    summary,
+		"pyramid": pyramid,
+		"titles":  titles,
+	}
+	for dir, output := range outputs {
+		if err := parser.SaveJSON(fmt.Sprintf("%s/%s/%s", outputDir, dir, filename), output); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mergeSummaryFiles(sumsDir string, groups []models.LocationGroup, outputDir string, adjuster *inflation.Adjuster) error {
+	files, err := filepath.Glob(filepath.Join(sumsDir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Found %d summary files to merge\n", len(files))
+
+	summariesByYear := make(map[int][]*models.Summary)
+	for _, file := range files {
+		summary, err := loadSummary(file)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %w", file, err)
+		}
+		summariesByYear[summary.Year] = append(summariesByYear[summary.Year], summary)
+	}
+
+	for year, summaries := range summariesByYear {
+		for _, group := range groups {
+			merged, err := calculator.MergeSummaries(group, year, summaries)
+			if err != nil {
+				return err
+			}
+			if merged == nil {
+				continue
+			}
+
+			if adjuster != nil {
+				if err := calculator.ApplyInflationToSummary(merged, adjuster); err != nil {
+					return err
+				}
+			}
+
+			filename := fmt.Sprintf("%s_%d.json", strings.ReplaceAll(group.Name, " ", "_"), year)
+			if err := parser.SaveJSON(fmt.Sprintf("%s/sums/%s", outputDir, filename), merged); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("✓ Merged %d summaries for %d\n", len(summaries), year)
+	}
+
+	return nil
+}
+
+func loadSummary(filepath string) (*models.Summary, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary models.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
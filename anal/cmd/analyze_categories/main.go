@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/categories", "Output directory for category analysis")
+	otherTopN := flag.Int("other-top", 50, "Number of uncategorized titles to report")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file (defaults to the bundled taxonomy)")
+	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	flag.Parse()
+
+	// Load title taxonomy
+	tax, err := taxonomy.Open(*taxonomyFile)
+	if err != nil {
+		log.Fatal("Error loading taxonomy:", err)
+	}
+	fmt.Printf("Using taxonomy %s version %s\n", tax.Name(), tax.Version())
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
+	// Get all JSON files
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	fmt.Printf("Found %d wage files to process\n", len(files))
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	// Process files concurrently
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(files))
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(filepath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := processFile(filepath, *outputDir, tax, *otherTopN, policy); err != nil {
+				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
+			} else {
+				fmt.Printf("✓ Analyzed categories for %s\n", filepath)
+			}
+		}(file)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All category analyses completed successfully!")
+	}
+}
+
+func processFile(filepath, outputDir string, tax *taxonomy.Taxonomy, otherTopN int, policy *models.SuppressionPolicy) error {
+	// Load wage data
+	data, err := parser.LoadWageData(filepath)
+	if err != nil {
+		return err
+	}
+
+	// Roll records up into categories
+	analysis, err := calculator.AnalyzeCategories(data, tax, otherTopN)
+	if err != nil {
+		return err
+	}
+
+	if analysis == nil {
+		return fmt.Errorf("no valid wage data found")
+	}
+
+	// Withhold small cells
+	if policy != nil {
+		if err := calculator.ApplySuppressionToCategories(analysis, *policy); err != nil {
+			return err
+		}
+	}
+
+	// Generate output filename
+	filename := fmt.Sprintf("%s_%d.json",
+		strings.ReplaceAll(data.Location, " ", "_"),
+		data.Year)
+	outputPath := fmt.Sprintf("%s/%s", outputDir, filename)
+
+	// Save analysis
+	if err := parser.SaveJSON(outputPath, analysis); err != nil {
+		return err
+	}
+
+	// Print summary
+	fmt.Printf("  - %s %d: %d categories, %d uncategorized titles reported\n",
+		data.Location, data.Year,
+		len(analysis.Categories),
+		len(analysis.UncategorizedTitles))
+
+	return nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/compression", "Output directory for pay compression analysis")
+	dictionaryFile := flag.String("titles", "", "Title dictionary for normalization (defaults to the bundled dictionary)")
+	minCount := flag.Int("min-count", 5, "Minimum employees for a level to be compared")
+	payBasis := flag.String("pay", calculator.PayBasisBase, "Pay compared between levels (base or gross)")
+	population := flag.String("population", "", "Population filter (all, no-students, salaried, full-time or a .json file); unfiltered when empty")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file for category filters (defaults to the bundled taxonomy)")
+	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	flag.Parse()
+
+	// Load title dictionary
+	normalizer, err := taxonomy.OpenNormalizer(*dictionaryFile)
+	if err != nil {
+		log.Fatal("Error loading title dictionary:", err)
+	}
+
+	opts := calculator.CompressionOptions{
+		Normalizer: normalizer,
+		MinCount:   *minCount,
+		PayBasis:   *payBasis,
+	}
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+
+		// Compare only levels meeting the policy's minimum
+		opts.MinCount = calculator.ComparisonMinCount(opts.MinCount, *policy)
+	}
+
+	// Resolve population filter
+	filter, err := parser.OpenPopulationFilter(*population, calculator.GetPopulationFilters())
+	if err != nil {
+		log.Fatal("Error loading population filter:", err)
+	}
+	var tax *taxonomy.Taxonomy
+	if filter != nil {
+		tax, err = taxonomy.Open(*taxonomyFile)
+		if err != nil {
+			log.Fatal("Error loading taxonomy:", err)
+		}
+		fmt.Printf("Restricting to the %s population\n", filter.Name)
+	}
+
+	// Get all JSON files
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	fmt.Printf("Found %d wage files to process\n", len(files))
+	fmt.Printf("Comparing %s pay across title levels using %s version %s\n", *payBasis, normalizer.Name(), normalizer.Version())
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	// Process files concurrently
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(files))
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(filepath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			compression, err := processFile(filepath, *outputDir, opts, filter, tax, policy)
+			if err != nil {
+				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
+				return
+			}
+
+			fmt.Printf("✓ %s %d: %d families, median top-to-entry ratio %.2f, %d with inversions\n",
+				compression.Location, compression.Year, compression.FamilyCount,
+				compression.MedianTopToEntry, compression.InvertedFamilies)
+		}(file)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All pay compression analyses completed successfully!")
+	}
+}
+
+func processFile(filepath, outputDir string, opts calculator.CompressionOptions, filter *models.PopulationFilter, tax *taxonomy.Taxonomy, policy *models.SuppressionPolicy) (*models.PayCompression, error) {
+	// Load wage data
+	data, err := parser.LoadWageData(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restrict to the selected population
+	var population *models.PopulationFilter
+	if filter != nil {
+		data, population, err = calculator.FilterPopulation(data, *filter, tax)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	compression, err := calculator.CalculatePayCompression(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if compression == nil {
+		return nil, fmt.Errorf("no valid wage data found")
+	}
+	compression.Population = population
+
+	// Record the suppression policy the levels were compared under
+	if policy != nil {
+		if err := calculator.ApplySuppressionToCompression(compression, *policy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Generate output filename
+	filename := fmt.Sprintf("%s_%d.json",
+		strings.ReplaceAll(data.Location, " ", "_"),
+		data.Year)
+	outputPath := fmt.Sprintf("%s/%s", outputDir, filename)
+
+	if err := parser.SaveJSON(outputPath, compression); err != nil {
+		return nil, err
+	}
+
+	return compression, nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
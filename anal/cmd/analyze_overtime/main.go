@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/overtime", "Output directory for overtime and adjustment analysis")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file (defaults to the bundled taxonomy)")
+	topN := flag.Int("top", 20, "Number of top titles and categories by dollars to include")
+	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	flag.Parse()
+
+	// Load title taxonomy
+	tax, err := taxonomy.Open(*taxonomyFile)
+	if err != nil {
+		log.Fatal("Error loading taxonomy:", err)
+	}
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
+	// Get all JSON files
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	fmt.Printf("Found %d wage files to process\n", len(files))
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	// Analyze files concurrently; year-over-year changes need every year
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(files))
+	var analyses []*models.SupplementalPayAnalysis
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(filepath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			analysis, err := processFile(filepath, tax, *topN)
+			if err != nil {
+				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
+				return
+			}
+
+			mu.Lock()
+			analyses = append(analyses, analysis)
+			mu.Unlock()
+		}(file)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+
+	calculator.ApplySupplementalPayChanges(analyses)
+	sort.Slice(analyses, func(i, j int) bool {
+		if analyses[i].Location == analyses[j].Location {
+			return analyses[i].Year < analyses[j].Year
+		}
+		return analyses[i].Location < analyses[j].Location
+	})
+
+	for _, analysis := range analyses {
+		// Withhold small cells once changes are computed from exact values
+		if policy != nil {
+			if err := calculator.ApplySuppressionToSupplementalPay(analysis, *policy); err != nil {
+				log.Println(err)
+				hasErrors = true
+				continue
+			}
+		}
+
+		filename := fmt.Sprintf("%s_%d.json",
+			strings.ReplaceAll(analysis.Location, " ", "_"),
+			analysis.Year)
+		outputPath := fmt.Sprintf("%s/%s", *outputDir, filename)
+
+		if err := parser.SaveJSON(outputPath, analysis); err != nil {
+			log.Println(err)
+			hasErrors = true
+			continue
+		}
+
+		fmt.Printf("✓ %s %d: %.1f%% receive overtime, top 10%% of recipients hold %.1f%%\n",
+			analysis.Location, analysis.Year,
+			analysis.Overtime.RecipientShare, analysis.Overtime.Top10Share)
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All overtime and adjustment analyses completed successfully!")
+	}
+}
+
+func processFile(filepath string, tax *taxonomy.Taxonomy, topN int) (*models.SupplementalPayAnalysis, error) {
+	// Load wage data
+	data, err := parser.LoadWageData(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := calculator.AnalyzeSupplementalPay(data, tax, topN)
+	if err != nil {
+		return nil, err
+	}
+
+	if analysis == nil {
+		return nil, fmt.Errorf("no valid wage data found")
+	}
+
+	return analysis, nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
@@ -10,7 +10,10 @@ import (
 	"sync"
 
 	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
 )
 
 func main() {
@@ -19,8 +22,80 @@ func main() {
 	outputDir := flag.String("output", "./output/titles", "Output directory for title analysis")
 	topN := flag.Int("top", 100, "Number of top titles to include")
 	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	cpiSeries := flag.String("cpi", "", "CPI series for real-dollar fields (cpi-u, california, sf-bay, los-angeles, regional or a .json file)")
+	baseYear := flag.Int("base-year", 2024, "Base year for real-dollar fields")
+	grouping := flag.String("group", calculator.GroupRaw, "Group titles by raw, normalized or family title")
+	dictionaryFile := flag.String("titles", "", "Title dictionary for normalization (defaults to the bundled dictionary)")
+	rankBy := flag.String("rank", calculator.RankCount, "Comma-separated rankings: count, total_pay, median, mean, max, growth (the first fills top_titles)")
+	minCount := flag.Int("min-count", 0, "Minimum employees for a title to be ranked")
+	population := flag.String("population", "", "Population filter (all, no-students, salaried, full-time or a .json file); unfiltered when empty")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file for category filters (defaults to the bundled taxonomy)")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	dpEpsilon := flag.Float64("dp-epsilon", 0, "Differential privacy epsilon to spend on this release (exact values when 0)")
+	dpDelta := flag.Float64("dp-delta", 1e-6, "Differential privacy delta to spend on choosing which titles to list")
+	dpBound := flag.Float64("dp-bound", 500000, "Pay each record is clipped to for private sums and percentiles")
+	dpLedger := flag.String("dp-ledger", "./output/privacy_budget.json", "Privacy budget ledger shared by the commands of a run")
+	dpBudget := flag.Float64("dp-budget", 1, "Total epsilon of the run when starting a new ledger")
 	flag.Parse()
 
+	// Load title dictionary
+	normalizer, err := taxonomy.OpenNormalizer(*dictionaryFile)
+	if err != nil {
+		log.Fatal("Error loading title dictionary:", err)
+	}
+	opts := calculator.TitleOptions{
+		TopN:       *topN,
+		Grouping:   *grouping,
+		Normalizer: normalizer,
+		RankBy:     parser.SplitList(*rankBy),
+		MinCount:   *minCount,
+	}
+
+	// Resolve population filter
+	filter, err := parser.OpenPopulationFilter(*population, calculator.GetPopulationFilters())
+	if err != nil {
+		log.Fatal("Error loading population filter:", err)
+	}
+	var tax *taxonomy.Taxonomy
+	if filter != nil {
+		tax, err = taxonomy.Open(*taxonomyFile)
+		if err != nil {
+			log.Fatal("Error loading taxonomy:", err)
+		}
+		fmt.Printf("Restricting to the %s population\n", filter.Name)
+	}
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
+	// Charge the privacy budget before releasing anything
+	var privacy *calculator.PrivacyOptions
+	if *dpEpsilon > 0 {
+		for _, rankBy := range opts.RankBy {
+			if rankBy == calculator.RankMax || rankBy == calculator.RankGrowth {
+				log.Fatalf("The %s ranking cannot be released with differential privacy", rankBy)
+			}
+		}
+		budget, err := calculator.ChargePrivacyLedger(*dpLedger, *dpBudget, "analyze_titles", *dpEpsilon, *dpDelta)
+		if err != nil {
+			log.Fatal("Error charging privacy budget:", err)
+		}
+		privacy = &calculator.PrivacyOptions{
+			Epsilon:  *dpEpsilon,
+			Delta:    *dpDelta,
+			PayBound: *dpBound,
+			Taxonomy: tax,
+			Budget:   budget.Epsilon,
+		}
+		fmt.Printf("Releasing with differential privacy (epsilon %g; %g of the run's %g spent)\n", *dpEpsilon, budget.Spent, budget.Epsilon)
+	}
+
 	// Get all JSON files
 	files, err := findWageFiles(*dataDir)
 	if err != nil {
@@ -29,6 +104,19 @@ func main() {
 
 	fmt.Printf("Found %d wage files to process\n", len(files))
 	fmt.Printf("Will extract top %d titles per file\n", *topN)
+	if *grouping != calculator.GroupRaw {
+		fmt.Printf("Grouping by %s title using %s version %s\n", *grouping, normalizer.Name(), normalizer.Version())
+	}
+
+	// Load CPI series if real-dollar output was requested
+	var adjuster *inflation.Adjuster
+	if *cpiSeries != "" {
+		adjuster, err = inflation.NewAdjuster(*cpiSeries, *baseYear)
+		if err != nil {
+			log.Fatal("Error loading CPI series:", err)
+		}
+		fmt.Printf("Adjusting for inflation to %d dollars using %s\n", *baseYear, *cpiSeries)
+	}
 
 	// Create output directory
 	if err := os.MkdirAll(*outputDir, 0755); err != nil {
@@ -47,7 +135,7 @@ func main() {
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			if err := processFile(filepath, *outputDir, *topN); err != nil {
+			if err := processFile(filepath, *outputDir, opts, adjuster, filter, tax, policy, privacy); err != nil {
 				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
 			} else {
 				fmt.Printf("✓ Analyzed titles for %s\n", filepath)
@@ -70,15 +158,45 @@ func main() {
 	}
 }
 
-func processFile(filepath, outputDir string, topN int) error {
+func processFile(filepath, outputDir string, opts calculator.TitleOptions, adjuster *inflation.Adjuster, filter *models.PopulationFilter, tax *taxonomy.Taxonomy, policy *models.SuppressionPolicy, privacy *calculator.PrivacyOptions) error {
 	// Load wage data
 	data, err := parser.LoadWageData(filepath)
 	if err != nil {
 		return err
 	}
 
+	// Restrict to the selected population
+	var population *models.PopulationFilter
+	if filter != nil {
+		data, population, err = calculator.FilterPopulation(data, *filter, tax)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Growth rankings compare against the previous year's file, if present
+	for _, rankBy := range opts.RankBy {
+		if rankBy != calculator.RankGrowth {
+			continue
+		}
+		previousFile := previousYearFile(filepath, data.Year)
+		if _, err := os.Stat(previousFile); err == nil {
+			opts.Previous, err = parser.LoadWageData(previousFile)
+			if err != nil {
+				return err
+			}
+			if filter != nil {
+				opts.Previous, _, err = calculator.FilterPopulation(opts.Previous, *filter, tax)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		break
+	}
+
 	// Analyze titles
-	analysis, err := calculator.AnalyzeTitles(data, topN)
+	analysis, err := calculator.AnalyzeTitlesWithOptions(data, opts)
 	if err != nil {
 		return err
 	}
@@ -86,6 +204,28 @@ func processFile(filepath, outputDir string, topN int) error {
 	if analysis == nil {
 		return fmt.Errorf("no valid title data found")
 	}
+	analysis.Population = population
+
+	// Replace exact values with noisy ones
+	if privacy != nil {
+		if err := calculator.ApplyPrivacyToTitles(analysis, data, opts, *privacy); err != nil {
+			return err
+		}
+	}
+
+	// Add real-dollar fields
+	if adjuster != nil {
+		if err := calculator.ApplyInflationToTitles(analysis, adjuster); err != nil {
+			return err
+		}
+	}
+
+	// Withhold small cells
+	if policy != nil {
+		if err := calculator.ApplySuppressionToTitles(analysis, *policy); err != nil {
+			return err
+		}
+	}
 
 	// Generate output filename
 	filename := fmt.Sprintf("%s_%d.json",
@@ -107,6 +247,12 @@ func processFile(filepath, outputDir string, topN int) error {
 	return nil
 }
 
+// previousYearFile returns the path of the same location's wage file for
+// the year before
+func previousYearFile(path string, year int) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf("wages_%d.json", year-1))
+}
+
 func findWageFiles(dataDir string) ([]string, error) {
 	var files []string
 
@@ -10,7 +10,10 @@ import (
 	"sync"
 
 	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
 )
 
 func main() {
@@ -18,8 +21,60 @@ func main() {
 	dataDir := flag.String("data", "../../data", "Path to data directory")
 	outputDir := flag.String("output", "./output/sums", "Output directory for summaries")
 	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	cpiSeries := flag.String("cpi", "", "CPI series for real-dollar fields (cpi-u, california, sf-bay, los-angeles, regional or a .json file)")
+	baseYear := flag.Int("base-year", 2024, "Base year for real-dollar fields")
+	population := flag.String("population", "", "Population filter (all, no-students, salaried, full-time or a .json file); unfiltered when empty")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file for category filters (defaults to the bundled taxonomy)")
+	bootstrapSamples := flag.Int("bootstrap", 0, "Bootstrap resamples for confidence intervals (disabled when 0)")
+	confidence := flag.Float64("confidence", 0.95, "Confidence level for bootstrap intervals")
+	seed := flag.Int64("seed", 1, "Random seed for bootstrap resampling")
+	dpEpsilon := flag.Float64("dp-epsilon", 0, "Differential privacy epsilon to spend on this release (exact values when 0)")
+	dpBound := flag.Float64("dp-bound", 500000, "Pay each record is clipped to for private sums and percentiles")
+	dpLedger := flag.String("dp-ledger", "./output/privacy_budget.json", "Privacy budget ledger shared by the commands of a run")
+	dpBudget := flag.Float64("dp-budget", 1, "Total epsilon of the run when starting a new ledger")
 	flag.Parse()
 
+	// Resolve population filter
+	filter, err := parser.OpenPopulationFilter(*population, calculator.GetPopulationFilters())
+	if err != nil {
+		log.Fatal("Error loading population filter:", err)
+	}
+	var tax *taxonomy.Taxonomy
+	if filter != nil {
+		tax, err = taxonomy.Open(*taxonomyFile)
+		if err != nil {
+			log.Fatal("Error loading taxonomy:", err)
+		}
+		fmt.Printf("Restricting to the %s population\n", filter.Name)
+	}
+
+	bootstrap := calculator.BootstrapOptions{
+		Samples:    *bootstrapSamples,
+		Confidence: *confidence,
+		Seed:       *seed,
+	}
+	if bootstrap.Samples > 0 {
+		fmt.Printf("Bootstrapping %.0f%% confidence intervals from %d resamples (seed %d)\n", bootstrap.Confidence*100, bootstrap.Samples, bootstrap.Seed)
+	}
+
+	// Charge the privacy budget before releasing anything
+	var privacy *calculator.PrivacyOptions
+	if *dpEpsilon > 0 {
+		if bootstrap.Samples > 0 {
+			log.Fatal("Bootstrap intervals cannot be released with differential privacy")
+		}
+		budget, err := calculator.ChargePrivacyLedger(*dpLedger, *dpBudget, "calculate_sums", *dpEpsilon, 0)
+		if err != nil {
+			log.Fatal("Error charging privacy budget:", err)
+		}
+		privacy = &calculator.PrivacyOptions{
+			Epsilon:  *dpEpsilon,
+			PayBound: *dpBound,
+			Budget:   budget.Epsilon,
+		}
+		fmt.Printf("Releasing with differential privacy (epsilon %g; %g of the run's %g spent)\n", *dpEpsilon, budget.Spent, budget.Epsilon)
+	}
+
 	// Get all JSON files
 	files, err := findWageFiles(*dataDir)
 	if err != nil {
@@ -28,6 +83,16 @@ func main() {
 
 	fmt.Printf("Found %d wage files to process\n", len(files))
 
+	// Load CPI series if real-dollar output was requested
+	var adjuster *inflation.Adjuster
+	if *cpiSeries != "" {
+		adjuster, err = inflation.NewAdjuster(*cpiSeries, *baseYear)
+		if err != nil {
+			log.Fatal("Error loading CPI series:", err)
+		}
+		fmt.Printf("Adjusting for inflation to %d dollars using %s\n", *baseYear, *cpiSeries)
+	}
+
 	// Create output directory
 	if err := os.MkdirAll(*outputDir, 0755); err != nil {
 		log.Fatal("Error creating output directory:", err)
@@ -45,7 +110,7 @@ func main() {
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			if err := processFile(filepath, *outputDir); err != nil {
+			if err := processFile(filepath, *outputDir, adjuster, filter, tax, bootstrap, privacy); err != nil {
 				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
 			} else {
 				fmt.Printf("✓ Processed %s\n", filepath)
@@ -68,15 +133,29 @@ func main() {
 	}
 }
 
-func processFile(filepath, outputDir string) error {
+func processFile(filepath, outputDir string, adjuster *inflation.Adjuster, filter *models.PopulationFilter, tax *taxonomy.Taxonomy, bootstrap calculator.BootstrapOptions, privacy *calculator.PrivacyOptions) error {
 	// Load wage data
 	data, err := parser.LoadWageData(filepath)
 	if err != nil {
 		return err
 	}
 
-	// Calculate summary statistics
-	summary, err := calculator.CalculateSummary(data)
+	// Restrict to the selected population
+	var population *models.PopulationFilter
+	if filter != nil {
+		data, population, err = calculator.FilterPopulation(data, *filter, tax)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Calculate summary statistics, with confidence intervals if requested
+	var summary *models.Summary
+	if bootstrap.Samples > 0 {
+		summary, err = calculator.CalculateSummaryWithBootstrap(data, bootstrap)
+	} else {
+		summary, err = calculator.CalculateSummary(data)
+	}
 	if err != nil {
 		return err
 	}
@@ -84,6 +163,21 @@ func processFile(filepath, outputDir string) error {
 	if summary == nil {
 		return fmt.Errorf("no valid wage data found")
 	}
+	summary.Population = population
+
+	// Replace exact values with noisy ones
+	if privacy != nil {
+		if err := calculator.ApplyPrivacyToSummary(summary, data, *privacy); err != nil {
+			return err
+		}
+	}
+
+	// Add real-dollar fields
+	if adjuster != nil {
+		if err := calculator.ApplyInflationToSummary(summary, adjuster); err != nil {
+			return err
+		}
+	}
 
 	// Generate output filename
 	filename := fmt.Sprintf("%s_%d.json",
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+func main() {
+	// Command line flags
+	sumsDir := flag.String("sums", "./output/sums", "Directory of summary files from calculate_sums")
+	outputDir := flag.String("output", "./output/trends", "Output directory for trends")
+	cpiSeries := flag.String("cpi", "", "CPI series for real-dollar fields (cpi-u, california, sf-bay, los-angeles, regional or a .json file)")
+	baseYear := flag.Int("base-year", 2024, "Base year for real-dollar fields")
+	flag.Parse()
+
+	// Load all summaries
+	files, err := filepath.Glob(filepath.Join(*sumsDir, "*.json"))
+	if err != nil {
+		log.Fatal("Error finding summary files:", err)
+	}
+
+	fmt.Printf("Found %d summary files to process\n", len(files))
+
+	var summaries []*models.Summary
+	for _, file := range files {
+		summary, err := loadSummary(file)
+		if err != nil {
+			log.Printf("Error loading %s: %v", file, err)
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// Load CPI series if real-dollar output was requested
+	var adjuster *inflation.Adjuster
+	if *cpiSeries != "" {
+		adjuster, err = inflation.NewAdjuster(*cpiSeries, *baseYear)
+		if err != nil {
+			log.Fatal("Error loading CPI series:", err)
+		}
+		fmt.Printf("Adjusting for inflation to %d dollars using %s\n", *baseYear, *cpiSeries)
+	}
+
+	trends, err := calculator.CalculateTrends(summaries, adjuster)
+	if err != nil {
+		log.Fatal("Error calculating trends:", err)
+	}
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	var hasErrors bool
+	for _, trend := range trends {
+		filename := fmt.Sprintf("%s.json", strings.ReplaceAll(trend.Location, " ", "_"))
+		outputPath := fmt.Sprintf("%s/%s", *outputDir, filename)
+
+		if err := parser.SaveJSON(outputPath, trend); err != nil {
+			log.Println(err)
+			hasErrors = true
+			continue
+		}
+
+		fmt.Printf("✓ %s: %d-%d (%d years)\n", trend.Location, trend.FirstYear, trend.LastYear, len(trend.Points))
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All trends calculated successfully!")
+	}
+}
+
+func loadSummary(filepath string) (*models.Summary, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary models.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+func main() {
+	// Command line flags
+	sumsDir := flag.String("sums", "./output/sums", "Directory of summary files from calculate_sums")
+	pyramidDir := flag.String("pyramid", "./output/pyramid", "Directory of pyramid files from generate_pyramid")
+	outputDir := flag.String("output", "./output/comparisons", "Output directory for comparisons")
+	metrics := flag.String("metrics", "median_pay,headcount,payroll,overtime_share,gini,share_above_200k", "Comma-separated metrics to rank by")
+	exclude := flag.String("exclude", "", "Comma-separated locations to leave out of the rankings")
+	flag.Parse()
+
+	excluded := make(map[string]bool)
+	for _, location := range parser.SplitList(*exclude) {
+		excluded[location] = true
+	}
+
+	// Load summaries and pyramids
+	summaryFiles, err := filepath.Glob(filepath.Join(*sumsDir, "*.json"))
+	if err != nil {
+		log.Fatal("Error finding summary files:", err)
+	}
+
+	pyramidFiles, err := filepath.Glob(filepath.Join(*pyramidDir, "*.json"))
+	if err != nil {
+		log.Fatal("Error finding pyramid files:", err)
+	}
+
+	fmt.Printf("Found %d summary and %d pyramid files\n", len(summaryFiles), len(pyramidFiles))
+
+	var summaries []*models.Summary
+	for _, file := range summaryFiles {
+		summary := &models.Summary{}
+		if err := parser.LoadJSON(file, summary); err != nil {
+			log.Println(err)
+			continue
+		}
+		if !excluded[summary.Location] {
+			summaries = append(summaries, summary)
+		}
+	}
+
+	var pyramids []*models.Pyramid
+	for _, file := range pyramidFiles {
+		pyramid := &models.Pyramid{}
+		if err := parser.LoadJSON(file, pyramid); err != nil {
+			log.Println(err)
+			continue
+		}
+		pyramids = append(pyramids, pyramid)
+	}
+
+	comparison, err := calculator.CalculateComparisons(summaries, pyramids, parser.SplitList(*metrics))
+	if err != nil {
+		log.Fatal("Error calculating comparisons:", err)
+	}
+
+	outputPath := fmt.Sprintf("%s/rankings.json", *outputDir)
+	if err := parser.SaveJSON(outputPath, comparison); err != nil {
+		log.Fatal("Error saving comparisons:", err)
+	}
+
+	fmt.Printf("✓ Ranked %d locations over %d years by %d metrics\n",
+		len(comparison.Locations), len(comparison.Years), len(comparison.Metrics))
+	fmt.Println("\n✅ Campus comparisons generated successfully!")
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+var yearPattern = regexp.MustCompile(`wages_(\d{4})\.json$`)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/title_comparisons", "Output directory for cross-campus title comparisons")
+	grouping := flag.String("group", calculator.GroupNormalized, "Group titles by raw, normalized or family title")
+	dictionaryFile := flag.String("titles", "", "Title dictionary for normalization (defaults to the bundled dictionary)")
+	minCount := flag.Int("min-count", 5, "Minimum employees with a title for a location to be compared")
+	minLocations := flag.Int("min-locations", 2, "Minimum qualifying locations for a title to be reported")
+	topN := flag.Int("top", 200, "Number of titles to include per year (0 for all)")
+	exclude := flag.String("exclude", "", "Comma-separated locations to leave out of the comparison")
+	workers := flag.Int("workers", 2, "Number of years processed concurrently")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	flag.Parse()
+
+	// Load title dictionary
+	normalizer, err := taxonomy.OpenNormalizer(*dictionaryFile)
+	if err != nil {
+		log.Fatal("Error loading title dictionary:", err)
+	}
+	opts := calculator.TitleComparisonOptions{
+		Grouping:     *grouping,
+		Normalizer:   normalizer,
+		MinCount:     *minCount,
+		MinLocations: *minLocations,
+		TopN:         *topN,
+	}
+
+	excluded := make(map[string]bool)
+	for _, location := range parser.SplitList(*exclude) {
+		excluded[location] = true
+	}
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+
+		// Publish pay only for locations meeting the policy's minimum
+		opts.MinCount = calculator.ComparisonMinCount(opts.MinCount, *policy)
+	}
+
+	// Get all JSON files grouped by year
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	filesByYear := make(map[int][]string)
+	for _, file := range files {
+		match := yearPattern.FindStringSubmatch(file)
+		if match == nil {
+			continue
+		}
+		year, _ := strconv.Atoi(match[1])
+		filesByYear[year] = append(filesByYear[year], file)
+	}
+
+	fmt.Printf("Found %d wage files across %d years\n", len(files), len(filesByYear))
+	fmt.Printf("Comparing %s titles held by at least %d employees at %d or more locations\n", *grouping, *minCount, *minLocations)
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	// Process years concurrently
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(filesByYear))
+
+	for year, yearFiles := range filesByYear {
+		wg.Add(1)
+		go func(year int, yearFiles []string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := processYear(year, yearFiles, excluded, *outputDir, opts, policy); err != nil {
+				errorsChan <- fmt.Errorf("error processing %d: %w", year, err)
+			}
+		}(year, yearFiles)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All title comparisons generated successfully!")
+	}
+}
+
+func processYear(year int, files []string, excluded map[string]bool, outputDir string, opts calculator.TitleComparisonOptions, policy *models.SuppressionPolicy) error {
+	// Load every location for the year
+	var datasets []*models.WageData
+	for _, file := range files {
+		data, err := parser.LoadWageData(file)
+		if err != nil {
+			return err
+		}
+		if excluded[data.Location] {
+			continue
+		}
+		datasets = append(datasets, data)
+	}
+
+	if len(datasets) < opts.MinLocations {
+		fmt.Printf("- Skipped %d: only %d locations\n", year, len(datasets))
+		return nil
+	}
+
+	report, err := calculator.CompareTitlesAcrossLocations(year, datasets, opts)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return nil
+	}
+
+	// Withhold small cells
+	if policy != nil {
+		if err := calculator.ApplySuppressionToTitleComparisons(report, *policy); err != nil {
+			return err
+		}
+	}
+
+	filename := fmt.Sprintf("%d.json", year)
+	if err := parser.SaveJSON(fmt.Sprintf("%s/%s", outputDir, filename), report); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Compared %d titles across %d locations for %d\n", len(report.Titles), len(report.Locations), year)
+	return nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/taxonomy", "Output directory for the taxonomy report")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file (defaults to the bundled taxonomy)")
+	showConflicts := flag.Int("show", 20, "Number of conflicting titles to print")
+	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	flag.Parse()
+
+	// Load title taxonomy
+	tax, err := taxonomy.Open(*taxonomyFile)
+	if err != nil {
+		log.Fatal("Error loading taxonomy:", err)
+	}
+	fmt.Printf("Using taxonomy %s version %s (%d rules)\n", tax.Name(), tax.Version(), len(tax.Rules()))
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
+	// Get all JSON files
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	fmt.Printf("Found %d wage files to process\n", len(files))
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	// Tally titles across files concurrently
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(files))
+	tallies := make(map[string]*calculator.TitleTally)
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(filepath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			data, err := parser.LoadWageData(filepath)
+			if err != nil {
+				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
+				return
+			}
+
+			mu.Lock()
+			calculator.TallyTitles(data, tallies)
+			mu.Unlock()
+		}(file)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+	if hasErrors {
+		log.Fatal("Error tallying titles")
+	}
+
+	report := calculator.ExplainTaxonomy(tax, tallies, len(files))
+
+	// Withhold small cells
+	if policy != nil {
+		if err := calculator.ApplySuppressionToTaxonomyReport(report, *policy); err != nil {
+			log.Fatal("Error applying suppression policy:", err)
+		}
+	}
+
+	outputPath := fmt.Sprintf("%s/report.json", *outputDir)
+	if err := parser.SaveJSON(outputPath, report); err != nil {
+		log.Fatal("Error saving taxonomy report:", err)
+	}
+
+	// Print summary
+	fmt.Printf("\nClassified %d titles (%d records)\n", report.UniqueTitles, report.Records)
+	for _, category := range report.Categories {
+		fmt.Printf("  %-50s %8d  %5.1f%%\n", category.Category, category.Count, category.Percentage)
+	}
+
+	if len(report.UnusedRules) > 0 {
+		fmt.Printf("\nRules that matched no titles: %s\n", strings.Join(report.UnusedRules, ", "))
+	}
+
+	fmt.Printf("\n%d titles were decided by rule order alone\n", report.Conflicts)
+	shown := 0
+	for _, title := range report.Titles {
+		if !title.Conflict || shown >= *showConflicts {
+			break
+		}
+		var rivals []string
+		for _, other := range title.Overridden {
+			if other.Priority == title.Rule.Priority && other.Category != title.Rule.Category {
+				rivals = append(rivals, fmt.Sprintf("%s (%s)", other.RuleID, other.Category))
+			}
+		}
+		fmt.Printf("  ⚠ %s [%d]: %s (%s) over %s\n",
+			title.Title, title.Count,
+			title.Rule.RuleID, title.Rule.Category,
+			strings.Join(rivals, ", "))
+		shown++
+	}
+
+	fmt.Printf("\n✅ Taxonomy report written to %s\n", outputPath)
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/outliers", "Output directory for outlier reports")
+	topN := flag.Int("top", 50, "Number of top earners to include")
+	threshold := flag.Float64("mad", 5, "Flag records more than this many median absolute deviations from their title's median")
+	minCount := flag.Int("min-count", 10, "Minimum employees for a title to be tested for outliers")
+	maxOutliers := flag.Int("max-outliers", 200, "Maximum outliers to list per file (0 for all)")
+	hideNames := flag.Bool("hide-names", false, "Leave names out even when the source data includes them")
+	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	flag.Parse()
+
+	if *minCount < 2 {
+		log.Fatal("-min-count must be at least 2 for a title's spread to be measured")
+	}
+
+	opts := calculator.OutlierOptions{
+		TopN:          *topN,
+		MADThreshold:  *threshold,
+		MinTitleCount: *minCount,
+		MaxOutliers:   *maxOutliers,
+		HideNames:     *hideNames,
+	}
+
+	// Get all JSON files
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	fmt.Printf("Found %d wage files to process\n", len(files))
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	// Process files concurrently
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(files))
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(filepath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := processFile(filepath, *outputDir, opts); err != nil {
+				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
+			}
+		}(file)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All outlier reports generated successfully!")
+	}
+}
+
+func processFile(filepath, outputDir string, opts calculator.OutlierOptions) error {
+	// Load wage data
+	data, err := parser.LoadWageData(filepath)
+	if err != nil {
+		return err
+	}
+
+	// Find outliers
+	report, err := calculator.FindOutliers(data, opts)
+	if err != nil {
+		return err
+	}
+
+	if report == nil {
+		return fmt.Errorf("no valid wage data found")
+	}
+
+	// Generate output filename
+	filename := fmt.Sprintf("%s_%d.json",
+		strings.ReplaceAll(data.Location, " ", "_"),
+		data.Year)
+	outputPath := fmt.Sprintf("%s/%s", outputDir, filename)
+
+	// Save report
+	if err := parser.SaveJSON(outputPath, report); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ %s %d: %d outliers, %d titles with overtime above base\n",
+		data.Location, data.Year, report.OutlierCount, len(report.OvertimeTitles))
+
+	return nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
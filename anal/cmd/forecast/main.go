@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+func main() {
+	// Command line flags
+	sumsDir := flag.String("sums", "./output/sums", "Directory of summary files from calculate_sums or aggregate_system")
+	outputDir := flag.String("output", "./output/forecasts", "Output directory for forecasts")
+	horizon := flag.Int("horizon", 5, "Number of years to project")
+	confidence := flag.Float64("confidence", 0.95, "Prediction interval level")
+	holdout := flag.Int("holdout", 3, "Years held out to score models (0 uses AIC instead)")
+	minYears := flag.Int("min-years", 5, "Minimum years of summaries for a location to be forecast")
+	cpiSeries := flag.String("cpi", "", "CPI series for real-dollar forecasts (cpi-u, california, sf-bay, los-angeles, regional or a .json file)")
+	baseYear := flag.Int("base-year", 2024, "Base year for real-dollar forecasts")
+	flag.Parse()
+
+	// Load all summaries
+	files, err := filepath.Glob(filepath.Join(*sumsDir, "*.json"))
+	if err != nil {
+		log.Fatal("Error finding summary files:", err)
+	}
+
+	fmt.Printf("Found %d summary files to process\n", len(files))
+
+	var summaries []*models.Summary
+	for _, file := range files {
+		summary, err := loadSummary(file)
+		if err != nil {
+			log.Printf("Error loading %s: %v", file, err)
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// Load CPI series if real-dollar output was requested
+	var adjuster *inflation.Adjuster
+	if *cpiSeries != "" {
+		adjuster, err = inflation.NewAdjuster(*cpiSeries, *baseYear)
+		if err != nil {
+			log.Fatal("Error loading CPI series:", err)
+		}
+		fmt.Printf("Forecasting in %d dollars using %s\n", *baseYear, *cpiSeries)
+	}
+
+	// A zero holdout flag means none, not the default
+	if *holdout == 0 {
+		*holdout = -1
+	}
+
+	forecasts, err := calculator.CalculateForecasts(summaries, adjuster, calculator.ForecastOptions{
+		Horizon:    *horizon,
+		Confidence: *confidence,
+		Holdout:    *holdout,
+		MinYears:   *minYears,
+	})
+	if err != nil {
+		log.Fatal("Error calculating forecasts:", err)
+	}
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	var hasErrors bool
+	for _, forecast := range forecasts {
+		filename := fmt.Sprintf("%s.json", strings.ReplaceAll(forecast.Location, " ", "_"))
+		outputPath := fmt.Sprintf("%s/%s", *outputDir, filename)
+
+		if err := parser.SaveJSON(outputPath, forecast); err != nil {
+			log.Println(err)
+			hasErrors = true
+			continue
+		}
+
+		fmt.Printf("✓ %s: %d-%d, projected to %d\n", forecast.Location, forecast.FirstYear, forecast.LastYear, forecast.LastYear+forecast.Horizon)
+		for _, series := range forecast.Series {
+			fmt.Printf("  - %s: best fit %s\n", series.Metric, series.Best)
+		}
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All forecasts calculated successfully!")
+	}
+}
+
+func loadSummary(filepath string) (*models.Summary, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary models.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/histograms", "Output directory for histograms")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file (defaults to the bundled taxonomy)")
+	binWidth := flag.Float64("bin-width", 5000, "Linear bin width in dollars")
+	logBins := flag.Bool("log", false, "Use log-spaced bins and a log-scale density")
+	binsPerDecade := flag.Int("bins-per-decade", 20, "Log bins per power of ten")
+	clip := flag.Float64("clip", 99.5, "Percentile of pay where linear bins stop; higher pay is counted as overflow")
+	kdePoints := flag.Int("kde-points", 200, "Number of points the kernel density is evaluated at")
+	bandwidth := flag.Float64("bandwidth", 0, "Kernel bandwidth in dollars, or log10 dollars with -log (0 uses Silverman's rule)")
+	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	flag.Parse()
+
+	// Load title taxonomy
+	tax, err := taxonomy.Open(*taxonomyFile)
+	if err != nil {
+		log.Fatal("Error loading taxonomy:", err)
+	}
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
+	opts := calculator.HistogramOptions{
+		BinWidth:       *binWidth,
+		LogBins:        *logBins,
+		BinsPerDecade:  *binsPerDecade,
+		ClipPercentile: *clip,
+		KDEPoints:      *kdePoints,
+		Bandwidth:      *bandwidth,
+	}
+
+	// Get all JSON files
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	fmt.Printf("Found %d wage files to process\n", len(files))
+	if *logBins {
+		fmt.Printf("Using %d log bins per decade\n", *binsPerDecade)
+	} else {
+		fmt.Printf("Using $%.0f bins up to the %gth percentile\n", *binWidth, *clip)
+	}
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	// Process files concurrently
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(files))
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(filepath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := processFile(filepath, *outputDir, tax, opts, policy); err != nil {
+				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
+			} else {
+				fmt.Printf("✓ Generated histogram for %s\n", filepath)
+			}
+		}(file)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All histograms generated successfully!")
+	}
+}
+
+func processFile(filepath, outputDir string, tax *taxonomy.Taxonomy, opts calculator.HistogramOptions, policy *models.SuppressionPolicy) error {
+	// Load wage data
+	data, err := parser.LoadWageData(filepath)
+	if err != nil {
+		return err
+	}
+
+	histogram, err := calculator.CalculateHistograms(data, tax, opts)
+	if err != nil {
+		return err
+	}
+
+	if histogram == nil {
+		return fmt.Errorf("no valid wage data found")
+	}
+
+	// Withhold small cells
+	if policy != nil {
+		if err := calculator.ApplySuppressionToHistogram(histogram, *policy); err != nil {
+			return err
+		}
+	}
+
+	// Generate output filename
+	filename := fmt.Sprintf("%s_%d.json",
+		strings.ReplaceAll(data.Location, " ", "_"),
+		data.Year)
+	outputPath := fmt.Sprintf("%s/%s", outputDir, filename)
+
+	// Save histogram
+	if err := parser.SaveCompactJSON(outputPath, histogram); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
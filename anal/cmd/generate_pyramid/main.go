@@ -10,7 +10,10 @@ import (
 	"sync"
 
 	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
 )
 
 func main() {
@@ -18,8 +21,28 @@ func main() {
 	dataDir := flag.String("data", "../../data", "Path to data directory")
 	outputDir := flag.String("output", "./output/pyramid", "Output directory for pyramids")
 	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	bracketScheme := flag.String("brackets", calculator.DefaultBracketScheme, "Bracket scheme (standard, fine, log, quintiles, deciles or a .json file)")
+	cpiSeries := flag.String("cpi", "", "CPI series for real-dollar fields (cpi-u, california, sf-bay, los-angeles, regional or a .json file)")
+	baseYear := flag.Int("base-year", 2024, "Base year for real-dollar fields")
+	population := flag.String("population", "", "Population filter (all, no-students, salaried, full-time or a .json file); unfiltered when empty")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file for bracket categories and category filters (defaults to the bundled taxonomy)")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	dpEpsilon := flag.Float64("dp-epsilon", 0, "Differential privacy epsilon to spend on this release (exact values when 0)")
+	dpDelta := flag.Float64("dp-delta", 1e-6, "Differential privacy delta to spend on choosing which titles to list")
+	dpBound := flag.Float64("dp-bound", 500000, "Pay each record is clipped to for private sums and percentiles")
+	dpLedger := flag.String("dp-ledger", "./output/privacy_budget.json", "Privacy budget ledger shared by the commands of a run")
+	dpBudget := flag.Float64("dp-budget", 1, "Total epsilon of the run when starting a new ledger")
 	flag.Parse()
 
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
 	// Get all JSON files
 	files, err := findWageFiles(*dataDir)
 	if err != nil {
@@ -28,6 +51,57 @@ func main() {
 
 	fmt.Printf("Found %d wage files to process\n", len(files))
 
+	// Resolve bracket scheme
+	scheme, err := loadScheme(*bracketScheme)
+	if err != nil {
+		log.Fatal("Error loading bracket scheme:", err)
+	}
+	fmt.Printf("Using %s bracket scheme\n", scheme.Name)
+
+	// Resolve population filter
+	filter, err := parser.OpenPopulationFilter(*population, calculator.GetPopulationFilters())
+	if err != nil {
+		log.Fatal("Error loading population filter:", err)
+	}
+
+	// Load title taxonomy for bracket categories and category filters
+	tax, err := taxonomy.Open(*taxonomyFile)
+	if err != nil {
+		log.Fatal("Error loading taxonomy:", err)
+	}
+	if filter != nil {
+		fmt.Printf("Restricting to the %s population\n", filter.Name)
+	}
+
+	// Load CPI series if real-dollar output was requested
+	var adjuster *inflation.Adjuster
+	if *cpiSeries != "" {
+		adjuster, err = inflation.NewAdjuster(*cpiSeries, *baseYear)
+		if err != nil {
+			log.Fatal("Error loading CPI series:", err)
+		}
+		fmt.Printf("Adjusting for inflation to %d dollars using %s\n", *baseYear, *cpiSeries)
+	}
+
+	// Charge the privacy budget before releasing anything
+	var privacy *calculator.PrivacyOptions
+	if *dpEpsilon > 0 {
+		if scheme.Type == "quantile" {
+			log.Fatal("Quantile bracket schemes take their edges from the data and cannot be released with differential privacy")
+		}
+		budget, err := calculator.ChargePrivacyLedger(*dpLedger, *dpBudget, "generate_pyramid", *dpEpsilon, *dpDelta)
+		if err != nil {
+			log.Fatal("Error charging privacy budget:", err)
+		}
+		privacy = &calculator.PrivacyOptions{
+			Epsilon:  *dpEpsilon,
+			Delta:    *dpDelta,
+			PayBound: *dpBound,
+			Budget:   budget.Epsilon,
+		}
+		fmt.Printf("Releasing with differential privacy (epsilon %g; %g of the run's %g spent)\n", *dpEpsilon, budget.Spent, budget.Epsilon)
+	}
+
 	// Create output directory
 	if err := os.MkdirAll(*outputDir, 0755); err != nil {
 		log.Fatal("Error creating output directory:", err)
@@ -45,7 +119,7 @@ func main() {
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			if err := processFile(filepath, *outputDir); err != nil {
+			if err := processFile(filepath, *outputDir, scheme, adjuster, filter, tax, policy, privacy); err != nil {
 				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
 			} else {
 				fmt.Printf("✓ Generated pyramid for %s\n", filepath)
@@ -65,19 +139,28 @@ func main() {
 
 	if !hasErrors {
 		fmt.Println("\n✅ All pyramids generated successfully!")
-		printBracketInfo()
+		printBracketInfo(scheme)
 	}
 }
 
-func processFile(filepath, outputDir string) error {
+func processFile(filepath, outputDir string, scheme models.BracketScheme, adjuster *inflation.Adjuster, filter *models.PopulationFilter, tax *taxonomy.Taxonomy, policy *models.SuppressionPolicy, privacy *calculator.PrivacyOptions) error {
 	// Load wage data
 	data, err := parser.LoadWageData(filepath)
 	if err != nil {
 		return err
 	}
 
+	// Restrict to the selected population
+	var population *models.PopulationFilter
+	if filter != nil {
+		data, population, err = calculator.FilterPopulation(data, *filter, tax)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Generate pyramid
-	pyramid, err := calculator.CalculatePyramid(data)
+	pyramid, err := calculator.CalculatePyramidWithScheme(data, scheme, tax)
 	if err != nil {
 		return err
 	}
@@ -85,6 +168,28 @@ func processFile(filepath, outputDir string) error {
 	if pyramid == nil {
 		return fmt.Errorf("no valid wage data found")
 	}
+	pyramid.Population = population
+
+	// Replace exact values with noisy ones
+	if privacy != nil {
+		if err := calculator.ApplyPrivacyToPyramid(pyramid, data, *privacy); err != nil {
+			return err
+		}
+	}
+
+	// Add real-dollar fields
+	if adjuster != nil {
+		if err := calculator.ApplyInflationToPyramid(pyramid, adjuster); err != nil {
+			return err
+		}
+	}
+
+	// Withhold small cells
+	if policy != nil {
+		if err := calculator.ApplySuppressionToPyramid(pyramid, *policy); err != nil {
+			return err
+		}
+	}
 
 	// Generate output filename
 	filename := fmt.Sprintf("%s_%d.json",
@@ -121,10 +226,38 @@ func findWageFiles(dataDir string) ([]string, error) {
 	return files, err
 }
 
-func printBracketInfo() {
-	fmt.Println("\nWage Brackets Used:")
-	brackets := calculator.GetWageBrackets()
+func loadScheme(name string) (models.BracketScheme, error) {
+	if strings.HasSuffix(name, ".json") {
+		scheme, err := parser.LoadBracketScheme(name)
+		if err != nil {
+			return models.BracketScheme{}, err
+		}
+		return *scheme, nil
+	}
+
+	scheme, ok := calculator.GetBracketSchemes()[name]
+	if !ok {
+		return models.BracketScheme{}, fmt.Errorf("unknown bracket scheme %q", name)
+	}
+	return scheme, nil
+}
+
+func printBracketInfo(scheme models.BracketScheme) {
+	fmt.Printf("\nWage Brackets Used (%s):\n", scheme.Name)
+	if scheme.Type == "quantile" {
+		fmt.Printf("  • %d equal-population groups, edges vary per file\n", scheme.Groups)
+		return
+	}
+
+	brackets, _, err := calculator.ResolveBrackets(scheme, nil)
+	if err != nil {
+		return
+	}
 	for _, bracket := range brackets {
+		if bracket.OpenEnded {
+			fmt.Printf("  • %s: $%.0f and above\n", bracket.Range, bracket.MinValue)
+			continue
+		}
 		fmt.Printf("  • %s: $%.0f - $%.0f\n", bracket.Range, bracket.MinValue, bracket.MaxValue)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+var yearPattern = regexp.MustCompile(`wages_(\d{4})\.json$`)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/linkage", "Output directory for employee linkage statistics")
+	dictionaryFile := flag.String("titles", "", "Title dictionary for normalization (defaults to the bundled dictionary)")
+	minConfidence := flag.Float64("min-confidence", 0.8, "Minimum confidence for a link between years")
+	margin := flag.Float64("margin", 0.05, "Minimum confidence margin over the next best candidate")
+	minBasePay := flag.Float64("min-base-pay", 33280, "Minimum base pay in both years for a raise to be counted")
+	minLinks := flag.Int("min-links", 10, "Minimum employees for a raise distribution to be reported")
+	flag.Parse()
+
+	// Load title dictionary
+	normalizer, err := taxonomy.OpenNormalizer(*dictionaryFile)
+	if err != nil {
+		log.Fatal("Error loading title dictionary:", err)
+	}
+	opts := calculator.LinkageOptions{
+		Normalizer:      normalizer,
+		MinConfidence:   *minConfidence,
+		AmbiguityMargin: *margin,
+		MinBasePay:      *minBasePay,
+		MinLinks:        *minLinks,
+	}
+
+	// Get all JSON files grouped by year
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	filesByYear := make(map[int][]string)
+	for _, file := range files {
+		match := yearPattern.FindStringSubmatch(file)
+		if match == nil {
+			continue
+		}
+		year, _ := strconv.Atoi(match[1])
+		filesByYear[year] = append(filesByYear[year], file)
+	}
+
+	years := make([]int, 0, len(filesByYear))
+	for year := range filesByYear {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+
+	fmt.Printf("Found %d wage files across %d years\n", len(files), len(years))
+
+	// Link each year to the next, keeping only two years in memory
+	var linkages []models.YearLinkage
+	var previous *calculator.LinkageYear
+	for _, year := range years {
+		current, err := prepareYear(year, filesByYear[year], opts)
+		if err != nil {
+			log.Fatalf("Error processing %d: %v", year, err)
+		}
+
+		if previous != nil && previous.Year == year-1 {
+			yearLinkages := calculator.LinkYears(previous, current, opts)
+			linkages = append(linkages, yearLinkages...)
+
+			system := yearLinkages[len(yearLinkages)-1]
+			fmt.Printf("✓ Linked %d-%d: %d of %d named employees (%.1f%%), %d ambiguous\n",
+				previous.Year, year, system.Linked, system.NamedRecords, system.LinkRate, system.Ambiguous)
+		}
+		previous = current
+	}
+
+	reports := calculator.BuildLinkageReports(linkages, opts)
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	var hasErrors bool
+	for _, report := range reports {
+		filename := fmt.Sprintf("%s.json", strings.ReplaceAll(report.Location, " ", "_"))
+		outputPath := fmt.Sprintf("%s/%s", *outputDir, filename)
+
+		if err := parser.SaveJSON(outputPath, report); err != nil {
+			log.Println(err)
+			hasErrors = true
+			continue
+		}
+
+		fmt.Printf("✓ %s: %d year pairs\n", report.Location, len(report.Years))
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ Employee linkage completed successfully!")
+	}
+}
+
+func prepareYear(year int, files []string, opts calculator.LinkageOptions) (*calculator.LinkageYear, error) {
+	// Load every location for the year
+	var datasets []*models.WageData
+	for _, file := range files {
+		data, err := parser.LoadWageData(file)
+		if err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, data)
+	}
+
+	return calculator.PrepareLinkageYear(year, datasets, opts), nil
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
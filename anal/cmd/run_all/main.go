@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
 )
 
 func main() {
@@ -14,6 +16,13 @@ func main() {
 	dataDir := flag.String("data", "../../data", "Path to data directory")
 	outputDir := flag.String("output", "./output", "Base output directory")
 	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	cpiSeries := flag.String("cpi", "", "CPI series for real-dollar fields (disabled when empty)")
+	baseYear := flag.Int("base-year", 2024, "Base year for real-dollar fields")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file (defaults to the bundled taxonomy)")
+	population := flag.String("population", "", "Population filter for summaries, pyramids and titles (all, no-students, salaried, full-time or a .json file)")
+	suppression := flag.String("suppression", "suppress", "Small-cell suppression policy for every output publishing title, bracket or category cells; find_outliers still lists individual records (none, suppress, coarsen, strict or a .json file)")
+	dpEpsilon := flag.Float64("dp-epsilon", 0, "Total differential privacy epsilon for the run; only noisy summaries, pyramids and titles are released when set")
+	dpBound := flag.Float64("dp-bound", 500000, "Pay each record is clipped to for private sums and percentiles")
 	flag.Parse()
 
 	fmt.Println("🚀 UC Wages Analysis Pipeline")
@@ -26,6 +35,20 @@ func main() {
 		fmt.Sprintf("%s/sums", *outputDir),
 		fmt.Sprintf("%s/pyramid", *outputDir),
 		fmt.Sprintf("%s/titles", *outputDir),
+		fmt.Sprintf("%s/trends", *outputDir),
+		fmt.Sprintf("%s/distributions", *outputDir),
+		fmt.Sprintf("%s/system", *outputDir),
+		fmt.Sprintf("%s/comparisons", *outputDir),
+		fmt.Sprintf("%s/categories", *outputDir),
+		fmt.Sprintf("%s/taxonomy", *outputDir),
+		fmt.Sprintf("%s/title_comparisons", *outputDir),
+		fmt.Sprintf("%s/title_trajectories", *outputDir),
+		fmt.Sprintf("%s/outliers", *outputDir),
+		fmt.Sprintf("%s/overtime", *outputDir),
+		fmt.Sprintf("%s/forecasts", *outputDir),
+		fmt.Sprintf("%s/histograms", *outputDir),
+		fmt.Sprintf("%s/compression", *outputDir),
+		fmt.Sprintf("%s/flows", *outputDir),
 	}
 
 	for _, dir := range dirs {
@@ -34,30 +57,175 @@ func main() {
 		}
 	}
 
-	// Run each analysis
+	// Inflation flags shared by every analysis that reports dollars
+	var cpiArgs []string
+	if *cpiSeries != "" {
+		cpiArgs = []string{"-cpi", *cpiSeries, "-base-year", fmt.Sprintf("%d", *baseYear)}
+	}
+
+	// Taxonomy flag shared by every analysis that categorizes titles
+	var taxonomyArgs []string
+	if *taxonomyFile != "" {
+		taxonomyArgs = []string{"-taxonomy", *taxonomyFile}
+	}
+
+	// Population flags shared by the summary, pyramid and title analyses so
+	// they all describe the same employees
+	var populationArgs []string
+	if *population != "" {
+		populationArgs = append([]string{"-population", *population}, taxonomyArgs...)
+	}
+
+	// Pyramids and system aggregates categorize bracket titles with the
+	// taxonomy even without a population filter
+	bracketArgs := populationArgs
+	if *population == "" {
+		bracketArgs = taxonomyArgs
+	}
+
+	// Suppression flag shared by every analysis publishing title, bracket
+	// or category cells
+	var suppressionArgs []string
+	if *suppression != "" {
+		suppressionArgs = []string{"-suppression", *suppression}
+	}
+
+	// Privacy flags. The run starts a fresh budget ledger and splits its
+	// epsilon between the four commands reading raw records by
+	// calculator.PrivacyRunShares, and its delta evenly between the three
+	// that list titles.
+	ledger := fmt.Sprintf("%s/privacy_budget.json", *outputDir)
+	privacyArgs := func(command string) []string {
+		if *dpEpsilon <= 0 {
+			return nil
+		}
+		args := []string{
+			"-dp-epsilon", fmt.Sprintf("%g", *dpEpsilon*calculator.PrivacyRunShares()[command]),
+			"-dp-bound", fmt.Sprintf("%g", *dpBound),
+			"-dp-ledger", ledger,
+			"-dp-budget", fmt.Sprintf("%g", *dpEpsilon),
+		}
+		if command != "calculate_sums" {
+			args = append(args, "-dp-delta", fmt.Sprintf("%g", calculator.DefaultPrivacyBudgetDelta/3))
+		}
+		return args
+	}
+	if *dpEpsilon > 0 {
+		if err := os.Remove(ledger); err != nil && !os.IsNotExist(err) {
+			log.Fatal("Error resetting privacy budget:", err)
+		}
+		fmt.Printf("Releasing with differential privacy (epsilon %g)\n", *dpEpsilon)
+	}
+
+	// Run each analysis. Private analyses either add privacy noise or only
+	// read the output of those that do; the rest are skipped when releasing
+	// privately.
 	analyses := []struct {
 		name    string
 		command string
 		args    []string
+		private bool
 	}{
 		{
 			name:    "Summary Statistics",
 			command: "calculate_sums",
-			args:    []string{"-data", *dataDir, "-output", fmt.Sprintf("%s/sums", *outputDir), "-workers", fmt.Sprintf("%d", *workers)},
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/sums", *outputDir), "-workers", fmt.Sprintf("%d", *workers)}, append(append(cpiArgs, populationArgs...), privacyArgs("calculate_sums")...)...),
+			private: true,
 		},
 		{
 			name:    "Wage Pyramids",
 			command: "generate_pyramid",
-			args:    []string{"-data", *dataDir, "-output", fmt.Sprintf("%s/pyramid", *outputDir), "-workers", fmt.Sprintf("%d", *workers)},
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/pyramid", *outputDir), "-workers", fmt.Sprintf("%d", *workers)}, append(append(append(cpiArgs, bracketArgs...), suppressionArgs...), privacyArgs("generate_pyramid")...)...),
+			private: true,
 		},
 		{
 			name:    "Title Analysis",
 			command: "analyze_titles",
-			args:    []string{"-data", *dataDir, "-output", fmt.Sprintf("%s/titles", *outputDir), "-workers", fmt.Sprintf("%d", *workers), "-top", "100"},
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/titles", *outputDir), "-workers", fmt.Sprintf("%d", *workers), "-top", "100"}, append(append(append(cpiArgs, populationArgs...), suppressionArgs...), privacyArgs("analyze_titles")...)...),
+			private: true,
+		},
+		{
+			name:    "Distribution Analysis",
+			command: "analyze_distributions",
+			args:    []string{"-data", *dataDir, "-output", fmt.Sprintf("%s/distributions", *outputDir), "-workers", fmt.Sprintf("%d", *workers)},
+		},
+		{
+			name:    "System-wide Aggregates",
+			command: "aggregate_system",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/system", *outputDir), "-top", "100"}, append(append(append(cpiArgs, bracketArgs...), suppressionArgs...), privacyArgs("aggregate_system")...)...),
+			private: true,
+		},
+		{
+			name:    "Trends",
+			command: "calculate_trends",
+			args:    append([]string{"-sums", fmt.Sprintf("%s/sums", *outputDir), "-output", fmt.Sprintf("%s/trends", *outputDir)}, cpiArgs...),
+			private: true,
+		},
+		{
+			name:    "Campus Comparisons",
+			command: "compare_campuses",
+			args:    []string{"-sums", fmt.Sprintf("%s/sums", *outputDir), "-pyramid", fmt.Sprintf("%s/pyramid", *outputDir), "-output", fmt.Sprintf("%s/comparisons", *outputDir)},
+			private: true,
+		},
+		{
+			name:    "Category Analysis",
+			command: "analyze_categories",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/categories", *outputDir), "-workers", fmt.Sprintf("%d", *workers)}, append(taxonomyArgs, suppressionArgs...)...),
+		},
+		{
+			name:    "Taxonomy Report",
+			command: "explain_taxonomy",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/taxonomy", *outputDir), "-workers", fmt.Sprintf("%d", *workers)}, append(taxonomyArgs, suppressionArgs...)...),
+		},
+		{
+			name:    "Cross-campus Title Comparison",
+			command: "compare_titles",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/title_comparisons", *outputDir)}, suppressionArgs...),
+		},
+		{
+			name:    "Title Trajectories",
+			command: "track_titles",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/title_trajectories", *outputDir)}, suppressionArgs...),
+		},
+		{
+			name:    "Outlier Report",
+			command: "find_outliers",
+			args:    []string{"-data", *dataDir, "-output", fmt.Sprintf("%s/outliers", *outputDir), "-workers", fmt.Sprintf("%d", *workers)},
+		},
+		{
+			name:    "Overtime Concentration",
+			command: "analyze_overtime",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/overtime", *outputDir), "-workers", fmt.Sprintf("%d", *workers)}, append(taxonomyArgs, suppressionArgs...)...),
+		},
+		{
+			name:    "Forecasts",
+			command: "forecast",
+			args:    append([]string{"-sums", fmt.Sprintf("%s/sums", *outputDir), "-output", fmt.Sprintf("%s/forecasts", *outputDir)}, cpiArgs...),
+			private: true,
+		},
+		{
+			name:    "Histograms",
+			command: "generate_histograms",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/histograms", *outputDir), "-workers", fmt.Sprintf("%d", *workers)}, append(taxonomyArgs, suppressionArgs...)...),
+		},
+		{
+			name:    "Pay Compression",
+			command: "analyze_compression",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/compression", *outputDir), "-workers", fmt.Sprintf("%d", *workers)}, suppressionArgs...),
+		},
+		{
+			name:    "Headcount Flows",
+			command: "estimate_flows",
+			args:    append([]string{"-data", *dataDir, "-output", fmt.Sprintf("%s/flows", *outputDir)}, suppressionArgs...),
 		},
 	}
 
 	for _, analysis := range analyses {
+		if *dpEpsilon > 0 && !analysis.private {
+			fmt.Printf("\n⏭ Skipping %s: it reads raw records without privacy noise\n", analysis.name)
+			continue
+		}
+
 		fmt.Printf("\n▶ Running %s...\n", analysis.name)
 		cmd := exec.Command(analysis.command, analysis.args...)
 		cmd.Stdout = os.Stdout
@@ -82,7 +250,7 @@ func generateReport(outputDir string) {
 	fmt.Println("==================")
 
 	// Count output files
-	analyses := []string{"sums", "pyramid", "titles"}
+	analyses := []string{"sums", "pyramid", "titles", "distributions", "trends", "system/sums", "comparisons", "categories", "taxonomy", "title_comparisons", "title_trajectories", "outliers", "overtime", "forecasts", "histograms", "compression", "flows"}
 	for _, dir := range analyses {
 		path := fmt.Sprintf("%s/%s", outputDir, dir)
 		files, err := os.ReadDir(path)
@@ -103,5 +271,19 @@ func generateReport(outputDir string) {
 	fmt.Printf("%s/\n", outputDir)
 	fmt.Println("├── sums/       # Statistical summaries per location-year")
 	fmt.Println("├── pyramid/    # Wage distribution pyramids")
-	fmt.Println("└── titles/     # Job title analysis")
+	fmt.Println("├── titles/     # Job title analysis")
+	fmt.Println("├── distributions/ # Inequality and distribution shape metrics")
+	fmt.Println("├── trends/     # Year-over-year series per location")
+	fmt.Println("├── system/     # UC-wide and grouped aggregates (sums, pyramid, titles)")
+	fmt.Println("├── comparisons/ # Campus rankings across metrics and years")
+	fmt.Println("├── categories/  # Job category rollups and uncategorized titles")
+	fmt.Println("├── taxonomy/    # Rule matched for each title and rule conflicts")
+	fmt.Println("├── title_comparisons/ # Title pay compared across campuses")
+	fmt.Println("├── title_trajectories/ # Title time series, introductions and renames")
+	fmt.Println("├── outliers/     # Top earners, pay outliers and overtime-heavy titles")
+	fmt.Println("├── overtime/     # Who receives overtime and adjustments")
+	fmt.Println("├── forecasts/    # Headcount, payroll and median pay projections")
+	fmt.Println("├── histograms/    # Pay histograms and kernel densities for charts")
+	fmt.Println("├── compression/   # Median pay ratios between title levels")
+	fmt.Println("└── flows/         # Headcount flows and payroll growth decomposition")
 }
\ No newline at end of file
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// server answers lookups from raw records when any were loaded and from
+// summaries and pyramids otherwise
+type server struct {
+	index     *calculator.PayIndex
+	minCount  int
+	summaries map[string]*models.Summary
+	pyramids  map[string]*models.Pyramid
+	latest    map[string]int
+}
+
+func main() {
+	// Command line flags
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	dataDir := flag.String("data", "../../data", "Path to data directory (empty to serve estimates from summaries)")
+	sumsDir := flag.String("sums", "./output/sums", "Directory of summary files used when no raw data is loaded")
+	pyramidDir := flag.String("pyramid", "./output/pyramid", "Directory of pyramid files used when no raw data is loaded")
+	taxonomyFile := flag.String("taxonomy", "", "Title taxonomy rules file (defaults to the bundled taxonomy)")
+	dictionaryFile := flag.String("titles", "", "Title dictionary for normalization (defaults to the bundled dictionary)")
+	workers := flag.Int("workers", 4, "Number of concurrent workers")
+	minCount := flag.Int("min-count", 5, "Fewest employees a title or category rank may cover")
+	flag.Parse()
+
+	if *minCount < 1 {
+		log.Fatal("Minimum count must be at least 1")
+	}
+
+	s := &server{minCount: *minCount}
+	var err error
+	if *dataDir != "" {
+		s.index, err = loadIndex(*dataDir, *taxonomyFile, *dictionaryFile, *workers)
+	} else {
+		err = s.loadSummaries(*sumsDir, *pyramidDir)
+	}
+	if err != nil {
+		log.Fatal("Error loading wage data:", err)
+	}
+
+	http.HandleFunc("/percentile", s.handlePercentile)
+
+	fmt.Printf("\n✅ Serving percentile lookups on %s/percentile\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func loadIndex(dataDir, taxonomyFile, dictionaryFile string, workers int) (*calculator.PayIndex, error) {
+	tax, err := taxonomy.Open(taxonomyFile)
+	if err != nil {
+		return nil, err
+	}
+	normalizer, err := taxonomy.OpenNormalizer(dictionaryFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get all JSON files
+	files, err := findWageFiles(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Found %d wage files to index\n", len(files))
+
+	// Load files concurrently
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, workers)
+	errorsChan := make(chan error, len(files))
+	index := calculator.NewPayIndex(tax, normalizer)
+
+	for _, file := range files {
+		wg.Add(1)
+		go func(filepath string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			data, err := parser.LoadWageData(filepath)
+			if err != nil {
+				errorsChan <- fmt.Errorf("error processing %s: %w", filepath, err)
+				return
+			}
+
+			mu.Lock()
+			index.Add(data)
+			mu.Unlock()
+		}(file)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+	if hasErrors {
+		return nil, fmt.Errorf("could not index every wage file")
+	}
+
+	fmt.Printf("✓ Indexed %d records\n", index.Len())
+	return index, nil
+}
+
+func (s *server) loadSummaries(sumsDir, pyramidDir string) error {
+	s.summaries = make(map[string]*models.Summary)
+	s.pyramids = make(map[string]*models.Pyramid)
+	s.latest = make(map[string]int)
+
+	summaryFiles, err := filepath.Glob(filepath.Join(sumsDir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range summaryFiles {
+		summary := &models.Summary{}
+		if err := parser.LoadJSON(file, summary); err != nil {
+			log.Println(err)
+			continue
+		}
+		s.summaries[lookupKey(summary.Location, summary.Year)] = summary
+		if summary.Year > s.latest[summary.Location] {
+			s.latest[summary.Location] = summary.Year
+		}
+	}
+
+	pyramidFiles, err := filepath.Glob(filepath.Join(pyramidDir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range pyramidFiles {
+		pyramid := &models.Pyramid{}
+		if err := parser.LoadJSON(file, pyramid); err != nil {
+			log.Println(err)
+			continue
+		}
+		s.pyramids[lookupKey(pyramid.Location, pyramid.Year)] = pyramid
+	}
+
+	if len(s.summaries) == 0 {
+		return fmt.Errorf("no summaries found in %s", sumsDir)
+	}
+
+	fmt.Printf("✓ Loaded %d summaries and %d pyramids\n", len(s.summaries), len(s.pyramids))
+	return nil
+}
+
+// handlePercentile answers GET /percentile?pay=85000&location=Merced&year=2023
+// with optional title, category, scheme and comparable parameters
+func (s *server) handlePercentile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("use GET"))
+		return
+	}
+
+	params := r.URL.Query()
+	query := calculator.PercentileQuery{
+		Location: strings.ReplaceAll(params.Get("location"), "_", " "),
+		Title:    params.Get("title"),
+		Category: params.Get("category"),
+		MinCount: s.minCount,
+	}
+
+	var err error
+	if query.Pay, err = strconv.ParseFloat(params.Get("pay"), 64); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("pay must be a number"))
+		return
+	}
+	if value := params.Get("year"); value != "" {
+		if query.Year, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("year must be a number"))
+			return
+		}
+	}
+	if value := params.Get("comparable"); value != "" {
+		if query.Comparable, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("comparable must be a number"))
+			return
+		}
+	}
+	if value := params.Get("scheme"); value != "" {
+		scheme, ok := calculator.GetBracketSchemes()[value]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown bracket scheme %q", value))
+			return
+		}
+		query.Scheme = scheme
+	}
+
+	var lookup *models.PercentileLookup
+	if s.index != nil {
+		lookup, err = s.index.Lookup(query)
+	} else {
+		lookup, err = s.estimate(query)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lookup)
+}
+
+// estimate answers a query from summaries, which only support overall ranks
+func (s *server) estimate(query calculator.PercentileQuery) (*models.PercentileLookup, error) {
+	if query.Title != "" || query.Category != "" {
+		return nil, fmt.Errorf("title and category lookups need raw data (-data)")
+	}
+
+	location := query.Location
+	if location == "" {
+		location = calculator.SystemLocation
+	}
+	year := query.Year
+	if year == 0 {
+		year = s.latest[location]
+	}
+
+	summary, ok := s.summaries[lookupKey(location, year)]
+	if !ok {
+		return nil, fmt.Errorf("no summary for %s %d", location, year)
+	}
+
+	return calculator.EstimatePercentile(summary, s.pyramids[lookupKey(location, year)], query.Pay)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func lookupKey(location string, year int) string {
+	return fmt.Sprintf("%s|%d", location, year)
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
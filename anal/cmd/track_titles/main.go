@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/calculator"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+var yearPattern = regexp.MustCompile(`wages_(\d{4})\.json$`)
+
+func main() {
+	// Command line flags
+	dataDir := flag.String("data", "../../data", "Path to data directory")
+	outputDir := flag.String("output", "./output/title_trajectories", "Output directory for title trajectories")
+	grouping := flag.String("group", calculator.GroupNormalized, "Group titles by raw, normalized or family title")
+	dictionaryFile := flag.String("titles", "", "Title dictionary for normalization (defaults to the bundled dictionary)")
+	minCount := flag.Int("min-count", 10, "Minimum employees on both sides of a likely rename")
+	tolerance := flag.Float64("rename-tolerance", 0.2, "Maximum relative difference in headcount and median pay for a likely rename")
+	workers := flag.Int("workers", 2, "Number of years processed concurrently")
+	suppression := flag.String("suppression", "", "Small-cell suppression policy (none, suppress, coarsen, strict or a .json file); unsuppressed when empty")
+	flag.Parse()
+
+	// Load title dictionary
+	normalizer, err := taxonomy.OpenNormalizer(*dictionaryFile)
+	if err != nil {
+		log.Fatal("Error loading title dictionary:", err)
+	}
+	opts := calculator.TitleTrajectoryOptions{
+		Grouping:        *grouping,
+		Normalizer:      normalizer,
+		MinCount:        *minCount,
+		RenameTolerance: *tolerance,
+	}
+
+	// Resolve suppression policy
+	policy, err := parser.OpenSuppressionPolicy(*suppression, calculator.GetSuppressionPolicies())
+	if err != nil {
+		log.Fatal("Error loading suppression policy:", err)
+	}
+	if policy != nil {
+		fmt.Printf("Applying the %s suppression policy\n", policy.Name)
+	}
+
+	// Get all JSON files grouped by year
+	files, err := findWageFiles(*dataDir)
+	if err != nil {
+		log.Fatal("Error finding wage files:", err)
+	}
+
+	filesByYear := make(map[int][]string)
+	for _, file := range files {
+		match := yearPattern.FindStringSubmatch(file)
+		if match == nil {
+			continue
+		}
+		year, _ := strconv.Atoi(match[1])
+		filesByYear[year] = append(filesByYear[year], file)
+	}
+
+	fmt.Printf("Found %d wage files across %d years\n", len(files), len(filesByYear))
+
+	// Summarize years concurrently
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, *workers)
+	errorsChan := make(chan error, len(filesByYear))
+	var years []*calculator.TitleYear
+
+	for year, yearFiles := range filesByYear {
+		wg.Add(1)
+		go func(year int, yearFiles []string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			titleYear, err := summarizeYear(year, yearFiles, opts)
+			if err != nil {
+				errorsChan <- fmt.Errorf("error processing %d: %w", year, err)
+				return
+			}
+			if titleYear == nil {
+				return
+			}
+
+			mu.Lock()
+			years = append(years, titleYear)
+			mu.Unlock()
+			fmt.Printf("✓ Summarized %d locations for %d\n", len(titleYear.Points)-1, year)
+		}(year, yearFiles)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+
+	// Report errors
+	var hasErrors bool
+	for err := range errorsChan {
+		log.Println(err)
+		hasErrors = true
+	}
+	if hasErrors {
+		log.Fatal("Error summarizing titles")
+	}
+
+	reports, err := calculator.CalculateTitleTrajectories(years, opts)
+	if err != nil {
+		log.Fatal("Error calculating title trajectories:", err)
+	}
+
+	// Create output directory
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		log.Fatal("Error creating output directory:", err)
+	}
+
+	for _, report := range reports {
+		// Withhold small cells
+		if policy != nil {
+			if err := calculator.ApplySuppressionToTrajectories(report, *policy); err != nil {
+				log.Fatal("Error applying suppression policy:", err)
+			}
+		}
+
+		filename := fmt.Sprintf("%s.json", strings.ReplaceAll(report.Location, " ", "_"))
+		outputPath := fmt.Sprintf("%s/%s", *outputDir, filename)
+
+		if err := parser.SaveJSON(outputPath, report); err != nil {
+			log.Println(err)
+			hasErrors = true
+			continue
+		}
+
+		fmt.Printf("✓ %s: %d titles, %d introduced, %d retired, %d likely renames (%d-%d)\n",
+			report.Location, len(report.Titles), report.Introduced, report.Retired,
+			len(report.Renames), report.FirstYear, report.LastYear)
+	}
+
+	if !hasErrors {
+		fmt.Println("\n✅ All title trajectories calculated successfully!")
+	}
+}
+
+func summarizeYear(year int, files []string, opts calculator.TitleTrajectoryOptions) (*calculator.TitleYear, error) {
+	// Load every location for the year
+	var datasets []*models.WageData
+	for _, file := range files {
+		data, err := parser.LoadWageData(file)
+		if err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, data)
+	}
+
+	return calculator.SummarizeTitleYear(year, datasets, opts)
+}
+
+func findWageFiles(dataDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip non-wage JSON files
+		if strings.HasSuffix(path, ".json") &&
+		   strings.Contains(path, "wages_") &&
+		   !strings.Contains(path, "scrape_progress") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,170 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/sketch"
+)
+
+// SystemLocation is the pseudo-location name for all locations combined
+const SystemLocation = "UC System"
+
+// GetLocationGroups returns the standard location groupings
+func GetLocationGroups() []models.LocationGroup {
+	return []models.LocationGroup{
+		{Name: SystemLocation},
+		{Name: "UC Campuses", Exclude: []string{"ASUCLA", "UCOP"}},
+		{Name: "UC Medical Center Campuses", Include: []string{"Davis", "Irvine", "Los Angeles", "San Diego", "San Francisco"}},
+		{Name: "UC Non-Medical Campuses", Include: []string{"Berkeley", "Merced", "Riverside", "Santa Barbara", "Santa Cruz"}},
+	}
+}
+
+// GroupIncludes reports whether a location belongs to a group
+func GroupIncludes(group models.LocationGroup, location string) bool {
+	for _, excluded := range group.Exclude {
+		if excluded == location {
+			return false
+		}
+	}
+
+	if len(group.Include) == 0 {
+		return true
+	}
+
+	for _, included := range group.Include {
+		if included == location {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeWageData combines the datasets belonging to a group into a single
+// dataset under the group's name. Calculators run on the merged records,
+// so percentiles and medians are exact rather than averages of averages.
+// Returns nil when no dataset matches the group.
+func MergeWageData(group models.LocationGroup, year int, datasets []*models.WageData) (*models.WageData, []string) {
+	merged := &models.WageData{
+		Location: group.Name,
+		Year:     year,
+		Records:  []models.WageRecord{},
+	}
+
+	var locations []string
+	for _, data := range datasets {
+		if data.Year != year || !GroupIncludes(group, data.Location) {
+			continue
+		}
+
+		merged.Records = append(merged.Records, data.Records...)
+		locations = append(locations, data.Location)
+	}
+
+	if len(locations) == 0 {
+		return nil, nil
+	}
+
+	merged.TotalRecords = len(merged.Records)
+	sort.Strings(locations)
+
+	return merged, locations
+}
+
+// MergeSummaries combines the summaries belonging to a group using their
+// stored sketches, without reloading raw records. Counts, totals, means,
+// standard deviation, min and max are exact; median, percentiles and Gini
+// are estimated from the merged sketch (see sketch.DefaultCompression for
+// the error bound) and the result is marked Approximate. Skewness and
+// kurtosis cannot be merged and are left at zero.
+// Returns nil when no summary matches the group.
+func MergeSummaries(group models.LocationGroup, year int, summaries []*models.Summary) (*models.Summary, error) {
+	digest := sketch.NewTDigest(sketch.DefaultCompression)
+	merged := &models.Summary{
+		Location:    group.Name,
+		Year:        year,
+		GeneratedAt: time.Now(),
+		Percentiles: make(map[string]float64),
+		Approximate: true,
+	}
+
+	sumSquares := 0.0
+	for _, summary := range summaries {
+		if summary.Year != year || !GroupIncludes(group, summary.Location) {
+			continue
+		}
+		if summary.Sketch == nil {
+			return nil, fmt.Errorf("summary for %s %d has no sketch", summary.Location, summary.Year)
+		}
+
+		if len(merged.Locations) == 0 {
+			merged.Population = copyPopulation(summary.Population)
+		} else if err := addPopulation(merged.Population, summary.Population); err != nil {
+			return nil, fmt.Errorf("summary for %s %d: %w", summary.Location, summary.Year, err)
+		}
+
+		n := float64(summary.EmployeeCount)
+		if len(merged.Locations) == 0 || summary.MinPay < merged.MinPay {
+			merged.MinPay = summary.MinPay
+		}
+		merged.MaxPay = math.Max(merged.MaxPay, summary.MaxPay)
+		merged.EmployeeCount += summary.EmployeeCount
+		merged.TotalGrossPay += summary.TotalGrossPay
+		merged.PayComponents.TotalBase += summary.PayComponents.TotalBase
+		merged.PayComponents.TotalOvertime += summary.PayComponents.TotalOvertime
+		merged.PayComponents.TotalAdjustments += summary.PayComponents.TotalAdjustments
+		sumSquares += n * (summary.StdDev*summary.StdDev + summary.AvgGrossPay*summary.AvgGrossPay)
+
+		digest.Merge(summary.Sketch)
+		merged.Locations = append(merged.Locations, summary.Location)
+	}
+
+	if merged.EmployeeCount == 0 {
+		return nil, nil
+	}
+
+	n := float64(merged.EmployeeCount)
+	merged.AvgGrossPay = merged.TotalGrossPay / n
+	merged.StdDev = math.Sqrt(math.Max(0, sumSquares/n-merged.AvgGrossPay*merged.AvgGrossPay))
+	merged.PayComponents.AvgBase = merged.PayComponents.TotalBase / n
+	merged.PayComponents.AvgOvertime = merged.PayComponents.TotalOvertime / n
+	merged.PayComponents.AvgAdjustments = merged.PayComponents.TotalAdjustments / n
+
+	merged.MedianPay = digest.Quantile(0.5)
+	for _, p := range PercentileValues {
+		merged.Percentiles[formatPercentileKey(p)] = digest.Quantile(p / 100)
+	}
+	merged.Gini = sketchGini(digest)
+	merged.Sketch = digest
+	sort.Strings(merged.Locations)
+
+	return merged, nil
+}
+
+// sketchGini estimates the Gini coefficient treating each centroid as a
+// point mass at its mean
+func sketchGini(digest *sketch.TDigest) float64 {
+	digest.Compress()
+
+	total := 0.0
+	for _, c := range digest.Centroids {
+		total += c.Mean * c.Count
+	}
+	if total == 0 || digest.Count == 0 {
+		return 0
+	}
+
+	// One minus twice the area under the Lorenz curve
+	area := 0.0
+	cumulativeShare := 0.0
+	for _, c := range digest.Centroids {
+		share := c.Mean * c.Count / total
+		area += c.Count / digest.Count * (2*cumulativeShare + share) / 2
+		cumulativeShare += share
+	}
+
+	return 1 - 2*area
+}
@@ -0,0 +1,129 @@
+package calculator
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+// BootstrapPercentile is the interval method reported in summaries: the
+// bounds are percentiles of the statistic across resamples
+const BootstrapPercentile = "percentile"
+
+// BootstrapOptions controls bootstrap confidence intervals. Samples defaults
+// to 1000 resamples and Confidence to 0.95. The same Seed always gives the
+// same intervals for the same data.
+type BootstrapOptions struct {
+	Samples    int
+	Confidence float64
+	Seed       int64
+}
+
+// CalculateSummaryWithBootstrap computes a summary with bootstrap confidence
+// intervals for its mean, median, percentiles and Gini coefficient
+func CalculateSummaryWithBootstrap(data *models.WageData, opts BootstrapOptions) (*models.Summary, error) {
+	summary, err := CalculateSummary(data)
+	if err != nil || summary == nil {
+		return summary, err
+	}
+
+	var grossPays []float64
+	for _, record := range data.Records {
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross > 0 {
+			grossPays = append(grossPays, gross)
+		}
+	}
+	sort.Float64s(grossPays)
+
+	summary.Bootstrap = bootstrapWages(grossPays, opts)
+	return summary, nil
+}
+
+// bootstrapWages resamples sorted wages with replacement and returns
+// intervals for the summary statistics
+func bootstrapWages(sorted []float64, opts BootstrapOptions) *models.SummaryBootstrap {
+	if opts.Samples <= 0 {
+		opts.Samples = 1000
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		opts.Confidence = 0.95
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	n := len(sorted)
+	counts := make([]int, n)
+	resample := make([]float64, n)
+
+	means := make([]float64, opts.Samples)
+	medians := make([]float64, opts.Samples)
+	ginis := make([]float64, opts.Samples)
+	percentiles := make(map[string][]float64)
+	for _, p := range PercentileValues {
+		percentiles[formatPercentileKey(p)] = make([]float64, opts.Samples)
+	}
+
+	for s := 0; s < opts.Samples; s++ {
+		// Count how often each value is drawn, then lay the draws out in
+		// order so the resample is already sorted
+		for i := range counts {
+			counts[i] = 0
+		}
+		for i := 0; i < n; i++ {
+			counts[rng.Intn(n)]++
+		}
+		k := 0
+		for i, count := range counts {
+			for ; count > 0; count-- {
+				resample[k] = sorted[i]
+				k++
+			}
+		}
+
+		// Index the sorted resample directly; the stats package would
+		// copy and sort it again for every statistic
+		means[s] = sumFloat64(resample) / float64(n)
+		medians[s] = sortedMedian(resample)
+		ginis[s] = sortedGini(resample)
+		for _, p := range PercentileValues {
+			percentiles[formatPercentileKey(p)][s] = sortedPercentile(resample, p)
+		}
+	}
+
+	result := &models.SummaryBootstrap{
+		Samples:     opts.Samples,
+		Confidence:  opts.Confidence,
+		Seed:        opts.Seed,
+		Method:      BootstrapPercentile,
+		Mean:        confidenceInterval(means, opts.Confidence),
+		Median:      confidenceInterval(medians, opts.Confidence),
+		Gini:        confidenceInterval(ginis, opts.Confidence),
+		Percentiles: make(map[string]models.ConfidenceInterval),
+	}
+	for key, estimates := range percentiles {
+		result.Percentiles[key] = confidenceInterval(estimates, opts.Confidence)
+	}
+
+	return result
+}
+
+// confidenceInterval returns the central interval holding the confidence
+// fraction of bootstrap estimates
+func confidenceInterval(estimates []float64, confidence float64) models.ConfidenceInterval {
+	sort.Float64s(estimates)
+	tail := (1 - confidence) / 2 * 100
+	stdError, err := stats.StandardDeviationSample(estimates)
+	if err != nil || math.IsNaN(stdError) {
+		stdError = 0
+	}
+
+	return models.ConfidenceInterval{
+		Lower:    percentileOf(estimates, tail),
+		Upper:    percentileOf(estimates, 100-tail),
+		StdError: stdError,
+	}
+}
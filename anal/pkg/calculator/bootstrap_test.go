@@ -0,0 +1,36 @@
+package calculator
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/montanaflynn/stats"
+)
+
+// The bootstrap indexes sorted resamples directly, so its estimates must
+// match the stats package CalculateSummary uses
+func TestSortedStatisticsMatchSummary(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{1, 2, 3, 10, 99, 100, 1001} {
+		sorted := make([]float64, n)
+		for i := range sorted {
+			sorted[i] = float64(20000 + 5000*r.Intn(40))
+		}
+		sort.Float64s(sorted)
+
+		median, _ := stats.Median(sorted)
+		if got := sortedMedian(sorted); got != median {
+			t.Errorf("n=%d: sortedMedian = %v, want %v", n, got, median)
+		}
+		if got, want := sortedGini(sorted), CalculateGiniCoefficient(sorted); got != want {
+			t.Errorf("n=%d: sortedGini = %v, want %v", n, got, want)
+		}
+		for _, p := range append([]float64{0.1, 2.5, 97.5, 100}, PercentileValues...) {
+			if got, want := sortedPercentile(sorted, p), percentileOf(sorted, p); got != want {
+				t.Errorf("n=%d: sortedPercentile(%v) = %v, want %v", n, p, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package calculator
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// OtherCategory is the category for titles no rule matches
+const OtherCategory = "Other"
+
+// AnalyzeCategories rolls every record up into a job category using the
+// given taxonomy (the bundled one when nil), with second-level
+// subcategories, and reports the largest uncategorized titles so the
+// taxonomy can be improved
+func AnalyzeCategories(data *models.WageData, tax *taxonomy.Taxonomy, otherTopN int) (*models.CategoryAnalysis, error) {
+	if tax == nil {
+		tax = taxonomy.Default()
+	}
+
+	categoryMap := make(map[string]*categoryData)
+	otherTitles := make(map[string][]float64)
+	categorizer := newCategoryCache(tax)
+
+	totalEmployees := 0
+	totalPay := 0.0
+
+	for _, record := range data.Records {
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+
+		path := categorizer.classify(record.Title)
+		category := path[0]
+
+		if _, exists := categoryMap[category]; !exists {
+			categoryMap[category] = newCategoryData()
+		}
+
+		cd := categoryMap[category]
+		cd.add(record.Title, gross)
+
+		// Roll the second level up under its parent
+		if len(path) > 1 {
+			subcategory := strings.Join(path[:2], taxonomy.Separator)
+			if _, exists := cd.subcategories[subcategory]; !exists {
+				cd.subcategories[subcategory] = newCategoryData()
+			}
+			cd.subcategories[subcategory].add(record.Title, gross)
+		}
+
+		if category == OtherCategory && isKnownTitle(record.Title) {
+			otherTitles[record.Title] = append(otherTitles[record.Title], gross)
+		}
+
+		totalEmployees++
+		totalPay += gross
+	}
+
+	if totalEmployees == 0 {
+		return nil, nil
+	}
+
+	var categories []models.CategoryStats
+	for category, cd := range categoryMap {
+		categoryStats := cd.stats(category, totalEmployees, totalPay)
+
+		for subcategory, sub := range cd.subcategories {
+			categoryStats.Subcategories = append(categoryStats.Subcategories, sub.stats(subcategory, totalEmployees, totalPay))
+		}
+		sortCategoryStats(categoryStats.Subcategories)
+
+		categories = append(categories, categoryStats)
+	}
+
+	// Largest categories first
+	sortCategoryStats(categories)
+
+	analysis := &models.CategoryAnalysis{
+		Location:            data.Location,
+		Year:                data.Year,
+		GeneratedAt:         time.Now(),
+		Taxonomy:            tax.Name(),
+		TaxonomyVersion:     tax.Version(),
+		EmployeeCount:       totalEmployees,
+		TotalPay:            totalPay,
+		Categories:          categories,
+		UncategorizedTitles: getTopTitles(otherTitles, otherTopN),
+	}
+
+	return analysis, nil
+}
+
+// categoryData holds temporary data for category calculations
+type categoryData struct {
+	wages         []float64
+	titles        map[string]bool
+	subcategories map[string]*categoryData
+}
+
+func newCategoryData() *categoryData {
+	return &categoryData{
+		titles:        make(map[string]bool),
+		subcategories: make(map[string]*categoryData),
+	}
+}
+
+func (cd *categoryData) add(title string, gross float64) {
+	cd.wages = append(cd.wages, gross)
+	if isKnownTitle(title) {
+		cd.titles[title] = true
+	}
+}
+
+// stats summarizes the category against location-wide totals
+func (cd *categoryData) stats(category string, totalEmployees int, totalPay float64) models.CategoryStats {
+	sort.Float64s(cd.wages)
+
+	total := sumFloat64(cd.wages)
+	avgPay, _ := stats.Mean(cd.wages)
+	medianPay, _ := stats.Median(cd.wages)
+	stdDev, _ := stats.StandardDeviation(cd.wages)
+
+	return models.CategoryStats{
+		Category:       category,
+		Count:          len(cd.wages),
+		HeadcountShare: float64(len(cd.wages)) / float64(totalEmployees) * 100,
+		TotalPay:       total,
+		PayrollShare:   total / totalPay * 100,
+		AvgPay:         avgPay,
+		MedianPay:      medianPay,
+		MinPay:         cd.wages[0],
+		MaxPay:         cd.wages[len(cd.wages)-1],
+		StdDev:         stdDev,
+		UniqueTitles:   len(cd.titles),
+	}
+}
+
+// sortCategoryStats orders categories largest first
+func sortCategoryStats(categories []models.CategoryStats) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Count == categories[j].Count {
+			return categories[i].Category < categories[j].Category
+		}
+		return categories[i].Count > categories[j].Count
+	})
+}
+
+// categoryCache memoizes title classification within a single file
+type categoryCache struct {
+	taxonomy *taxonomy.Taxonomy
+	paths    map[string][]string
+}
+
+func newCategoryCache(tax *taxonomy.Taxonomy) *categoryCache {
+	if tax == nil {
+		tax = taxonomy.Default()
+	}
+	return &categoryCache{
+		taxonomy: tax,
+		paths:    make(map[string][]string),
+	}
+}
+
+// classify returns the category path of a title; unknown titles are Other
+func (c *categoryCache) classify(title string) []string {
+	if !isKnownTitle(title) {
+		return []string{OtherCategory}
+	}
+
+	if path, ok := c.paths[title]; ok {
+		return path
+	}
+
+	path := c.taxonomy.Classify(title).Path
+	c.paths[title] = path
+	return path
+}
+
+// categorize returns the top-level category of a title
+func (c *categoryCache) categorize(title string) string {
+	return c.classify(title)[0]
+}
+
+// getCategoryCounts summarizes bracket pay by category, largest first
+func getCategoryCounts(categories map[string][]float64, bracketCount int) []models.CategoryCount {
+	var counts []models.CategoryCount
+
+	for category, wages := range categories {
+		avgPay, _ := stats.Mean(wages)
+		counts = append(counts, models.CategoryCount{
+			Category:   category,
+			Count:      len(wages),
+			Percentage: float64(len(wages)) / float64(bracketCount) * 100,
+			AvgPay:     avgPay,
+		})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count == counts[j].Count {
+			return counts[i].Category < counts[j].Category
+		}
+		return counts[i].Count > counts[j].Count
+	})
+
+	return counts
+}
+
+// isKnownTitle reports whether a title is present and not redacted
+func isKnownTitle(title string) bool {
+	return title != "" && title != "*****"
+}
@@ -0,0 +1,216 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// ComparisonMetric defines a metric locations can be ranked by
+type ComparisonMetric struct {
+	Name       string
+	Label      string
+	Descending bool
+	Value      func(summary *models.Summary, pyramid *models.Pyramid) (float64, bool)
+}
+
+// GetComparisonMetrics returns the standard ranking metrics
+func GetComparisonMetrics() []ComparisonMetric {
+	return []ComparisonMetric{
+		{"median_pay", "Median gross pay", true, func(s *models.Summary, _ *models.Pyramid) (float64, bool) {
+			return s.MedianPay, true
+		}},
+		{"avg_pay", "Average gross pay", true, func(s *models.Summary, _ *models.Pyramid) (float64, bool) {
+			return s.AvgGrossPay, true
+		}},
+		{"headcount", "Employee count", true, func(s *models.Summary, _ *models.Pyramid) (float64, bool) {
+			return float64(s.EmployeeCount), true
+		}},
+		{"payroll", "Total gross pay", true, func(s *models.Summary, _ *models.Pyramid) (float64, bool) {
+			return s.TotalGrossPay, true
+		}},
+		{"overtime_share", "Overtime share of gross pay (%)", true, func(s *models.Summary, _ *models.Pyramid) (float64, bool) {
+			if s.TotalGrossPay == 0 {
+				return 0, false
+			}
+			return s.PayComponents.TotalOvertime / s.TotalGrossPay * 100, true
+		}},
+		{"gini", "Gini coefficient", true, func(s *models.Summary, _ *models.Pyramid) (float64, bool) {
+			return s.Gini, s.Gini > 0
+		}},
+		{"share_above_200k", "Employees earning $200k+ (%)", true, func(s *models.Summary, p *models.Pyramid) (float64, bool) {
+			return shareAbove(s, p, 200000)
+		}},
+	}
+}
+
+// CalculateComparisons ranks locations per year for the named metrics.
+// Pyramids are only needed for bracket-based metrics and may be nil.
+func CalculateComparisons(summaries []*models.Summary, pyramids []*models.Pyramid, metricNames []string) (*models.Comparison, error) {
+	available := make(map[string]ComparisonMetric)
+	for _, metric := range GetComparisonMetrics() {
+		available[metric.Name] = metric
+	}
+
+	var metrics []ComparisonMetric
+	for _, name := range metricNames {
+		metric, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown comparison metric %q", name)
+		}
+		metrics = append(metrics, metric)
+	}
+
+	// Index pyramids by location-year
+	pyramidIndex := make(map[string]*models.Pyramid)
+	for _, pyramid := range pyramids {
+		pyramidIndex[locationYearKey(pyramid.Location, pyramid.Year)] = pyramid
+	}
+
+	yearSet := make(map[int]bool)
+	locationSet := make(map[string]bool)
+	summariesByYear := make(map[int][]*models.Summary)
+	for _, summary := range summaries {
+		yearSet[summary.Year] = true
+		locationSet[summary.Location] = true
+		summariesByYear[summary.Year] = append(summariesByYear[summary.Year], summary)
+	}
+
+	comparison := &models.Comparison{
+		GeneratedAt: time.Now(),
+		Years:       sortedYears(yearSet),
+		Locations:   sortedKeys(locationSet),
+		Metrics:     []models.MetricRanking{},
+	}
+
+	for _, metric := range metrics {
+		cells := make(map[string][]models.RankCell)
+
+		for _, year := range comparison.Years {
+			type entry struct {
+				location string
+				value    float64
+			}
+
+			var entries []entry
+			for _, summary := range summariesByYear[year] {
+				pyramid := pyramidIndex[locationYearKey(summary.Location, year)]
+				value, ok := metric.Value(summary, pyramid)
+				if !ok {
+					continue
+				}
+				entries = append(entries, entry{summary.Location, value})
+			}
+
+			sort.Slice(entries, func(i, j int) bool {
+				if entries[i].value == entries[j].value {
+					return entries[i].location < entries[j].location
+				}
+				if metric.Descending {
+					return entries[i].value > entries[j].value
+				}
+				return entries[i].value < entries[j].value
+			})
+
+			// Competition ranking: ties share a rank and leave a gap
+			for i, e := range entries {
+				rank := i + 1
+				if i > 0 && e.value == entries[i-1].value {
+					rank = cells[entries[i-1].location][len(cells[entries[i-1].location])-1].Rank
+				}
+
+				cell := models.RankCell{
+					Year:  year,
+					Value: e.value,
+					Rank:  rank,
+					OutOf: len(entries),
+				}
+
+				if previous := cells[e.location]; len(previous) > 0 {
+					cell.RankChange = previous[len(previous)-1].Rank - rank
+				}
+
+				cells[e.location] = append(cells[e.location], cell)
+			}
+		}
+
+		ranking := models.MetricRanking{
+			Metric:     metric.Name,
+			Label:      metric.Label,
+			Descending: metric.Descending,
+			Rows:       []models.LocationRanks{},
+		}
+		for _, location := range comparison.Locations {
+			if len(cells[location]) == 0 {
+				continue
+			}
+			ranking.Rows = append(ranking.Rows, models.LocationRanks{
+				Location: location,
+				Cells:    cells[location],
+			})
+		}
+
+		comparison.Metrics = append(comparison.Metrics, ranking)
+	}
+
+	return comparison, nil
+}
+
+// shareAbove returns the percent of employees paid at or above the
+// threshold. Pyramid counts are exact when no bracket straddles the
+// threshold; otherwise the summary's sketch estimates it.
+func shareAbove(summary *models.Summary, pyramid *models.Pyramid, threshold float64) (float64, bool) {
+	if share, ok := bracketShareAbove(pyramid, threshold); ok {
+		return share, true
+	}
+
+	if summary == nil || summary.Sketch == nil || summary.Sketch.Count == 0 {
+		return 0, false
+	}
+	return (1 - summary.Sketch.CDF(threshold)) * 100, true
+}
+
+// bracketShareAbove counts the brackets starting at or above the threshold.
+// It fails when a bracket straddles the threshold, as with schemes that do
+// not have it as an edge or brackets merged across it, or when some pay
+// falls outside the brackets.
+func bracketShareAbove(pyramid *models.Pyramid, threshold float64) (float64, bool) {
+	if pyramid == nil || pyramid.TotalEmployees == 0 || pyramid.Unbracketed > 0 {
+		return 0, false
+	}
+
+	count := 0
+	for _, bracket := range pyramid.Brackets {
+		if bracket.MinValue >= threshold {
+			count += bracket.Count
+		} else if bracket.OpenEnded || bracket.MaxValue > threshold {
+			return 0, false
+		}
+	}
+
+	return float64(count) / float64(pyramid.TotalEmployees) * 100, true
+}
+
+func locationYearKey(location string, year int) string {
+	return fmt.Sprintf("%s|%d", location, year)
+}
+
+func sortedYears(set map[int]bool) []int {
+	years := make([]int, 0, len(set))
+	for year := range set {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+	return years
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
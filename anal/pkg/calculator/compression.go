@@ -0,0 +1,231 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// Pay measures compared across levels
+const (
+	PayBasisBase  = "base"
+	PayBasisGross = "gross"
+)
+
+// CompressionOptions controls CalculatePayCompression. Titles are split
+// into families and levels by Normalizer (the bundled dictionary when nil).
+// Levels with fewer than MinCount employees (default 5) are left out, and
+// medians compare PayBasis pay (default base pay, which excludes overtime).
+type CompressionOptions struct {
+	Normalizer *taxonomy.Normalizer
+	MinCount   int
+	PayBasis   string
+}
+
+// CalculatePayCompression compares median pay between the levels of each
+// title family in a location-year, such as Assistant, Associate and full
+// Professor or Analyst 1 through 4. Titles in a family with different
+// appointment qualifiers (e.g. academic and fiscal year) are compared
+// separately.
+func CalculatePayCompression(data *models.WageData, opts CompressionOptions) (*models.PayCompression, error) {
+	opts, err := compressionDefaults(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make(map[string]*models.NormalizedTitle)
+	families := make(map[string]*compressionFamily)
+
+	for _, record := range data.Records {
+		if !isKnownTitle(record.Title) {
+			continue
+		}
+		base, _, _, gross := parser.ConvertRecordToFloat(record)
+		pay := base
+		if opts.PayBasis == PayBasisGross {
+			pay = gross
+		}
+		if gross <= 0 || pay <= 0 {
+			continue
+		}
+
+		title, ok := normalized[record.Title]
+		if !ok {
+			title = opts.Normalizer.Normalize(record.Title)
+			normalized[record.Title] = title
+		}
+
+		qualifiers := append([]string(nil), title.Qualifiers...)
+		sort.Strings(qualifiers)
+		key := title.Family + "|" + strings.Join(qualifiers, ",")
+
+		family, exists := families[key]
+		if !exists {
+			family = &compressionFamily{
+				family:     title.Family,
+				qualifiers: qualifiers,
+				levels:     make(map[string]*compressionLevel),
+			}
+			families[key] = family
+		}
+		family.add(title, pay)
+	}
+
+	if len(families) == 0 {
+		return nil, nil
+	}
+
+	result := &models.PayCompression{
+		Location:      data.Location,
+		Year:          data.Year,
+		GeneratedAt:   time.Now(),
+		PayBasis:      opts.PayBasis,
+		Normalization: opts.Normalizer.Name() + "@" + opts.Normalizer.Version(),
+		MinCount:      opts.MinCount,
+		Families:      []models.FamilyCompression{},
+	}
+
+	var topToEntry, adjacent []float64
+	for _, family := range families {
+		compression, ok := family.compression(opts.MinCount)
+		if !ok {
+			continue
+		}
+
+		result.Families = append(result.Families, compression)
+		topToEntry = append(topToEntry, compression.TopToEntry)
+		for _, ratio := range compression.Adjacent {
+			adjacent = append(adjacent, ratio.Ratio)
+		}
+		if compression.Inversions > 0 {
+			result.InvertedFamilies++
+		}
+	}
+
+	result.FamilyCount = len(result.Families)
+	if result.FamilyCount > 0 {
+		sort.Float64s(topToEntry)
+		sort.Float64s(adjacent)
+		result.MedianTopToEntry = percentileOf(topToEntry, 50)
+		result.MedianAdjacent = percentileOf(adjacent, 50)
+	}
+
+	// Largest families first
+	sort.Slice(result.Families, func(i, j int) bool {
+		a, b := result.Families[i], result.Families[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Family != b.Family {
+			return a.Family < b.Family
+		}
+		return strings.Join(a.Qualifiers, ",") < strings.Join(b.Qualifiers, ",")
+	})
+
+	return result, nil
+}
+
+func compressionDefaults(opts CompressionOptions) (CompressionOptions, error) {
+	if opts.Normalizer == nil {
+		opts.Normalizer = taxonomy.DefaultNormalizer()
+	}
+	if opts.MinCount < 1 {
+		opts.MinCount = 5
+	}
+	if opts.PayBasis == "" {
+		opts.PayBasis = PayBasisBase
+	}
+	if opts.PayBasis != PayBasisBase && opts.PayBasis != PayBasisGross {
+		return opts, fmt.Errorf("unknown pay basis %q (want %s or %s)", opts.PayBasis, PayBasisBase, PayBasisGross)
+	}
+	return opts, nil
+}
+
+// compressionFamily holds temporary data for a title family
+type compressionFamily struct {
+	family     string
+	qualifiers []string
+	levels     map[string]*compressionLevel
+}
+
+// compressionLevel holds the pay of one normalized title in a family
+type compressionLevel struct {
+	title *models.NormalizedTitle
+	pays  []float64
+}
+
+func (f *compressionFamily) add(title *models.NormalizedTitle, pay float64) {
+	level, exists := f.levels[title.Title]
+	if !exists {
+		level = &compressionLevel{title: title}
+		f.levels[title.Title] = level
+	}
+	level.pays = append(level.pays, pay)
+}
+
+// compression orders the family's levels by rank then level number and
+// compares each with the next, reporting false when fewer than two levels
+// have enough employees
+func (f *compressionFamily) compression(minCount int) (models.FamilyCompression, bool) {
+	var levels []models.CompressionLevel
+	for _, level := range f.levels {
+		if len(level.pays) < minCount {
+			continue
+		}
+		sort.Float64s(level.pays)
+		levels = append(levels, models.CompressionLevel{
+			Title:     level.title.Title,
+			Rank:      level.title.Rank,
+			RankOrder: level.title.RankOrder,
+			Level:     level.title.Level,
+			Count:     len(level.pays),
+			MedianPay: percentileOf(level.pays, 50),
+		})
+	}
+	if len(levels) < 2 {
+		return models.FamilyCompression{}, false
+	}
+
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].RankOrder != levels[j].RankOrder {
+			return levels[i].RankOrder < levels[j].RankOrder
+		}
+		if levels[i].Level != levels[j].Level {
+			return levels[i].Level < levels[j].Level
+		}
+		return levels[i].Title < levels[j].Title
+	})
+
+	result := models.FamilyCompression{
+		Family:     f.family,
+		Qualifiers: f.qualifiers,
+		Levels:     levels,
+		Adjacent:   []models.LevelRatio{},
+		TopToEntry: safeRatio(levels[len(levels)-1].MedianPay, levels[0].MedianPay),
+	}
+	for i, level := range levels {
+		result.Count += level.Count
+		if i == 0 {
+			continue
+		}
+
+		lower := levels[i-1]
+		ratio := models.LevelRatio{
+			Lower:    lower.Title,
+			Higher:   level.Title,
+			Ratio:    safeRatio(level.MedianPay, lower.MedianPay),
+			Inverted: level.MedianPay < lower.MedianPay,
+		}
+		if ratio.Inverted {
+			result.Inversions++
+		}
+		result.Adjacent = append(result.Adjacent, ratio)
+	}
+
+	return result, true
+}
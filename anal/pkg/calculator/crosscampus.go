@@ -0,0 +1,201 @@
+package calculator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// TitleComparisonPercentiles are the percentiles reported per location
+var TitleComparisonPercentiles = []float64{10, 25, 75, 90}
+
+// TitleComparisonOptions controls CompareTitlesAcrossLocations. A location
+// is only compared for a title with at least MinCount employees, and a title
+// is only reported when MinLocations locations qualify. TopN keeps the
+// titles with the most qualifying employees (0 keeps all).
+type TitleComparisonOptions struct {
+	Grouping     string
+	Normalizer   *taxonomy.Normalizer
+	MinCount     int
+	MinLocations int
+	TopN         int
+}
+
+// CompareTitlesAcrossLocations joins title pay across the locations of one
+// year, grouping titles by normalized title unless told otherwise
+func CompareTitlesAcrossLocations(year int, datasets []*models.WageData, opts TitleComparisonOptions) (*models.TitleComparisonReport, error) {
+	if opts.Grouping == "" {
+		opts.Grouping = GroupNormalized
+	}
+	if opts.Normalizer == nil {
+		opts.Normalizer = taxonomy.DefaultNormalizer()
+	}
+	if opts.MinLocations < 2 {
+		opts.MinLocations = 2
+	}
+
+	keyFor, err := titleGrouping(TitleOptions{Grouping: opts.Grouping, Normalizer: opts.Normalizer})
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect wages per title per location
+	titleWages := make(map[string]map[string][]float64)
+	titleVariants := make(map[string]map[string]bool)
+	keys := make(map[string]string)
+	locationSet := make(map[string]bool)
+
+	for _, data := range datasets {
+		if data.Year != year {
+			continue
+		}
+
+		for _, record := range data.Records {
+			if !isKnownTitle(record.Title) {
+				continue
+			}
+
+			_, _, _, gross := parser.ConvertRecordToFloat(record)
+			if gross <= 0 {
+				continue
+			}
+
+			key, ok := keys[record.Title]
+			if !ok {
+				key = keyFor(record.Title)
+				keys[record.Title] = key
+			}
+
+			if _, exists := titleWages[key]; !exists {
+				titleWages[key] = make(map[string][]float64)
+				titleVariants[key] = make(map[string]bool)
+			}
+			titleWages[key][data.Location] = append(titleWages[key][data.Location], gross)
+			titleVariants[key][record.Title] = true
+		}
+
+		locationSet[data.Location] = true
+	}
+
+	if len(locationSet) == 0 {
+		return nil, nil
+	}
+
+	var titles []models.TitleComparison
+	for title, byLocation := range titleWages {
+		comparison := compareTitle(title, byLocation, opts.MinCount)
+		if comparison.Locations < opts.MinLocations {
+			continue
+		}
+
+		if opts.Grouping != GroupRaw {
+			comparison.Variants = sortedKeys(titleVariants[title])
+		}
+		titles = append(titles, comparison)
+	}
+
+	// Most widely held titles first
+	sort.Slice(titles, func(i, j int) bool {
+		if titles[i].Count == titles[j].Count {
+			return titles[i].Title < titles[j].Title
+		}
+		return titles[i].Count > titles[j].Count
+	})
+
+	if opts.TopN > 0 && len(titles) > opts.TopN {
+		titles = titles[:opts.TopN]
+	}
+
+	report := &models.TitleComparisonReport{
+		Year:         year,
+		GeneratedAt:  time.Now(),
+		Grouping:     opts.Grouping,
+		MinCount:     opts.MinCount,
+		MinLocations: opts.MinLocations,
+		Locations:    sortedKeys(locationSet),
+		Titles:       titles,
+	}
+	if opts.Grouping != GroupRaw {
+		report.Normalization = opts.Normalizer.Name() + "@" + opts.Normalizer.Version()
+	}
+
+	return report, nil
+}
+
+// compareTitle builds per-location distributions for the locations meeting
+// the headcount threshold and ranks them by median. Smaller locations only
+// report their headcount.
+func compareTitle(title string, byLocation map[string][]float64, minCount int) models.TitleComparison {
+	comparison := models.TitleComparison{Title: title}
+
+	var pooled []float64
+	for _, location := range sortedLocations(byLocation) {
+		wages := byLocation[location]
+		if len(wages) < minCount {
+			comparison.BelowThreshold = append(comparison.BelowThreshold, models.LocationCount{
+				Location: location,
+				Count:    len(wages),
+			})
+			continue
+		}
+
+		sort.Float64s(wages)
+		avgPay, _ := stats.Mean(wages)
+		medianPay, _ := stats.Median(wages)
+
+		percentiles := make(map[string]float64)
+		for _, p := range TitleComparisonPercentiles {
+			percentiles[formatPercentileKey(p)] = percentileOf(wages, p)
+		}
+
+		comparison.ByLocation = append(comparison.ByLocation, models.TitleLocationPay{
+			Location:    location,
+			Count:       len(wages),
+			AvgPay:      avgPay,
+			MedianPay:   medianPay,
+			MinPay:      wages[0],
+			MaxPay:      wages[len(wages)-1],
+			TotalPay:    sumFloat64(wages),
+			Percentiles: percentiles,
+		})
+		pooled = append(pooled, wages...)
+	}
+
+	comparison.Locations = len(comparison.ByLocation)
+	comparison.Count = len(pooled)
+	if comparison.Locations == 0 {
+		return comparison
+	}
+
+	comparison.PooledAvgPay, _ = stats.Mean(pooled)
+	comparison.PooledMedian, _ = stats.Median(pooled)
+
+	// Highest median first
+	sort.SliceStable(comparison.ByLocation, func(i, j int) bool {
+		return comparison.ByLocation[i].MedianPay > comparison.ByLocation[j].MedianPay
+	})
+	for i := range comparison.ByLocation {
+		location := &comparison.ByLocation[i]
+		location.MedianRank = i + 1
+		location.RatioToPooledMedian = safeRatio(location.MedianPay, comparison.PooledMedian)
+	}
+
+	lowest := comparison.ByLocation[len(comparison.ByLocation)-1].MedianPay
+	comparison.MedianSpread = safeRatio(comparison.ByLocation[0].MedianPay, lowest)
+
+	return comparison
+}
+
+// sortedLocations returns the locations of a wage map in name order
+func sortedLocations(byLocation map[string][]float64) []string {
+	locations := make([]string, 0, len(byLocation))
+	for location := range byLocation {
+		locations = append(locations, location)
+	}
+	sort.Strings(locations)
+	return locations
+}
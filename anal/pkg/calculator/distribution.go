@@ -0,0 +1,142 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+// AtkinsonEpsilons are the inequality aversion parameters reported
+var AtkinsonEpsilons = []float64{0.5, 1, 2}
+
+// CalculateDistribution computes inequality and shape metrics for wage data
+func CalculateDistribution(data *models.WageData, lorenzPoints int) (*models.Distribution, error) {
+	var grossPays []float64
+
+	for _, record := range data.Records {
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross > 0 {
+			grossPays = append(grossPays, gross)
+		}
+	}
+
+	if len(grossPays) == 0 {
+		return nil, nil
+	}
+
+	sort.Float64s(grossPays)
+
+	p10, _ := stats.Percentile(grossPays, 10)
+	p50, _ := stats.Percentile(grossPays, 50)
+	p90, _ := stats.Percentile(grossPays, 90)
+
+	distribution := &models.Distribution{
+		Location:      data.Location,
+		Year:          data.Year,
+		GeneratedAt:   time.Now(),
+		EmployeeCount: len(grossPays),
+		Gini:          CalculateGiniCoefficient(grossPays),
+		Theil:         CalculateTheilIndex(grossPays),
+		Atkinson:      make(map[string]float64),
+		Ratio90To10:   safeRatio(p90, p10),
+		Ratio90To50:   safeRatio(p90, p50),
+		Ratio50To10:   safeRatio(p50, p10),
+		Skewness:      CalculateSkewness(grossPays),
+		Kurtosis:      CalculateKurtosis(grossPays),
+		Deciles:       CalculateQuantileGroups(grossPays, 10),
+		Quintiles:     CalculateQuantileGroups(grossPays, 5),
+		Lorenz:        CalculateLorenzCurve(grossPays, lorenzPoints),
+	}
+
+	for _, epsilon := range AtkinsonEpsilons {
+		key := fmt.Sprintf("e%g", epsilon)
+		distribution.Atkinson[key] = CalculateAtkinsonIndex(grossPays, epsilon)
+	}
+
+	// Palma ratio: share of the top 10% over share of the bottom 40%
+	deciles := distribution.Deciles
+	if len(deciles) == 10 {
+		bottom40 := deciles[0].Share + deciles[1].Share + deciles[2].Share + deciles[3].Share
+		distribution.PalmaRatio = safeRatio(deciles[9].Share, bottom40)
+	}
+
+	return distribution, nil
+}
+
+// CalculateLorenzCurve returns evenly spaced Lorenz curve points from
+// (0, 0) to (100, 100). Wages must be sorted ascending.
+func CalculateLorenzCurve(sortedWages []float64, points int) []models.LorenzPoint {
+	if len(sortedWages) == 0 || points < 1 {
+		return nil
+	}
+
+	total := sumFloat64(sortedWages)
+	if total == 0 {
+		return nil
+	}
+
+	n := len(sortedWages)
+	curve := []models.LorenzPoint{{PopulationShare: 0, IncomeShare: 0}}
+
+	cumulative := 0.0
+	index := 0
+	for i := 1; i <= points; i++ {
+		upTo := n * i / points
+		for ; index < upTo; index++ {
+			cumulative += sortedWages[index]
+		}
+
+		curve = append(curve, models.LorenzPoint{
+			PopulationShare: float64(i) / float64(points) * 100,
+			IncomeShare:     cumulative / total * 100,
+		})
+	}
+
+	return curve
+}
+
+// CalculateQuantileGroups splits sorted wages into equal-sized groups and
+// reports each group's pay range and share of total pay
+func CalculateQuantileGroups(sortedWages []float64, groups int) []models.QuantileGroup {
+	n := len(sortedWages)
+	if n < groups || groups < 1 {
+		return nil
+	}
+
+	total := sumFloat64(sortedWages)
+	result := make([]models.QuantileGroup, 0, groups)
+
+	for g := 0; g < groups; g++ {
+		start := n * g / groups
+		end := n * (g + 1) / groups
+		slice := sortedWages[start:end]
+
+		groupTotal := sumFloat64(slice)
+		group := models.QuantileGroup{
+			Group:  g + 1,
+			Count:  len(slice),
+			MinPay: slice[0],
+			MaxPay: slice[len(slice)-1],
+			AvgPay: groupTotal / float64(len(slice)),
+		}
+		if total > 0 {
+			group.Share = groupTotal / total * 100
+		}
+
+		result = append(result, group)
+	}
+
+	return result
+}
+
+// safeRatio divides a by b, returning zero when b is zero
+func safeRatio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
@@ -0,0 +1,336 @@
+package calculator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// FlowOptions controls headcount flows. Grouping and Normalizer decide
+// which titles are the same from one year to the next, as for title
+// trajectories, and TopN (default 10) limits each list of titles.
+type FlowOptions struct {
+	Grouping   string
+	Normalizer *taxonomy.Normalizer
+	TopN       int
+}
+
+// flowYear is one year of a location's title statistics
+type flowYear struct {
+	year        int
+	points      map[string]models.TitleYearPoint
+	percentiles map[string]float64
+}
+
+// CalculateHeadcountFlows compares each pair of adjacent years of data at
+// every location and for all locations combined, using the per-title
+// statistics from SummarizeTitleYear. Record ids restart in every file, so
+// flows are estimated from title headcounts rather than individuals.
+// Combined flows only cover the locations with data in both years, so a
+// location joining or leaving the data is not counted as a flow.
+func CalculateHeadcountFlows(years []*TitleYear, opts FlowOptions) []*models.HeadcountFlowReport {
+	opts = flowDefaults(opts)
+
+	sort.Slice(years, func(i, j int) bool {
+		return years[i].Year < years[j].Year
+	})
+
+	series := make(map[string][]flowYear)
+	coverage := make(map[int]map[string]bool)
+	byYear := make(map[int]*TitleYear, len(years))
+	for _, titleYear := range years {
+		byYear[titleYear.Year] = titleYear
+		coverage[titleYear.Year] = make(map[string]bool)
+		for location, points := range titleYear.Points {
+			if location != SystemLocation {
+				coverage[titleYear.Year][location] = true
+			}
+			series[location] = append(series[location], flowYear{
+				year:        titleYear.Year,
+				points:      points,
+				percentiles: titleYear.Percentiles[location],
+			})
+		}
+	}
+
+	var reports []*models.HeadcountFlowReport
+	for location, locationYears := range series {
+		report := &models.HeadcountFlowReport{
+			Location:    location,
+			GeneratedAt: time.Now(),
+			Grouping:    opts.Grouping,
+			FirstYear:   locationYears[0].year,
+			LastYear:    locationYears[len(locationYears)-1].year,
+			TopN:        opts.TopN,
+			Flows:       []models.HeadcountFlow{},
+		}
+		if opts.Grouping != GroupRaw {
+			report.Normalization = opts.Normalizer.Name() + "@" + opts.Normalizer.Version()
+		}
+
+		for i := 1; i < len(locationYears); i++ {
+			previous, current := locationYears[i-1], locationYears[i]
+			if location == SystemLocation {
+				shared := sharedCoverage(coverage[previous.year], coverage[current.year])
+				previous = sharedFlowYear(byYear[previous.year], shared)
+				current = sharedFlowYear(byYear[current.year], shared)
+			}
+
+			flow := headcountFlow(previous, current, opts.TopN)
+			if location == SystemLocation {
+				flow.AddedLocations, flow.DroppedLocations = coverageChanges(coverage[flow.PreviousYear], coverage[flow.Year])
+			}
+			report.Flows = append(report.Flows, flow)
+		}
+
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Location < reports[j].Location
+	})
+
+	return reports
+}
+
+func flowDefaults(opts FlowOptions) FlowOptions {
+	if opts.Grouping == "" {
+		opts.Grouping = GroupRaw
+	}
+	if opts.Normalizer == nil {
+		opts.Normalizer = taxonomy.DefaultNormalizer()
+	}
+	if opts.TopN < 1 {
+		opts.TopN = 10
+	}
+	return opts
+}
+
+// sharedCoverage returns the locations with data in both years
+func sharedCoverage(previous, current map[string]bool) map[string]bool {
+	shared := make(map[string]bool)
+	for location := range previous {
+		if current[location] {
+			shared[location] = true
+		}
+	}
+	return shared
+}
+
+// sharedFlowYear summarizes a year's titles over the given locations only
+func sharedFlowYear(titleYear *TitleYear, locations map[string]bool) flowYear {
+	byTitle := make(map[string][]float64)
+	var allWages []float64
+	for location, titles := range titleYear.wages {
+		if !locations[location] {
+			continue
+		}
+		for title, wages := range titles {
+			byTitle[title] = append(byTitle[title], wages...)
+			allWages = append(allWages, wages...)
+		}
+	}
+
+	points := make(map[string]models.TitleYearPoint, len(byTitle))
+	for title, wages := range byTitle {
+		points[title] = titleYearPoint(titleYear.Year, wages)
+	}
+
+	return flowYear{
+		year:        titleYear.Year,
+		points:      points,
+		percentiles: payPercentiles(allWages),
+	}
+}
+
+// coverageChanges lists the locations only in the current year and those
+// only in the previous year
+func coverageChanges(previous, current map[string]bool) ([]string, []string) {
+	var added, dropped []string
+	for _, location := range sortedKeys(current) {
+		if !previous[location] {
+			added = append(added, location)
+		}
+	}
+	for _, location := range sortedKeys(previous) {
+		if !current[location] {
+			dropped = append(dropped, location)
+		}
+	}
+	return added, dropped
+}
+
+// headcountFlow compares two years of a location's titles
+func headcountFlow(previous, current flowYear, topN int) models.HeadcountFlow {
+	flow := models.HeadcountFlow{
+		PreviousYear: previous.year,
+		Year:         current.year,
+	}
+
+	titles := make(map[string]bool)
+	var previousPayroll, payroll float64
+	for title, point := range previous.points {
+		titles[title] = true
+		flow.PreviousHeadcount += point.Count
+		previousPayroll += point.TotalPay
+	}
+	for title, point := range current.points {
+		titles[title] = true
+		flow.Headcount += point.Count
+		payroll += point.TotalPay
+	}
+
+	var gainers, losers, births, deaths []models.TitleFlow
+	var medianGrowths []float64
+
+	for title := range titles {
+		before, existed := previous.points[title]
+		after, exists := current.points[title]
+		titleChange := titleFlow(title, before, after)
+
+		switch {
+		case !existed:
+			flow.TitleBirths++
+			flow.BirthHeadcount += after.Count
+			births = append(births, titleChange)
+		case !exists:
+			flow.TitleDeaths++
+			flow.DeathHeadcount += before.Count
+			deaths = append(deaths, titleChange)
+		default:
+			flow.ContinuingTitles++
+			medianGrowths = append(medianGrowths, titleChange.MedianGrowth)
+			if titleChange.Change > 0 {
+				gainers = append(gainers, titleChange)
+			} else if titleChange.Change < 0 {
+				losers = append(losers, titleChange)
+			}
+		}
+
+		if titleChange.Change > 0 {
+			flow.GrossGain += titleChange.Change
+		} else {
+			flow.GrossLoss -= titleChange.Change
+		}
+	}
+
+	flow.NetChange = flow.Headcount - flow.PreviousHeadcount
+	flow.NetGrowth = percentChange(float64(flow.PreviousHeadcount), float64(flow.Headcount))
+	flow.Reallocation = flow.GrossGain + flow.GrossLoss
+	if flow.NetChange < 0 {
+		flow.Reallocation += flow.NetChange
+	} else {
+		flow.Reallocation -= flow.NetChange
+	}
+
+	flow.Payroll = decomposePayroll(flow.PreviousHeadcount, flow.Headcount, previousPayroll, payroll)
+	flow.PayShift = payShift(previous.percentiles, current.percentiles, medianGrowths)
+
+	flow.Gainers = topTitleFlows(gainers, topN, func(a, b models.TitleFlow) bool { return a.Change > b.Change })
+	flow.Losers = topTitleFlows(losers, topN, func(a, b models.TitleFlow) bool { return a.Change < b.Change })
+	flow.Births = topTitleFlows(births, topN, func(a, b models.TitleFlow) bool { return a.Count > b.Count })
+	flow.Deaths = topTitleFlows(deaths, topN, func(a, b models.TitleFlow) bool { return a.PreviousCount > b.PreviousCount })
+
+	return flow
+}
+
+// titleFlow compares a title's points, either of which may be missing
+func titleFlow(title string, before, after models.TitleYearPoint) models.TitleFlow {
+	result := models.TitleFlow{
+		Title:          title,
+		PreviousCount:  before.Count,
+		Count:          after.Count,
+		Change:         after.Count - before.Count,
+		PreviousMedian: before.MedianPay,
+		Median:         after.MedianPay,
+		PayrollChange:  after.TotalPay - before.TotalPay,
+	}
+	if before.Count > 0 && after.Count > 0 {
+		result.MedianGrowth = percentChange(before.MedianPay, after.MedianPay)
+	}
+
+	result.HeadcountEffect, result.PerCapitaEffect = payrollEffects(before.Count, after.Count, before.TotalPay, after.TotalPay)
+	return result
+}
+
+// payrollEffects splits a payroll change into the headcount change at the
+// average per-capita pay of both years and the per-capita pay change at
+// the average headcount. When either year has no employees its per-capita
+// pay is taken from the other, so the whole change is headcount.
+func payrollEffects(previousCount, count int, previousPayroll, payroll float64) (float64, float64) {
+	previousPerCapita := safeRatio(previousPayroll, float64(previousCount))
+	perCapita := safeRatio(payroll, float64(count))
+	if previousCount == 0 {
+		previousPerCapita = perCapita
+	}
+	if count == 0 {
+		perCapita = previousPerCapita
+	}
+
+	headcountEffect := float64(count-previousCount) * (previousPerCapita + perCapita) / 2
+	perCapitaEffect := (perCapita - previousPerCapita) * float64(previousCount+count) / 2
+	return headcountEffect, perCapitaEffect
+}
+
+// decomposePayroll splits a location's payroll change into headcount and
+// per-capita pay effects
+func decomposePayroll(previousCount, count int, previousPayroll, payroll float64) models.PayrollDecomposition {
+	result := models.PayrollDecomposition{
+		PreviousPayroll:   previousPayroll,
+		Payroll:           payroll,
+		Change:            payroll - previousPayroll,
+		Growth:            percentChange(previousPayroll, payroll),
+		PreviousPerCapita: safeRatio(previousPayroll, float64(previousCount)),
+		PerCapita:         safeRatio(payroll, float64(count)),
+	}
+	result.PerCapitaGrowth = percentChange(result.PreviousPerCapita, result.PerCapita)
+	result.HeadcountEffect, result.PerCapitaEffect = payrollEffects(previousCount, count, previousPayroll, payroll)
+	result.HeadcountShare = safeRatio(result.HeadcountEffect, result.Change) * 100
+	result.PerCapitaShare = safeRatio(result.PerCapitaEffect, result.Change) * 100
+
+	return result
+}
+
+// payShift compares the percentiles of two years' pay
+func payShift(previous, current map[string]float64, medianGrowths []float64) models.PayShift {
+	shift := models.PayShift{
+		Previous: previous,
+		Current:  current,
+		Growth:   make(map[string]float64),
+	}
+	for key, value := range current {
+		if before, ok := previous[key]; ok {
+			shift.Growth[key] = percentChange(before, value)
+		}
+	}
+
+	if len(medianGrowths) > 0 {
+		sort.Float64s(medianGrowths)
+		shift.ContinuingMedianGrowth = percentileOf(medianGrowths, 50)
+	}
+	return shift
+}
+
+// topTitleFlows orders title flows by less, then by title, and keeps the
+// first n
+func topTitleFlows(flows []models.TitleFlow, n int, less func(a, b models.TitleFlow) bool) []models.TitleFlow {
+	sort.Slice(flows, func(i, j int) bool {
+		if less(flows[i], flows[j]) {
+			return true
+		}
+		if less(flows[j], flows[i]) {
+			return false
+		}
+		return flows[i].Title < flows[j].Title
+	})
+
+	if len(flows) > n {
+		flows = flows[:n]
+	}
+	if flows == nil {
+		flows = []models.TitleFlow{}
+	}
+	return flows
+}
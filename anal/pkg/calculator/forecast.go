@@ -0,0 +1,507 @@
+package calculator
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// Forecast models
+const (
+	ModelLinear               = "linear"
+	ModelLogLinear            = "log_linear"
+	ModelExponentialSmoothing = "exponential_smoothing"
+)
+
+// Forecast metrics, named after the summary fields they project
+const (
+	MetricEmployeeCount = "employee_count"
+	MetricTotalGrossPay = "total_gross_pay"
+	MetricMedianPay     = "median_gross_pay"
+)
+
+// ForecastOptions controls CalculateForecasts. Horizon is the number of
+// years projected past the last year of data (default 5) and Confidence the
+// prediction interval level (default 0.95). Holdout years are held back to
+// score each model out of sample (default 3, negative disables). Locations
+// need MinYears years of summaries (default 5).
+type ForecastOptions struct {
+	Horizon    int
+	Confidence float64
+	Holdout    int
+	MinYears   int
+}
+
+// forecastFit is a model fitted to a series with its projections
+type forecastFit struct {
+	model       string
+	parameters  map[string]float64
+	fitted      []float64
+	projections []models.ForecastPoint
+	k           int
+}
+
+// forecastModel fits a series and projects it to future years
+type forecastModel func(years []int, values []float64, future []int, confidence float64) *forecastFit
+
+var forecastModels = []forecastModel{fitLinear, fitLogLinear, fitExponentialSmoothing}
+
+// CalculateForecasts fits linear, log-linear and exponential smoothing models
+// to the headcount, total payroll and median pay of each location's yearly
+// summaries and projects them forward. The adjuster is optional; when set,
+// dollar series are converted to real dollars before fitting.
+func CalculateForecasts(summaries []*models.Summary, adjuster *inflation.Adjuster, opts ForecastOptions) ([]*models.Forecast, error) {
+	opts = forecastDefaults(opts)
+
+	byLocation := make(map[string][]*models.Summary)
+	for _, summary := range summaries {
+		byLocation[summary.Location] = append(byLocation[summary.Location], summary)
+	}
+
+	var forecasts []*models.Forecast
+	for location, series := range byLocation {
+		if len(series) < opts.MinYears {
+			continue
+		}
+
+		sort.Slice(series, func(i, j int) bool {
+			return series[i].Year < series[j].Year
+		})
+
+		years := make([]int, len(series))
+		metrics := map[string][]float64{
+			MetricEmployeeCount: make([]float64, len(series)),
+			MetricTotalGrossPay: make([]float64, len(series)),
+			MetricMedianPay:     make([]float64, len(series)),
+		}
+		for i, summary := range series {
+			factor := 1.0
+			if adjuster != nil {
+				var err error
+				factor, err = adjuster.Factor(location, summary.Year)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			years[i] = summary.Year
+			metrics[MetricEmployeeCount][i] = float64(summary.EmployeeCount)
+			metrics[MetricTotalGrossPay][i] = summary.TotalGrossPay * factor
+			metrics[MetricMedianPay][i] = summary.MedianPay * factor
+		}
+
+		forecast := &models.Forecast{
+			Location:    location,
+			GeneratedAt: time.Now(),
+			FirstYear:   years[0],
+			LastYear:    years[len(years)-1],
+			Horizon:     opts.Horizon,
+			Confidence:  opts.Confidence,
+			Holdout:     opts.Holdout,
+		}
+
+		if adjuster != nil {
+			cpi := adjuster.SeriesFor(location)
+			forecast.Inflation = &models.InflationAdjustment{
+				Series:   cpi.ID,
+				Version:  cpi.Version,
+				BaseYear: adjuster.BaseYear(),
+			}
+		}
+
+		for _, metric := range []string{MetricEmployeeCount, MetricTotalGrossPay, MetricMedianPay} {
+			forecast.Series = append(forecast.Series, forecastSeries(metric, years, metrics[metric], opts))
+		}
+
+		forecasts = append(forecasts, forecast)
+	}
+
+	sort.Slice(forecasts, func(i, j int) bool {
+		return forecasts[i].Location < forecasts[j].Location
+	})
+
+	return forecasts, nil
+}
+
+func forecastDefaults(opts ForecastOptions) ForecastOptions {
+	if opts.Horizon <= 0 {
+		opts.Horizon = 5
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		opts.Confidence = 0.95
+	}
+	if opts.Holdout == 0 {
+		opts.Holdout = 3
+	}
+	if opts.Holdout < 0 {
+		opts.Holdout = 0
+	}
+	if opts.MinYears < 3 {
+		opts.MinYears = 5
+	}
+	return opts
+}
+
+// forecastSeries fits every model to one metric and picks the best
+func forecastSeries(metric string, years []int, values []float64, opts ForecastOptions) models.ForecastSeries {
+	series := models.ForecastSeries{
+		Metric:  metric,
+		History: seriesPoints(years, values),
+		Models:  []models.ForecastModel{},
+	}
+
+	last := years[len(years)-1]
+	future := make([]int, opts.Horizon)
+	for i := range future {
+		future[i] = last + i + 1
+	}
+
+	// Hold out the last years when enough remain to fit every model
+	holdout := opts.Holdout
+	if len(years)-holdout < 3 {
+		holdout = 0
+	}
+	cut := len(years) - holdout
+
+	for _, fit := range forecastModels {
+		result := fit(years, values, future, opts.Confidence)
+		if result == nil {
+			continue
+		}
+
+		model := forecastFitMetrics(result, years, values)
+		if holdout > 0 {
+			if backtest := fit(years[:cut], values[:cut], years[cut:], opts.Confidence); backtest != nil {
+				var projected []float64
+				for _, point := range backtest.projections {
+					projected = append(projected, point.Value)
+				}
+				model.HoldoutMAPE = meanAbsPercentError(values[cut:], projected)
+			}
+		}
+		series.Models = append(series.Models, model)
+	}
+
+	// Lowest holdout error, or lowest AIC without a holdout
+	best := -1
+	for i, model := range series.Models {
+		if best < 0 {
+			best = i
+			continue
+		}
+		if holdout > 0 && model.HoldoutMAPE < series.Models[best].HoldoutMAPE {
+			best = i
+		} else if holdout == 0 && model.AIC < series.Models[best].AIC {
+			best = i
+		}
+	}
+	if best >= 0 {
+		series.Best = series.Models[best].Model
+	}
+
+	return series
+}
+
+// forecastFitMetrics scores a fit against history
+func forecastFitMetrics(fit *forecastFit, years []int, values []float64) models.ForecastModel {
+	n := float64(len(values))
+	mean := sumFloat64(values) / n
+
+	sse, sst, absError := 0.0, 0.0, 0.0
+	for i, value := range values {
+		residual := value - fit.fitted[i]
+		sse += residual * residual
+		sst += (value - mean) * (value - mean)
+		absError += math.Abs(residual)
+	}
+
+	model := models.ForecastModel{
+		Model:       fit.model,
+		Parameters:  fit.parameters,
+		RMSE:        math.Sqrt(sse / n),
+		MAE:         absError / n,
+		MAPE:        meanAbsPercentError(values, fit.fitted),
+		AIC:         n*math.Log(math.Max(sse/n, 1e-12)) + 2*float64(fit.k),
+		Fitted:      seriesPoints(years, fit.fitted),
+		Projections: fit.projections,
+	}
+	if sst > 0 {
+		model.RSquared = 1 - sse/sst
+	}
+	return model
+}
+
+// fitLinear fits an ordinary least squares trend line
+func fitLinear(years []int, values []float64, future []int, confidence float64) *forecastFit {
+	line := fitLine(years, values)
+	if line == nil {
+		return nil
+	}
+
+	t := tQuantile((1+confidence)/2, line.n-2)
+	fit := &forecastFit{
+		model: ModelLinear,
+		parameters: map[string]float64{
+			"intercept": line.intercept,
+			"slope":     line.slope,
+		},
+		k: 2,
+	}
+	for _, year := range years {
+		fit.fitted = append(fit.fitted, line.predict(year))
+	}
+	for _, year := range future {
+		value := line.predict(year)
+		margin := t * line.predictionError(year)
+		fit.projections = append(fit.projections, models.ForecastPoint{
+			Year:  year,
+			Value: value,
+			Lower: value - margin,
+			Upper: value + margin,
+		})
+	}
+	return fit
+}
+
+// fitLogLinear fits a trend line to the log of the values, which projects
+// constant percentage growth. Values must all be positive.
+func fitLogLinear(years []int, values []float64, future []int, confidence float64) *forecastFit {
+	logs := make([]float64, len(values))
+	for i, value := range values {
+		if value <= 0 {
+			return nil
+		}
+		logs[i] = math.Log(value)
+	}
+
+	line := fitLine(years, logs)
+	if line == nil {
+		return nil
+	}
+
+	t := tQuantile((1+confidence)/2, line.n-2)
+	fit := &forecastFit{
+		model: ModelLogLinear,
+		parameters: map[string]float64{
+			"intercept":     line.intercept,
+			"slope":         line.slope,
+			"annual_growth": (math.Exp(line.slope) - 1) * 100,
+		},
+		k: 2,
+	}
+	for _, year := range years {
+		fit.fitted = append(fit.fitted, math.Exp(line.predict(year)))
+	}
+	for _, year := range future {
+		value := line.predict(year)
+		margin := t * line.predictionError(year)
+		fit.projections = append(fit.projections, models.ForecastPoint{
+			Year:  year,
+			Value: math.Exp(value),
+			Lower: math.Exp(value - margin),
+			Upper: math.Exp(value + margin),
+		})
+	}
+	return fit
+}
+
+// fitExponentialSmoothing fits Holt's linear trend method, choosing the
+// level and trend smoothing weights that minimize one-step-ahead error
+func fitExponentialSmoothing(years []int, values []float64, future []int, confidence float64) *forecastFit {
+	if len(values) < 3 {
+		return nil
+	}
+
+	// Grid search both weights in steps of 0.05
+	var best *holtState
+	for a := 1; a < 20; a++ {
+		for b := 1; b < 20; b++ {
+			state := holt(values, float64(a)/20, float64(b)/20)
+			if best == nil || state.sse < best.sse {
+				best = state
+			}
+		}
+	}
+
+	// Residual variance from the one-step errors after the first two points
+	sigma := math.Sqrt(best.sse / float64(len(values)-2))
+	z := normalQuantile((1 + confidence) / 2)
+
+	fit := &forecastFit{
+		model: ModelExponentialSmoothing,
+		parameters: map[string]float64{
+			"alpha": best.alpha,
+			"beta":  best.beta,
+			"level": best.level,
+			"trend": best.trend,
+		},
+		fitted: best.fitted,
+		k:      4,
+	}
+
+	last := years[len(years)-1]
+	for _, year := range future {
+		h := year - last
+		value := best.level + float64(h)*best.trend
+
+		variance := 1.0
+		for j := 1; j < h; j++ {
+			weight := best.alpha * (1 + float64(j)*best.beta)
+			variance += weight * weight
+		}
+		margin := z * sigma * math.Sqrt(variance)
+
+		fit.projections = append(fit.projections, models.ForecastPoint{
+			Year:  year,
+			Value: value,
+			Lower: value - margin,
+			Upper: value + margin,
+		})
+	}
+	return fit
+}
+
+// holtState is the result of running Holt's method over a series
+type holtState struct {
+	alpha, beta  float64
+	level, trend float64
+	fitted       []float64
+	sse          float64
+}
+
+// holt runs Holt's linear trend method, starting from the first value and
+// first difference, and accumulates the one-step-ahead squared error
+func holt(values []float64, alpha, beta float64) *holtState {
+	state := &holtState{
+		alpha:  alpha,
+		beta:   beta,
+		level:  values[0],
+		trend:  values[1] - values[0],
+		fitted: []float64{values[0]},
+	}
+
+	for i := 1; i < len(values); i++ {
+		predicted := state.level + state.trend
+		state.fitted = append(state.fitted, predicted)
+		if i > 1 {
+			state.sse += (values[i] - predicted) * (values[i] - predicted)
+		}
+
+		level := alpha*values[i] + (1-alpha)*predicted
+		state.trend = beta*(level-state.level) + (1-beta)*state.trend
+		state.level = level
+	}
+
+	return state
+}
+
+// trendLine is an ordinary least squares fit of values on years
+type trendLine struct {
+	intercept, slope float64
+	origin           int
+	n                int
+	meanX, sxx       float64
+	residualStdDev   float64
+}
+
+// fitLine regresses values on years measured from the first year. At least
+// three points are needed to estimate the residual variance.
+func fitLine(years []int, values []float64) *trendLine {
+	n := len(values)
+	if n < 3 {
+		return nil
+	}
+
+	line := &trendLine{origin: years[0], n: n}
+	meanY := 0.0
+	for i, year := range years {
+		line.meanX += float64(year - line.origin)
+		meanY += values[i]
+	}
+	line.meanX /= float64(n)
+	meanY /= float64(n)
+
+	sxy := 0.0
+	for i, year := range years {
+		dx := float64(year-line.origin) - line.meanX
+		line.sxx += dx * dx
+		sxy += dx * (values[i] - meanY)
+	}
+	if line.sxx == 0 {
+		return nil
+	}
+
+	line.slope = sxy / line.sxx
+	line.intercept = meanY - line.slope*line.meanX
+
+	sse := 0.0
+	for i, year := range years {
+		residual := values[i] - line.predict(year)
+		sse += residual * residual
+	}
+	line.residualStdDev = math.Sqrt(sse / float64(n-2))
+
+	return line
+}
+
+func (l *trendLine) predict(year int) float64 {
+	return l.intercept + l.slope*float64(year-l.origin)
+}
+
+// predictionError is the standard error of a new observation at year
+func (l *trendLine) predictionError(year int) float64 {
+	dx := float64(year-l.origin) - l.meanX
+	return l.residualStdDev * math.Sqrt(1+1/float64(l.n)+dx*dx/l.sxx)
+}
+
+// normalQuantile returns the standard normal quantile of p
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// tQuantile approximates the Student t quantile of p with df degrees of
+// freedom using the Cornish-Fisher expansion around the normal quantile
+func tQuantile(p float64, df int) float64 {
+	z := normalQuantile(p)
+	if df < 1 {
+		return z
+	}
+
+	v := float64(df)
+	z2 := z * z
+	g1 := (z2 + 1) * z / 4
+	g2 := ((5*z2+16)*z2 + 3) * z / 96
+	g3 := (((3*z2+19)*z2+17)*z2 - 15) * z / 384
+	g4 := ((((79*z2+776)*z2+1482)*z2-1920)*z2 - 945) * z / 92160
+
+	return z + g1/v + g2/(v*v) + g3/(v*v*v) + g4/(v*v*v*v)
+}
+
+// meanAbsPercentError returns the mean absolute percent error of predictions,
+// skipping actual values of zero
+func meanAbsPercentError(actual, predicted []float64) float64 {
+	total := 0.0
+	count := 0
+	for i, value := range actual {
+		if value == 0 || i >= len(predicted) {
+			continue
+		}
+		total += math.Abs((value - predicted[i]) / value)
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count) * 100
+}
+
+func seriesPoints(years []int, values []float64) []models.SeriesPoint {
+	points := make([]models.SeriesPoint, len(years))
+	for i, year := range years {
+		points[i] = models.SeriesPoint{Year: year, Value: values[i]}
+	}
+	return points
+}
@@ -0,0 +1,293 @@
+package calculator
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// Histogram scales
+const (
+	ScaleLinear = "linear"
+	ScaleLog    = "log"
+)
+
+// HistogramOptions controls CalculateHistograms. Linear bins are BinWidth
+// dollars wide (default 5000) from zero up to the ClipPercentile of pay
+// (default 99.5), with higher pay counted as overflow. Log bins split each
+// power of ten into BinsPerDecade bins (default 20) and cover every record.
+// Kernel densities are evaluated at KDEPoints points (default 200) using
+// Silverman's rule of thumb for the bandwidth unless Bandwidth is set, in
+// the units of the scale.
+type HistogramOptions struct {
+	BinWidth       float64
+	LogBins        bool
+	BinsPerDecade  int
+	ClipPercentile float64
+	KDEPoints      int
+	Bandwidth      float64
+}
+
+// CalculateHistograms bins the gross pay of a location-year and estimates
+// its density, overall and for each top-level category of the given
+// taxonomy (the bundled one when nil)
+func CalculateHistograms(data *models.WageData, tax *taxonomy.Taxonomy, opts HistogramOptions) (*models.WageHistogram, error) {
+	opts = histogramDefaults(opts)
+	if tax == nil {
+		tax = taxonomy.Default()
+	}
+
+	categorizer := newCategoryCache(tax)
+	var wages []float64
+	byCategory := make(map[string][]float64)
+
+	for _, record := range data.Records {
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+		wages = append(wages, gross)
+		category := categorizer.categorize(record.Title)
+		byCategory[category] = append(byCategory[category], gross)
+	}
+
+	if len(wages) == 0 {
+		return nil, nil
+	}
+
+	sort.Float64s(wages)
+
+	histogram := models.Histogram{Scale: ScaleLinear, BinWidth: opts.BinWidth}
+	if opts.LogBins {
+		histogram = models.Histogram{Scale: ScaleLog, BinsPerDecade: opts.BinsPerDecade}
+	}
+	histogram.Edges = histogramEdges(wages, opts)
+	histogram.Counts, histogram.Overflow = binWages(wages, histogram.Edges)
+	histogram.Density = binDensity(histogram.Counts, histogram.Edges, histogram.Scale, len(wages))
+
+	grid := densityGrid(histogram.Edges, histogram.Scale, opts.KDEPoints)
+	bandwidth, density := kernelDensity(wages, grid, histogram.Scale, opts.Bandwidth)
+
+	result := &models.WageHistogram{
+		Location:    data.Location,
+		Year:        data.Year,
+		GeneratedAt: time.Now(),
+		Count:       len(wages),
+		Histogram:   histogram,
+		KDE: models.DensityEstimate{
+			Kernel:    "gaussian",
+			Scale:     histogram.Scale,
+			Bandwidth: bandwidth,
+			X:         gridDollars(grid, histogram.Scale),
+			Density:   density,
+		},
+		Categories:      []models.CategoryHistogram{},
+		Taxonomy:        tax.Name(),
+		TaxonomyVersion: tax.Version(),
+	}
+
+	for category, categoryWages := range byCategory {
+		sort.Float64s(categoryWages)
+
+		counts, overflow := binWages(categoryWages, histogram.Edges)
+		categoryBandwidth, categoryDensity := kernelDensity(categoryWages, grid, histogram.Scale, opts.Bandwidth)
+		result.Categories = append(result.Categories, models.CategoryHistogram{
+			Category:  category,
+			Count:     len(categoryWages),
+			Share:     float64(len(categoryWages)) / float64(len(wages)) * 100,
+			Counts:    counts,
+			Density:   binDensity(counts, histogram.Edges, histogram.Scale, len(categoryWages)),
+			Overflow:  overflow,
+			Bandwidth: categoryBandwidth,
+			KDE:       categoryDensity,
+		})
+	}
+
+	// Largest categories first
+	sort.Slice(result.Categories, func(i, j int) bool {
+		a, b := result.Categories[i], result.Categories[j]
+		if a.Count == b.Count {
+			return a.Category < b.Category
+		}
+		return a.Count > b.Count
+	})
+
+	return result, nil
+}
+
+func histogramDefaults(opts HistogramOptions) HistogramOptions {
+	if opts.BinWidth <= 0 {
+		opts.BinWidth = 5000
+	}
+	if opts.BinsPerDecade < 1 {
+		opts.BinsPerDecade = 20
+	}
+	if opts.ClipPercentile <= 0 || opts.ClipPercentile > 100 {
+		opts.ClipPercentile = 99.5
+	}
+	if opts.KDEPoints < 2 {
+		opts.KDEPoints = 200
+	}
+	return opts
+}
+
+// histogramEdges returns bin edges for sorted wages: multiples of the bin
+// width up to the clip percentile, or log-spaced edges spanning every power
+// of ten the wages fall in
+func histogramEdges(sorted []float64, opts HistogramOptions) []float64 {
+	if !opts.LogBins {
+		top := math.Ceil(percentileOf(sorted, opts.ClipPercentile)/opts.BinWidth) * opts.BinWidth
+		if top < opts.BinWidth {
+			top = opts.BinWidth
+		}
+		edges := make([]float64, int(math.Round(top/opts.BinWidth))+1)
+		for i := range edges {
+			edges[i] = float64(i) * opts.BinWidth
+		}
+		return edges
+	}
+
+	low := math.Floor(math.Log10(sorted[0]))
+	high := math.Ceil(math.Log10(sorted[len(sorted)-1]))
+	if high <= low {
+		high = low + 1
+	}
+
+	steps := int(high-low) * opts.BinsPerDecade
+	edges := make([]float64, steps+1)
+	for i := range edges {
+		edges[i] = roundSignificant(math.Pow(10, low+float64(i)/float64(opts.BinsPerDecade)), 4)
+	}
+	return edges
+}
+
+// binWages counts sorted wages between edges, including the top edge in
+// the last bin, and returns the number above the top edge separately
+func binWages(sorted []float64, edges []float64) ([]int, int) {
+	counts := make([]int, len(edges)-1)
+	overflow := 0
+	top := edges[len(edges)-1]
+
+	for _, wage := range sorted {
+		if wage > top {
+			overflow++
+			continue
+		}
+		bin := sort.Search(len(edges), func(i int) bool { return edges[i] > wage }) - 1
+		if bin >= len(counts) {
+			bin = len(counts) - 1
+		}
+		if bin < 0 {
+			bin = 0
+		}
+		counts[bin]++
+	}
+
+	return counts, overflow
+}
+
+// binDensity divides counts by the total and each bin's width on the scale
+func binDensity(counts []int, edges []float64, scale string, total int) []float64 {
+	density := make([]float64, len(counts))
+	for i, count := range counts {
+		width := edges[i+1] - edges[i]
+		if scale == ScaleLog {
+			width = math.Log10(edges[i+1]) - math.Log10(edges[i])
+		}
+		if count == 0 || width <= 0 {
+			continue
+		}
+		density[i] = roundSignificant(float64(count)/float64(total)/width, 4)
+	}
+	return density
+}
+
+// densityGrid returns evenly spaced points across the histogram's range on
+// the scale (log10 dollars for the log scale)
+func densityGrid(edges []float64, scale string, points int) []float64 {
+	low, high := edges[0], edges[len(edges)-1]
+	if scale == ScaleLog {
+		low, high = math.Log10(low), math.Log10(high)
+	}
+
+	grid := make([]float64, points)
+	step := (high - low) / float64(points-1)
+	for i := range grid {
+		grid[i] = low + float64(i)*step
+	}
+	return grid
+}
+
+// gridDollars converts grid points back to rounded dollars
+func gridDollars(grid []float64, scale string) []float64 {
+	dollars := make([]float64, len(grid))
+	for i, point := range grid {
+		if scale == ScaleLog {
+			dollars[i] = roundSignificant(math.Pow(10, point), 4)
+		} else {
+			dollars[i] = math.Round(point*100) / 100
+		}
+	}
+	return dollars
+}
+
+// kernelDensity evaluates a Gaussian kernel density estimate of sorted
+// wages at the grid points, on the log10 scale when asked. A zero bandwidth
+// selects Silverman's rule of thumb. The bandwidth used is returned.
+func kernelDensity(sorted []float64, grid []float64, scale string, bandwidth float64) (float64, []float64) {
+	values := sorted
+	if scale == ScaleLog {
+		values = make([]float64, len(sorted))
+		for i, wage := range sorted {
+			values[i] = math.Log10(wage)
+		}
+	}
+
+	if bandwidth <= 0 {
+		bandwidth = silvermanBandwidth(values)
+	}
+	if bandwidth <= 0 {
+		// Every value is the same; use the grid spacing
+		bandwidth = (grid[len(grid)-1] - grid[0]) / float64(len(grid)-1)
+	}
+
+	n := float64(len(values))
+	norm := 1 / (n * bandwidth * math.Sqrt(2*math.Pi))
+	density := make([]float64, len(grid))
+
+	for i, point := range grid {
+		// Values beyond five bandwidths contribute nothing measurable
+		lo := sort.SearchFloat64s(values, point-5*bandwidth)
+		hi := sort.SearchFloat64s(values, point+5*bandwidth)
+
+		sum := 0.0
+		for _, value := range values[lo:hi] {
+			u := (point - value) / bandwidth
+			sum += math.Exp(-u * u / 2)
+		}
+		density[i] = roundSignificant(sum*norm, 4)
+	}
+
+	return bandwidth, density
+}
+
+// silvermanBandwidth returns 0.9 min(sd, IQR/1.34) n^(-1/5) for sorted values
+func silvermanBandwidth(sorted []float64) float64 {
+	if len(sorted) < 2 {
+		return 0
+	}
+
+	sd, _ := stats.StandardDeviationSample(sorted)
+	spread := sd
+	iqr := percentileOf(sorted, 75) - percentileOf(sorted, 25)
+	if iqr > 0 && iqr/1.34 < spread {
+		spread = iqr / 1.34
+	}
+
+	return 0.9 * spread * math.Pow(float64(len(sorted)), -0.2)
+}
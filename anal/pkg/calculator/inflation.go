@@ -0,0 +1,101 @@
+package calculator
+
+import (
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// ApplyInflationToSummary fills the real-dollar fields of a summary
+func ApplyInflationToSummary(summary *models.Summary, adjuster *inflation.Adjuster) error {
+	adjustment, err := adjuster.Describe(summary.Location, summary.Year)
+	if err != nil {
+		return err
+	}
+	factor := adjustment.Factor
+
+	summary.Inflation = adjustment
+	summary.RealTotalGrossPay = summary.TotalGrossPay * factor
+	summary.RealAvgGrossPay = summary.AvgGrossPay * factor
+	summary.RealMedianPay = summary.MedianPay * factor
+	summary.RealStdDev = summary.StdDev * factor
+	summary.RealMinPay = summary.MinPay * factor
+	summary.RealMaxPay = summary.MaxPay * factor
+
+	summary.RealPercentiles = make(map[string]float64)
+	for key, value := range summary.Percentiles {
+		summary.RealPercentiles[key] = value * factor
+	}
+
+	summary.RealPayComponents = scaleComponents(summary.PayComponents, factor)
+
+	return nil
+}
+
+// ApplyInflationToPyramid fills the real-dollar fields of a pyramid.
+// Bracket edges stay nominal so brackets remain comparable across years.
+func ApplyInflationToPyramid(pyramid *models.Pyramid, adjuster *inflation.Adjuster) error {
+	adjustment, err := adjuster.Describe(pyramid.Location, pyramid.Year)
+	if err != nil {
+		return err
+	}
+	factor := adjustment.Factor
+
+	pyramid.Inflation = adjustment
+	pyramid.RealTotalPay = pyramid.TotalPay * factor
+
+	for i := range pyramid.Brackets {
+		bracket := &pyramid.Brackets[i]
+		bracket.RealAvgPay = bracket.AvgPay * factor
+		bracket.RealMedianPay = bracket.MedianPay * factor
+		bracket.RealTotalPay = bracket.TotalPay * factor
+		bracket.RealPayComponents = scaleComponents(bracket.PayComponents, factor)
+
+		for j := range bracket.TopTitles {
+			bracket.TopTitles[j].RealAvgPay = bracket.TopTitles[j].AvgPay * factor
+		}
+	}
+
+	return nil
+}
+
+// ApplyInflationToTitles fills the real-dollar fields of a title analysis
+func ApplyInflationToTitles(analysis *models.TitleAnalysis, adjuster *inflation.Adjuster) error {
+	adjustment, err := adjuster.Describe(analysis.Location, analysis.Year)
+	if err != nil {
+		return err
+	}
+	factor := adjustment.Factor
+
+	analysis.Inflation = adjustment
+
+	scaleTitleStats(analysis.TopTitles, factor)
+	for _, ranking := range analysis.Rankings {
+		scaleTitleStats(ranking.Titles, factor)
+	}
+
+	return nil
+}
+
+// scaleTitleStats fills the real-dollar fields of title statistics in place
+func scaleTitleStats(titles []models.TitleStats, factor float64) {
+	for i := range titles {
+		title := &titles[i]
+		title.RealAvgPay = title.AvgPay * factor
+		title.RealMedianPay = title.MedianPay * factor
+		title.RealMinPay = title.MinPay * factor
+		title.RealMaxPay = title.MaxPay * factor
+		title.RealTotalPay = title.TotalPay * factor
+	}
+}
+
+// scaleComponents returns a copy of pay components multiplied by factor
+func scaleComponents(components models.PayComponents, factor float64) *models.PayComponents {
+	return &models.PayComponents{
+		TotalBase:        components.TotalBase * factor,
+		TotalOvertime:    components.TotalOvertime * factor,
+		TotalAdjustments: components.TotalAdjustments * factor,
+		AvgBase:          components.AvgBase * factor,
+		AvgOvertime:      components.AvgOvertime * factor,
+		AvgAdjustments:   components.AvgAdjustments * factor,
+	}
+}
@@ -0,0 +1,511 @@
+package calculator
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// Weights of the parts of a linkage confidence score. Records are only
+// compared when their last names match, so the name part scores first
+// names.
+const (
+	linkNameWeight   = 0.6
+	linkCampusWeight = 0.2
+	linkTitleWeight  = 0.2
+)
+
+// raisePercentiles are reported for raise distributions
+var raisePercentiles = []float64{10, 25, 50, 75, 90}
+
+// raiseBands bucket raises in percent, from min up to but excluding max
+var raiseBands = []struct {
+	label    string
+	min, max float64
+}{
+	{"cut", math.Inf(-1), 0},
+	{"0-3%", 0, 3},
+	{"3-5%", 3, 5},
+	{"5-10%", 5, 10},
+	{"10-20%", 10, 20},
+	{"20%+", 20, math.Inf(1)},
+}
+
+// LinkageOptions controls employee linkage. Links need a confidence of at
+// least MinConfidence (default 0.8) and a margin of AmbiguityMargin
+// (default 0.05) over the next best candidate. Raises compare base pay of
+// at least MinBasePay in both years (default 33280, a full year at $16 an
+// hour) so partial years are left out, and distributions of fewer than
+// MinLinks employees (default 10) are not reported.
+type LinkageOptions struct {
+	Normalizer      *taxonomy.Normalizer
+	MinConfidence   float64
+	AmbiguityMargin float64
+	MinBasePay      float64
+	MinLinks        int
+}
+
+// LinkageYear holds the named records of one year prepared for linkage.
+// Redacted records are counted but cannot be linked.
+type LinkageYear struct {
+	Year      int
+	records   []linkRecord
+	byLast    map[string][]int
+	redacted  map[string]int
+	locations map[string]bool
+}
+
+// linkRecord is a named employee in one year
+type linkRecord struct {
+	first    string
+	last     string
+	location string
+	title    *models.NormalizedTitle
+	basePay  float64
+}
+
+// employeeLink is a matched pair of records in consecutive years
+type employeeLink struct {
+	previous   int
+	current    int
+	confidence float64
+}
+
+// PrepareLinkageYear collects the named records of a year from every
+// location's data
+func PrepareLinkageYear(year int, datasets []*models.WageData, opts LinkageOptions) *LinkageYear {
+	opts = linkageDefaults(opts)
+
+	titles := make(map[string]*models.NormalizedTitle)
+	linkageYear := &LinkageYear{
+		Year:      year,
+		byLast:    make(map[string][]int),
+		redacted:  make(map[string]int),
+		locations: make(map[string]bool),
+	}
+
+	for _, data := range datasets {
+		if data.Year != year {
+			continue
+		}
+		linkageYear.locations[data.Location] = true
+
+		for _, record := range data.Records {
+			base, _, _, gross := parser.ConvertRecordToFloat(record)
+			if gross <= 0 {
+				continue
+			}
+			// Names of only punctuation, such as "-", are redacted too
+			first, last := linkageName(record.FirstName), linkageName(record.LastName)
+			if recordName(record) == "" || first == "" || last == "" {
+				linkageYear.redacted[data.Location]++
+				continue
+			}
+
+			title, ok := titles[record.Title]
+			if !ok {
+				title = opts.Normalizer.Normalize(record.Title)
+				titles[record.Title] = title
+			}
+
+			linkageYear.byLast[last] = append(linkageYear.byLast[last], len(linkageYear.records))
+			linkageYear.records = append(linkageYear.records, linkRecord{
+				first:    first,
+				last:     last,
+				location: data.Location,
+				title:    title,
+				basePay:  base,
+			})
+		}
+	}
+
+	return linkageYear
+}
+
+// LinkYears matches the named records of one year with the next and
+// returns aggregate statistics for each location of the earlier year that
+// also has data in the later one, and for those locations combined.
+// Individual links are not returned.
+func LinkYears(previous, current *LinkageYear, opts LinkageOptions) []models.YearLinkage {
+	opts = linkageDefaults(opts)
+
+	links, ambiguous := matchEmployees(previous, current, opts)
+
+	byLocation := make(map[string][]employeeLink)
+	for _, link := range links {
+		location := previous.records[link.previous].location
+		byLocation[location] = append(byLocation[location], link)
+	}
+
+	named := make(map[string]int)
+	for _, record := range previous.records {
+		named[record.location]++
+	}
+
+	var result []models.YearLinkage
+	var systemLinks []employeeLink
+	systemNamed, systemRedacted, systemAmbiguous := 0, 0, 0
+	for _, location := range sortedKeys(previous.locations) {
+		if !current.locations[location] {
+			continue
+		}
+
+		linkage := yearLinkage(location, previous, current, byLocation[location], opts)
+		linkage.NamedRecords = named[location]
+		linkage.RedactedRecords = previous.redacted[location]
+		linkage.Ambiguous = ambiguous[location]
+		linkage.LinkRate = safeRatio(float64(linkage.Linked), float64(linkage.NamedRecords)) * 100
+		result = append(result, linkage)
+
+		systemLinks = append(systemLinks, byLocation[location]...)
+		systemNamed += linkage.NamedRecords
+		systemRedacted += linkage.RedactedRecords
+		systemAmbiguous += linkage.Ambiguous
+	}
+
+	system := yearLinkage(SystemLocation, previous, current, systemLinks, opts)
+	system.NamedRecords = systemNamed
+	system.RedactedRecords = systemRedacted
+	system.Ambiguous = systemAmbiguous
+	system.LinkRate = safeRatio(float64(system.Linked), float64(system.NamedRecords)) * 100
+	result = append(result, system)
+
+	return result
+}
+
+// BuildLinkageReports groups year linkages into one report per location
+func BuildLinkageReports(linkages []models.YearLinkage, opts LinkageOptions) []*models.LinkageReport {
+	opts = linkageDefaults(opts)
+
+	byLocation := make(map[string]*models.LinkageReport)
+	for _, linkage := range linkages {
+		report, exists := byLocation[linkage.Location]
+		if !exists {
+			report = &models.LinkageReport{
+				Location:        linkage.Location,
+				GeneratedAt:     time.Now(),
+				Normalization:   opts.Normalizer.Name() + "@" + opts.Normalizer.Version(),
+				MinConfidence:   opts.MinConfidence,
+				AmbiguityMargin: opts.AmbiguityMargin,
+				MinBasePay:      opts.MinBasePay,
+				MinLinks:        opts.MinLinks,
+			}
+			byLocation[linkage.Location] = report
+		}
+		report.Years = append(report.Years, linkage)
+	}
+
+	var reports []*models.LinkageReport
+	for _, report := range byLocation {
+		sort.Slice(report.Years, func(i, j int) bool {
+			return report.Years[i].PreviousYear < report.Years[j].PreviousYear
+		})
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Location < reports[j].Location
+	})
+
+	return reports
+}
+
+func linkageDefaults(opts LinkageOptions) LinkageOptions {
+	if opts.Normalizer == nil {
+		opts.Normalizer = taxonomy.DefaultNormalizer()
+	}
+	if opts.MinConfidence <= 0 || opts.MinConfidence > 1 {
+		opts.MinConfidence = 0.8
+	}
+	if opts.AmbiguityMargin <= 0 {
+		opts.AmbiguityMargin = 0.05
+	}
+	if opts.MinBasePay <= 0 {
+		opts.MinBasePay = 33280
+	}
+	if opts.MinLinks < 1 {
+		opts.MinLinks = 10
+	}
+	return opts
+}
+
+// matchEmployees links each named record of the previous year to its best
+// candidate with the same last name in the current year, best links first
+// and each record at most once. Records whose two best candidates are
+// within the ambiguity margin are left unlinked and counted by location.
+func matchEmployees(previous, current *LinkageYear, opts LinkageOptions) ([]employeeLink, map[string]int) {
+	ambiguous := make(map[string]int)
+	var candidates []employeeLink
+
+	for i, record := range previous.records {
+		best, second := employeeLink{previous: i, current: -1}, 0.0
+		for _, j := range current.byLast[record.last] {
+			confidence := linkConfidence(record, current.records[j])
+			if confidence > best.confidence {
+				second = best.confidence
+				best.current, best.confidence = j, confidence
+			} else if confidence > second {
+				second = confidence
+			}
+		}
+
+		if best.current < 0 || best.confidence < opts.MinConfidence {
+			continue
+		}
+		if second >= opts.MinConfidence && best.confidence-second < opts.AmbiguityMargin {
+			ambiguous[record.location]++
+			continue
+		}
+		candidates = append(candidates, best)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].confidence == candidates[j].confidence {
+			return candidates[i].previous < candidates[j].previous
+		}
+		return candidates[i].confidence > candidates[j].confidence
+	})
+
+	matched := make(map[int]bool)
+	var links []employeeLink
+	for _, candidate := range candidates {
+		if matched[candidate.current] {
+			continue
+		}
+		matched[candidate.current] = true
+		links = append(links, candidate)
+	}
+
+	return links, ambiguous
+}
+
+// linkConfidence scores how likely two records with the same last name are
+// the same employee, from first name, campus and title similarity
+func linkConfidence(a, b linkRecord) float64 {
+	confidence := linkNameWeight * firstNameSimilarity(a.first, b.first)
+	if a.location == b.location {
+		confidence += linkCampusWeight
+	}
+	return confidence + linkTitleWeight*titleSimilarity(a.title, b.title)
+}
+
+// firstNameSimilarity compares first names, also comparing just the first
+// word so a dropped middle name or initial ("SCOTT C" and "SCOTT") matches
+func firstNameSimilarity(a, b string) float64 {
+	score := jaroWinkler(a, b)
+	aWords, bWords := strings.Fields(a), strings.Fields(b)
+	if len(aWords) > 0 && len(bWords) > 0 && (len(aWords) > 1 || len(bWords) > 1) {
+		if word := jaroWinkler(aWords[0], bWords[0]); word > score {
+			score = word
+		}
+	}
+	return score
+}
+
+// titleSimilarity is 1 for the same normalized title, 0.8 within a family
+// and otherwise the share of title words in common
+func titleSimilarity(a, b *models.NormalizedTitle) float64 {
+	if a.Title == b.Title {
+		return 1
+	}
+	if a.Family == b.Family {
+		return 0.8
+	}
+
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(a.Title) {
+		words[word] = true
+	}
+	shared, total := 0, len(words)
+	for _, word := range strings.Fields(b.Title) {
+		if words[word] {
+			shared++
+			words[word] = false
+		} else if _, seen := words[word]; !seen {
+			words[word] = false
+			total++
+		}
+	}
+	return safeRatio(float64(shared), float64(total))
+}
+
+// promoted reports whether a linked employee moved up a rank or level
+// within the same title family
+func promoted(from, to *models.NormalizedTitle) bool {
+	if from.Family != to.Family {
+		return false
+	}
+	if from.RankOrder != to.RankOrder {
+		return to.RankOrder > from.RankOrder
+	}
+	return to.Level > from.Level
+}
+
+// yearLinkage summarizes links starting at a location
+func yearLinkage(location string, previous, current *LinkageYear, links []employeeLink, opts LinkageOptions) models.YearLinkage {
+	linkage := models.YearLinkage{
+		Location:     location,
+		PreviousYear: previous.Year,
+		Year:         current.Year,
+		Linked:       len(links),
+	}
+	if len(links) == 0 {
+		return linkage
+	}
+
+	var confidences, raises, promotedRaises, stayedRaises []float64
+	for _, link := range links {
+		from := previous.records[link.previous]
+		to := current.records[link.current]
+		confidences = append(confidences, link.confidence)
+
+		if from.location != to.location {
+			linkage.CampusMoves++
+		}
+		isPromotion := promoted(from.title, to.title)
+		if from.title.Title != to.title.Title {
+			linkage.TitleChanges++
+		}
+		if isPromotion {
+			linkage.Promotions++
+		}
+
+		if from.basePay < opts.MinBasePay || to.basePay < opts.MinBasePay {
+			continue
+		}
+		raise := percentChange(from.basePay, to.basePay)
+		raises = append(raises, raise)
+		if isPromotion {
+			promotedRaises = append(promotedRaises, raise)
+		} else if from.title.Title == to.title.Title {
+			stayedRaises = append(stayedRaises, raise)
+		}
+	}
+
+	linkage.MeanConfidence, _ = stats.Mean(confidences)
+	linkage.TitleChangeRate = float64(linkage.TitleChanges) / float64(linkage.Linked) * 100
+	linkage.PromotionRate = float64(linkage.Promotions) / float64(linkage.Linked) * 100
+	linkage.Raises = raiseDistribution(raises, opts.MinLinks)
+	linkage.PromotedRaises = raiseDistribution(promotedRaises, opts.MinLinks)
+	linkage.SameTitleRaises = raiseDistribution(stayedRaises, opts.MinLinks)
+
+	return linkage
+}
+
+// raiseDistribution summarizes raises in percent, or returns nil when
+// there are fewer than minCount of them
+func raiseDistribution(raises []float64, minCount int) *models.RaiseDistribution {
+	if len(raises) < minCount {
+		return nil
+	}
+	sort.Float64s(raises)
+
+	distribution := &models.RaiseDistribution{
+		Count:       len(raises),
+		Percentiles: make(map[string]float64),
+	}
+	distribution.Mean, _ = stats.Mean(raises)
+	distribution.Median = percentileOf(raises, 50)
+	for _, p := range raisePercentiles {
+		distribution.Percentiles[formatPercentileKey(p)] = percentileOf(raises, p)
+	}
+
+	for _, band := range raiseBands {
+		count := 0
+		for _, raise := range raises {
+			if raise >= band.min && raise < band.max {
+				count++
+			}
+		}
+		distribution.Bands = append(distribution.Bands, models.RaiseBand{
+			Label: band.label,
+			Count: count,
+			Share: float64(count) / float64(len(raises)) * 100,
+		})
+	}
+	distribution.PayCutShare = distribution.Bands[0].Share
+
+	return distribution
+}
+
+// linkageName upper-cases a name part and drops punctuation and extra
+// spaces
+func linkageName(name string) string {
+	name = strings.ToUpper(name)
+	name = strings.NewReplacer(".", " ", ",", " ", "'", "", "-", " ").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings, from 0
+// for nothing in common to 1 for identical strings
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if a == "" || b == "" {
+		return 0
+	}
+
+	window := len(a)
+	if len(b) > window {
+		window = len(b)
+	}
+	window = window/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	aMatched := make([]bool, len(a))
+	bMatched := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		low, high := i-window, i+window+1
+		if low < 0 {
+			low = 0
+		}
+		if high > len(b) {
+			high = len(b)
+		}
+		for j := low; j < high; j++ {
+			if bMatched[j] || a[i] != b[j] {
+				continue
+			}
+			aMatched[i], bMatched[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range a {
+		if !aMatched[i] {
+			continue
+		}
+		for !bMatched[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+
+	// Boost strings sharing a prefix of up to four characters
+	prefix := 0
+	for prefix < 4 && prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
@@ -0,0 +1,74 @@
+package calculator
+
+import (
+	"testing"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+func linkageData(year int, records ...models.WageRecord) *models.WageData {
+	for i := range records {
+		records[i].Title = "ANL 2"
+		records[i].BasePay = "60000"
+		records[i].GrossPay = "60000"
+	}
+	return &models.WageData{Location: "Merced", Year: year, Records: records}
+}
+
+func TestLinkYearsPunctuationNames(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current []models.WageRecord
+		named, redacted   int
+		linked            int
+	}{
+		{"punctuation first name",
+			[]models.WageRecord{{FirstName: "-", LastName: "SMITH"}},
+			[]models.WageRecord{{FirstName: "JOHN A", LastName: "SMITH"}},
+			0, 1, 0},
+		{"punctuation last name",
+			[]models.WageRecord{{FirstName: "JOHN A", LastName: "."}},
+			[]models.WageRecord{{FirstName: "JOHN", LastName: "."}},
+			0, 1, 0},
+		{"punctuation in the later year",
+			[]models.WageRecord{{FirstName: "JOHN A", LastName: "SMITH"}},
+			[]models.WageRecord{{FirstName: "-", LastName: "SMITH"}},
+			1, 0, 0},
+		{"named records still link",
+			[]models.WageRecord{{FirstName: "JOHN A", LastName: "SMITH"}, {FirstName: "-", LastName: "SMITH"}},
+			[]models.WageRecord{{FirstName: "JOHN", LastName: "SMITH"}},
+			1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := PrepareLinkageYear(2020, []*models.WageData{linkageData(2020, tt.previous...)}, LinkageOptions{})
+			current := PrepareLinkageYear(2021, []*models.WageData{linkageData(2021, tt.current...)}, LinkageOptions{})
+
+			linkages := LinkYears(previous, current, LinkageOptions{})
+			merced := linkages[0]
+			if merced.NamedRecords != tt.named || merced.RedactedRecords != tt.redacted || merced.Linked != tt.linked {
+				t.Errorf("named %d, redacted %d, linked %d; want %d, %d, %d",
+					merced.NamedRecords, merced.RedactedRecords, merced.Linked, tt.named, tt.redacted, tt.linked)
+			}
+		})
+	}
+}
+
+func TestFirstNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"SCOTT C", "SCOTT", 1},
+		{"", "JOHN A", 0},
+		{"JOHN A", "", 0},
+		{"", "", 1},
+	}
+
+	for _, tt := range tests {
+		if got := firstNameSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("firstNameSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
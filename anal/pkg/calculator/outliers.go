@@ -0,0 +1,181 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+// OutlierOptions controls FindOutliers. Records more than MADThreshold
+// median absolute deviations from their title's median are outliers; only
+// titles with at least MinTitleCount employees are tested; it defaults to 10
+// and must be at least 2. HideNames drops
+// names even when the source did not redact them.
+type OutlierOptions struct {
+	TopN          int
+	MADThreshold  float64
+	MinTitleCount int
+	MaxOutliers   int
+	HideNames     bool
+}
+
+// FindOutliers lists the top earners of a location-year, records far from
+// their title's median and titles paying more overtime than base pay
+func FindOutliers(data *models.WageData, opts OutlierOptions) (*models.OutlierReport, error) {
+	if opts.MADThreshold <= 0 {
+		opts.MADThreshold = 5
+	}
+	switch {
+	case opts.MinTitleCount == 0:
+		opts.MinTitleCount = 10
+	case opts.MinTitleCount < 2:
+		return nil, fmt.Errorf("outlier titles need a minimum of at least 2 employees, got %d", opts.MinTitleCount)
+	}
+
+	var earners []models.EarnerRecord
+	byTitle := make(map[string][]int)
+	overtime := make(map[string]*models.OvertimeTitle)
+
+	for _, record := range data.Records {
+		base, overtimePay, adjust, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+
+		earner := models.EarnerRecord{
+			ID:          record.ID,
+			Title:       record.Title,
+			BasePay:     base,
+			OvertimePay: overtimePay,
+			AdjustPay:   adjust,
+			GrossPay:    gross,
+		}
+		if !opts.HideNames {
+			earner.Name = recordName(record)
+		}
+		earners = append(earners, earner)
+
+		if !isKnownTitle(record.Title) {
+			continue
+		}
+		byTitle[record.Title] = append(byTitle[record.Title], len(earners)-1)
+
+		title, exists := overtime[record.Title]
+		if !exists {
+			title = &models.OvertimeTitle{Title: record.Title}
+			overtime[record.Title] = title
+		}
+		title.Count++
+		title.TotalBase += base
+		title.TotalOvertime += overtimePay
+		if overtimePay > base {
+			title.RecordsOverBase++
+		}
+	}
+
+	if len(earners) == 0 {
+		return nil, nil
+	}
+
+	report := &models.OutlierReport{
+		Location:       data.Location,
+		Year:           data.Year,
+		GeneratedAt:    time.Now(),
+		MADThreshold:   opts.MADThreshold,
+		MinTitleCount:  opts.MinTitleCount,
+		NamesHidden:    opts.HideNames,
+		Outliers:       []models.PayOutlier{},
+		OvertimeTitles: []models.OvertimeTitle{},
+	}
+
+	// Records far from their title's median
+	for _, indexes := range byTitle {
+		if len(indexes) < opts.MinTitleCount {
+			continue
+		}
+
+		wages := make([]float64, len(indexes))
+		for i, index := range indexes {
+			wages[i] = earners[index].GrossPay
+		}
+		median, _ := stats.Median(wages)
+		mad, _ := stats.MedianAbsoluteDeviation(wages)
+		if mad == 0 {
+			continue
+		}
+
+		for _, index := range indexes {
+			deviation := (earners[index].GrossPay - median) / mad
+			if math.Abs(deviation) <= opts.MADThreshold {
+				continue
+			}
+			report.Outliers = append(report.Outliers, models.PayOutlier{
+				EarnerRecord: earners[index],
+				TitleCount:   len(indexes),
+				TitleMedian:  median,
+				TitleMAD:     mad,
+				Deviation:    deviation,
+			})
+		}
+	}
+
+	sort.Slice(report.Outliers, func(i, j int) bool {
+		a, b := math.Abs(report.Outliers[i].Deviation), math.Abs(report.Outliers[j].Deviation)
+		if a == b {
+			return report.Outliers[i].ID < report.Outliers[j].ID
+		}
+		return a > b
+	})
+	report.OutlierCount = len(report.Outliers)
+	if opts.MaxOutliers > 0 && len(report.Outliers) > opts.MaxOutliers {
+		report.Outliers = report.Outliers[:opts.MaxOutliers]
+	}
+
+	// Titles paying more overtime than base
+	for _, title := range overtime {
+		if title.TotalOvertime <= title.TotalBase {
+			continue
+		}
+		title.OvertimeRatio = safeRatio(title.TotalOvertime, title.TotalBase)
+		report.OvertimeTitles = append(report.OvertimeTitles, *title)
+	}
+	sort.Slice(report.OvertimeTitles, func(i, j int) bool {
+		a, b := report.OvertimeTitles[i], report.OvertimeTitles[j]
+		if a.TotalOvertime == b.TotalOvertime {
+			return a.Title < b.Title
+		}
+		return a.TotalOvertime > b.TotalOvertime
+	})
+
+	// Top earners last, since sorting reorders the records
+	sort.Slice(earners, func(i, j int) bool {
+		if earners[i].GrossPay == earners[j].GrossPay {
+			return earners[i].ID < earners[j].ID
+		}
+		return earners[i].GrossPay > earners[j].GrossPay
+	})
+	if opts.TopN > 0 && len(earners) > opts.TopN {
+		earners = earners[:opts.TopN]
+	}
+	report.TopEarners = earners
+
+	return report, nil
+}
+
+// recordName joins a record's first and last name, returning an empty
+// string when either part is redacted or missing. Redacted names are runs
+// of asterisks, whose length varies by year.
+func recordName(record models.WageRecord) string {
+	first := strings.TrimSpace(record.FirstName)
+	last := strings.TrimSpace(record.LastName)
+	if strings.Trim(first, "*") == "" || strings.Trim(last, "*") == "" {
+		return ""
+	}
+	return first + " " + last
+}
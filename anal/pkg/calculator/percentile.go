@@ -0,0 +1,411 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// Lookup sources
+const (
+	SourceRecords = "records"
+	SourceSummary = "summary"
+)
+
+// PercentileQuery asks where Pay ranks. An empty Location ranks against
+// every location and a zero Year uses the latest year with data. Category
+// matches a top-level category or a full category path. Title matches raw
+// titles and titles that normalize to the same canonical title. Category
+// and title ranks and comparable titles need at least MinCount employees,
+// so a lookup cannot reveal the pay of one or two people.
+type PercentileQuery struct {
+	Pay        float64
+	Location   string
+	Year       int
+	Title      string
+	Category   string
+	Scheme     models.BracketScheme
+	Comparable int
+	MinCount   int
+}
+
+// PayIndex holds gross pay for every record with its location, year, title
+// and category so percentile lookups can filter without reloading files.
+// Add is not safe for concurrent use; Lookup is once loading is done.
+type PayIndex struct {
+	categories *categoryCache
+	normalizer *taxonomy.Normalizer
+	titleIDs   map[string]int
+	titles     []indexedTitle
+	entries    []payEntry
+}
+
+type indexedTitle struct {
+	raw        string
+	normalized string
+	category   string
+}
+
+type payEntry struct {
+	location string
+	year     int
+	title    int
+	gross    float64
+}
+
+// NewPayIndex returns an empty index. Nil taxonomy and normalizer select
+// the bundled defaults.
+func NewPayIndex(tax *taxonomy.Taxonomy, normalizer *taxonomy.Normalizer) *PayIndex {
+	if normalizer == nil {
+		normalizer = taxonomy.DefaultNormalizer()
+	}
+	return &PayIndex{
+		categories: newCategoryCache(tax),
+		normalizer: normalizer,
+		titleIDs:   make(map[string]int),
+	}
+}
+
+// Add indexes every paid record of a location-year
+func (idx *PayIndex) Add(data *models.WageData) {
+	for _, record := range data.Records {
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+
+		idx.entries = append(idx.entries, payEntry{
+			location: data.Location,
+			year:     data.Year,
+			title:    idx.titleID(record.Title),
+			gross:    gross,
+		})
+	}
+}
+
+// Len returns the number of indexed records
+func (idx *PayIndex) Len() int {
+	return len(idx.entries)
+}
+
+// titleID interns a raw title with its normalized title and category path
+func (idx *PayIndex) titleID(title string) int {
+	if id, ok := idx.titleIDs[title]; ok {
+		return id
+	}
+
+	indexed := indexedTitle{
+		raw:      title,
+		category: strings.Join(idx.categories.classify(title), taxonomy.Separator),
+	}
+	if isKnownTitle(title) {
+		indexed.normalized = idx.normalizer.Normalize(title).Title
+	}
+
+	id := len(idx.titles)
+	idx.titles = append(idx.titles, indexed)
+	idx.titleIDs[title] = id
+	return id
+}
+
+// Lookup ranks a pay amount against the indexed records matching the query
+func (idx *PayIndex) Lookup(query PercentileQuery) (*models.PercentileLookup, error) {
+	query = percentileQueryDefaults(query)
+	if query.Pay <= 0 {
+		return nil, fmt.Errorf("pay must be positive")
+	}
+
+	if query.Year == 0 {
+		for _, entry := range idx.entries {
+			if (query.Location == "" || entry.location == query.Location) && entry.year > query.Year {
+				query.Year = entry.year
+			}
+		}
+		if query.Year == 0 {
+			return nil, fmt.Errorf("no records for location %q", query.Location)
+		}
+	}
+
+	// Match the title against raw and normalized titles
+	titleKey := ""
+	if query.Title != "" {
+		titleKey = idx.normalizer.Normalize(query.Title).Title
+	}
+
+	var population []float64
+	var titleWages []float64
+	titleMatches := make(map[string]bool)
+	byTitle := make(map[string][]float64)
+
+	for _, entry := range idx.entries {
+		if entry.year != query.Year || (query.Location != "" && entry.location != query.Location) {
+			continue
+		}
+
+		title := idx.titles[entry.title]
+		if query.Category != "" && !matchesCategory(title.category, query.Category) {
+			continue
+		}
+
+		population = append(population, entry.gross)
+		if title.normalized != "" {
+			byTitle[title.normalized] = append(byTitle[title.normalized], entry.gross)
+		}
+
+		if query.Title != "" && (strings.EqualFold(title.raw, query.Title) || (title.normalized != "" && title.normalized == titleKey)) {
+			titleWages = append(titleWages, entry.gross)
+			titleMatches[title.raw] = true
+		}
+	}
+
+	if len(population) == 0 {
+		return nil, fmt.Errorf("no records for %s", describeQuery(query))
+	}
+	if query.Category != "" && len(population) < query.MinCount {
+		return nil, fmt.Errorf("fewer than %d employees for %s", query.MinCount, describeQuery(query))
+	}
+
+	sort.Float64s(population)
+
+	lookup := &models.PercentileLookup{
+		Pay:      query.Pay,
+		Location: query.Location,
+		Year:     query.Year,
+		Category: query.Category,
+		Title:    query.Title,
+		Source:   SourceRecords,
+		MinCount: query.MinCount,
+		Overall:  percentileRank(population, query.Pay),
+	}
+	if lookup.Location == "" {
+		lookup.Location = SystemLocation
+	}
+
+	if query.Title != "" {
+		if len(titleWages) == 0 {
+			return nil, fmt.Errorf("no records with title %q for %s", query.Title, describeQuery(query))
+		}
+		if len(titleWages) < query.MinCount {
+			return nil, fmt.Errorf("fewer than %d employees with title %q for %s", query.MinCount, query.Title, describeQuery(query))
+		}
+		sort.Float64s(titleWages)
+		rank := percentileRank(titleWages, query.Pay)
+		lookup.WithinTitle = &rank
+		lookup.MatchedTitles = sortedKeys(titleMatches)
+	}
+
+	bracket, err := payBracket(query.Scheme, population, query.Pay)
+	if err != nil {
+		return nil, err
+	}
+	lookup.Bracket = bracket
+
+	lookup.ComparableTitles = comparableTitles(byTitle, query.Pay, query.MinCount, query.Comparable)
+
+	return lookup, nil
+}
+
+// EstimatePercentile ranks a pay amount using a summary and, when given, a
+// pyramid for the bracket. The summary's sketch is used when present;
+// otherwise the rank is interpolated between its reported percentiles.
+func EstimatePercentile(summary *models.Summary, pyramid *models.Pyramid, pay float64) (*models.PercentileLookup, error) {
+	if pay <= 0 {
+		return nil, fmt.Errorf("pay must be positive")
+	}
+	if summary == nil || summary.EmployeeCount == 0 {
+		return nil, fmt.Errorf("summary has no employees")
+	}
+
+	var fraction float64
+	if summary.Sketch != nil {
+		fraction = summary.Sketch.CDF(pay)
+	} else {
+		fraction = interpolateRank(summary, pay)
+	}
+
+	lookup := &models.PercentileLookup{
+		Pay:         pay,
+		Location:    summary.Location,
+		Year:        summary.Year,
+		Source:      SourceSummary,
+		Approximate: true,
+		Overall: models.PercentileRank{
+			Population: summary.EmployeeCount,
+			Below:      int(math.Round(fraction * float64(summary.EmployeeCount))),
+			Percentile: fraction * 100,
+			MedianPay:  summary.MedianPay,
+		},
+	}
+
+	if pyramid != nil {
+		for _, bracket := range pyramid.Brackets {
+			definition := BracketDefinition{MinValue: bracket.MinValue, MaxValue: bracket.MaxValue, OpenEnded: bracket.OpenEnded}
+			if !definition.Contains(pay) {
+				continue
+			}
+			lookup.Bracket = &models.PayBracket{
+				Scheme:     DefaultBracketScheme,
+				Range:      bracket.Range,
+				MinValue:   bracket.MinValue,
+				MaxValue:   bracket.MaxValue,
+				OpenEnded:  bracket.OpenEnded,
+				Count:      bracket.Count,
+				Percentage: bracket.Percentage,
+			}
+			if pyramid.Scheme != nil {
+				lookup.Bracket.Scheme = pyramid.Scheme.Name
+			}
+			break
+		}
+	}
+
+	return lookup, nil
+}
+
+func percentileQueryDefaults(query PercentileQuery) PercentileQuery {
+	if query.Scheme.Name == "" {
+		query.Scheme = GetBracketSchemes()[DefaultBracketScheme]
+	}
+	if query.Comparable == 0 {
+		query.Comparable = 5
+	}
+	if query.MinCount == 0 {
+		query.MinCount = 5
+	}
+	return query
+}
+
+// percentileRank ranks a pay amount within sorted wages
+func percentileRank(sorted []float64, pay float64) models.PercentileRank {
+	below := sort.SearchFloat64s(sorted, pay)
+	equal := sort.Search(len(sorted), func(i int) bool { return sorted[i] > pay }) - below
+	median, _ := stats.Median(sorted)
+
+	return models.PercentileRank{
+		Population: len(sorted),
+		Below:      below,
+		Equal:      equal,
+		Percentile: (float64(below) + float64(equal)/2) / float64(len(sorted)) * 100,
+		MedianPay:  median,
+	}
+}
+
+// payBracket finds the bracket of a scheme containing a pay amount and
+// counts the population in it
+func payBracket(scheme models.BracketScheme, sorted []float64, pay float64) (*models.PayBracket, error) {
+	brackets, _, err := ResolveBrackets(scheme, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bracket := range brackets {
+		if !bracket.Contains(pay) {
+			continue
+		}
+
+		count := 0
+		for _, wage := range sorted {
+			if bracket.Contains(wage) {
+				count++
+			}
+		}
+
+		return &models.PayBracket{
+			Scheme:     scheme.Name,
+			Range:      bracket.Range,
+			MinValue:   bracket.MinValue,
+			MaxValue:   bracket.MaxValue,
+			OpenEnded:  bracket.OpenEnded,
+			Count:      count,
+			Percentage: float64(count) / float64(len(sorted)) * 100,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// comparableTitles returns the titles whose median is closest to a pay amount
+func comparableTitles(byTitle map[string][]float64, pay float64, minCount, limit int) []models.ComparableTitle {
+	var titles []models.ComparableTitle
+	for title, wages := range byTitle {
+		if len(wages) < minCount {
+			continue
+		}
+
+		sort.Float64s(wages)
+		rank := percentileRank(wages, pay)
+		titles = append(titles, models.ComparableTitle{
+			Title:      title,
+			Count:      len(wages),
+			MedianPay:  rank.MedianPay,
+			Difference: rank.MedianPay - pay,
+			Percentile: rank.Percentile,
+		})
+	}
+
+	sort.Slice(titles, func(i, j int) bool {
+		a, b := math.Abs(titles[i].Difference), math.Abs(titles[j].Difference)
+		if a == b {
+			return titles[i].Title < titles[j].Title
+		}
+		return a < b
+	})
+
+	if len(titles) > limit {
+		titles = titles[:limit]
+	}
+	return titles
+}
+
+// interpolateRank estimates the fraction of employees paid at most pay
+// from a summary's minimum, percentiles and maximum
+func interpolateRank(summary *models.Summary, pay float64) float64 {
+	type point struct{ pay, fraction float64 }
+	points := []point{{summary.MinPay, 0}}
+	for _, p := range PercentileValues {
+		if value, ok := summary.Percentiles[formatPercentileKey(p)]; ok {
+			points = append(points, point{value, p / 100})
+		}
+	}
+	points = append(points, point{summary.MaxPay, 1})
+
+	if pay < points[0].pay {
+		return 0
+	}
+	for i := 1; i < len(points); i++ {
+		if pay >= points[i].pay {
+			continue
+		}
+		lo, hi := points[i-1], points[i]
+		if hi.pay == lo.pay {
+			return lo.fraction
+		}
+		return lo.fraction + (pay-lo.pay)/(hi.pay-lo.pay)*(hi.fraction-lo.fraction)
+	}
+	return 1
+}
+
+// matchesCategory reports whether a category path falls under a category
+func matchesCategory(path, category string) bool {
+	category = strings.Join(taxonomy.SplitCategory(category), taxonomy.Separator)
+	return strings.EqualFold(path, category) ||
+		strings.HasPrefix(strings.ToUpper(path), strings.ToUpper(category+taxonomy.Separator))
+}
+
+func describeQuery(query PercentileQuery) string {
+	location := query.Location
+	if location == "" {
+		location = SystemLocation
+	}
+	description := fmt.Sprintf("%s %d", location, query.Year)
+	if query.Category != "" {
+		description += " in " + query.Category
+	}
+	return description
+}
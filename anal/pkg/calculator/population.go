@@ -0,0 +1,248 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// FTE bases for PopulationFilter
+const (
+	FTEBasisTitle = "title"
+	FTEBasisFixed = "fixed"
+)
+
+// Reasons a record is excluded by a population filter
+const (
+	ExcludedGrossPay = "min_gross_pay"
+	ExcludedBasePay  = "min_base_pay"
+	ExcludedCategory = "category"
+	ExcludedFTE      = "fte"
+)
+
+// GetPopulationFilters returns the built-in population filters. "all" keeps
+// every paid record, which is what analyses use when no filter is given.
+func GetPopulationFilters() map[string]models.PopulationFilter {
+	return map[string]models.PopulationFilter{
+		"all": {
+			Name:        "all",
+			Description: "Every record with positive gross pay",
+		},
+		"no-students": {
+			Name:              "no-students",
+			Description:       "Excludes titles the taxonomy classifies as Student",
+			ExcludeCategories: []string{"Student"},
+		},
+		"salaried": {
+			Name:        "salaried",
+			Description: "Excludes records without base pay, such as stipend-only and adjustment-only payments",
+			MinBasePay:  1,
+		},
+		"full-time": {
+			Name:              "full-time",
+			Description:       "Excludes students and records with under half their title's median base pay, or of a $16/hour full-time salary for titles with fewer than 10 employees",
+			MinBasePay:        1,
+			ExcludeCategories: []string{"Student"},
+			MinFTE:            0.5,
+			FTEBasis:          FTEBasisTitle,
+			FTEPercentile:     50,
+			FTEMinTitleCount:  10,
+			FullTimeBasePay:   33280,
+		},
+	}
+}
+
+// FilterPopulation returns a copy of data holding only the records the
+// filter keeps, along with the filter definition and counts to record in
+// output. Categories are looked up in the given taxonomy (the bundled one
+// when nil).
+func FilterPopulation(data *models.WageData, filter models.PopulationFilter, tax *taxonomy.Taxonomy) (*models.WageData, *models.PopulationFilter, error) {
+	filter, err := populationFilterDefaults(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tax == nil {
+		tax = taxonomy.Default()
+	}
+	if len(filter.ExcludeCategories) > 0 {
+		filter.Taxonomy = tax.Name() + "@" + tax.Version()
+	}
+
+	categorizer := newCategoryCache(tax)
+	reference := fteReferences(data, filter)
+	filter.ExcludedBy = make(map[string]int)
+
+	filtered := &models.WageData{
+		Location:  data.Location,
+		Year:      data.Year,
+		ScrapedAt: data.ScrapedAt,
+	}
+
+	for _, record := range data.Records {
+		base, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+		filter.Records++
+
+		reason := excludedBy(filter, categorizer, reference, record.Title, base, gross)
+		if reason != "" {
+			filter.ExcludedBy[reason]++
+			filter.Excluded++
+			continue
+		}
+
+		filtered.Records = append(filtered.Records, record)
+		filter.Kept++
+	}
+	filtered.TotalRecords = len(filtered.Records)
+
+	return filtered, &filter, nil
+}
+
+func populationFilterDefaults(filter models.PopulationFilter) (models.PopulationFilter, error) {
+	if filter.Name == "" {
+		filter.Name = "custom"
+	}
+	if filter.MinFTE <= 0 {
+		return filter, nil
+	}
+
+	if filter.FTEBasis == "" {
+		filter.FTEBasis = FTEBasisTitle
+	}
+	switch filter.FTEBasis {
+	case FTEBasisTitle:
+		if filter.FTEPercentile <= 0 || filter.FTEPercentile > 100 {
+			filter.FTEPercentile = 50
+		}
+		if filter.FTEMinTitleCount < 1 {
+			filter.FTEMinTitleCount = 10
+		}
+	case FTEBasisFixed:
+		if filter.FullTimeBasePay <= 0 {
+			return filter, fmt.Errorf("population filter %q: fixed FTE basis needs full_time_base_pay", filter.Name)
+		}
+	default:
+		return filter, fmt.Errorf("population filter %q: unknown FTE basis %q", filter.Name, filter.FTEBasis)
+	}
+
+	return filter, nil
+}
+
+// fteReferences returns the full-time base pay of each title large enough
+// to estimate one, or nil when the filter does not use a title basis
+func fteReferences(data *models.WageData, filter models.PopulationFilter) map[string]float64 {
+	if filter.MinFTE <= 0 || filter.FTEBasis != FTEBasisTitle {
+		return nil
+	}
+
+	basePays := make(map[string][]float64)
+	for _, record := range data.Records {
+		if !isKnownTitle(record.Title) {
+			continue
+		}
+		base, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 || base <= 0 {
+			continue
+		}
+		basePays[record.Title] = append(basePays[record.Title], base)
+	}
+
+	references := make(map[string]float64)
+	for title, pays := range basePays {
+		if len(pays) < filter.FTEMinTitleCount {
+			continue
+		}
+		sort.Float64s(pays)
+		references[title] = percentileOf(pays, filter.FTEPercentile)
+	}
+	return references
+}
+
+// excludedBy returns the first criterion a record fails, or an empty
+// string when the filter keeps it
+func excludedBy(filter models.PopulationFilter, categorizer *categoryCache, references map[string]float64, title string, base, gross float64) string {
+	if gross < filter.MinGrossPay {
+		return ExcludedGrossPay
+	}
+	if base < filter.MinBasePay {
+		return ExcludedBasePay
+	}
+
+	if len(filter.ExcludeCategories) > 0 {
+		path := strings.Join(categorizer.classify(title), taxonomy.Separator)
+		for _, category := range filter.ExcludeCategories {
+			if matchesCategory(path, category) {
+				return ExcludedCategory
+			}
+		}
+	}
+
+	if filter.MinFTE > 0 {
+		fullTime, ok := references[title]
+		if !ok {
+			fullTime = filter.FullTimeBasePay
+		}
+		if fullTime > 0 && base/fullTime < filter.MinFTE {
+			return ExcludedFTE
+		}
+	}
+
+	return ""
+}
+
+// MergePopulations combines the filters recorded for locations merged into
+// one aggregate, summing their counts. All must use the same filter.
+func MergePopulations(populations []*models.PopulationFilter) (*models.PopulationFilter, error) {
+	if len(populations) == 0 {
+		return nil, nil
+	}
+
+	merged := copyPopulation(populations[0])
+	for _, population := range populations[1:] {
+		if err := addPopulation(merged, population); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// copyPopulation copies a recorded filter so merged counts can be added
+func copyPopulation(population *models.PopulationFilter) *models.PopulationFilter {
+	if population == nil {
+		return nil
+	}
+	copied := *population
+	copied.ExcludedBy = make(map[string]int)
+	for reason, count := range population.ExcludedBy {
+		copied.ExcludedBy[reason] = count
+	}
+	return &copied
+}
+
+// addPopulation adds the counts of a filter recorded on an output being
+// merged, returning an error when the outputs used different filters
+func addPopulation(merged, population *models.PopulationFilter) error {
+	if merged == nil || population == nil {
+		if merged != population {
+			return fmt.Errorf("cannot merge filtered and unfiltered outputs")
+		}
+		return nil
+	}
+	if merged.Name != population.Name {
+		return fmt.Errorf("cannot merge outputs filtered by %q and %q", merged.Name, population.Name)
+	}
+
+	merged.Records += population.Records
+	merged.Kept += population.Kept
+	merged.Excluded += population.Excluded
+	for reason, count := range population.ExcludedBy {
+		merged.ExcludedBy[reason] += count
+	}
+	return nil
+}
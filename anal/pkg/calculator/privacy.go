@@ -0,0 +1,695 @@
+package calculator
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// Noise mechanisms
+const (
+	MechanismLaplace     = "laplace"
+	MechanismExponential = "exponential"
+)
+
+// PrivacyUnit is what a private release protects: one payroll record, an
+// employee's pay at one location in one year. Location-year files hold
+// different records, so releasing every file of a kind spends the
+// output's epsilon once.
+const PrivacyUnit = "record"
+
+// PrivacyOptions controls the private release of a summary, pyramid or
+// title analysis. Epsilon is required; Delta (default 1e-6) is only spent
+// on choosing which titles to list. Pay is clipped to PayBound (default
+// 500000) before summing, which bounds how much one record can move a
+// total. Titles are clipped to the smaller of PayBound and the bound for
+// their top-level category in Taxonomy (the bundled one when nil), from
+// CategoryBounds (DefaultPrivacyCategoryBounds when nil). Budget is the
+// run's total epsilon, recorded with the output.
+type PrivacyOptions struct {
+	Epsilon        float64
+	Delta          float64
+	PayBound       float64
+	CategoryBounds map[string]float64
+	Taxonomy       *taxonomy.Taxonomy
+	Budget         float64
+}
+
+// Shares of an output's epsilon by statistic. Percentiles and medians need
+// the most budget to land near the data, and listing titles needs enough
+// for ordinary titles to clear the threshold; counts and sums stay
+// accurate on much less.
+var (
+	summaryPrivacyShares = map[string]float64{
+		"employee_count":  0.1,
+		"total_gross_pay": 0.1,
+		"pay_components":  0.2,
+		"percentiles":     0.6,
+	}
+	pyramidPrivacyShares = map[string]float64{
+		"bracket_counts":     0.2,
+		"bracket_total_pay":  0.2,
+		"bracket_medians":    0.3,
+		"bracket_top_titles": 0.3,
+	}
+	titlePrivacyShares = map[string]float64{
+		"unique_titles":   0.05,
+		"title_counts":    0.35,
+		"title_total_pay": 0.2,
+		"title_medians":   0.4,
+	}
+)
+
+// DefaultPrivacyCategoryBounds returns the pay bounds of private title
+// releases by top-level category of the bundled taxonomy. They are fixed
+// in advance rather than read from the data, and sit above nearly all pay
+// in each category, so titles such as student jobs are not clipped to
+// executive pay; other categories use PayBound.
+func DefaultPrivacyCategoryBounds() map[string]float64 {
+	return map[string]float64{
+		"Student":                60000,
+		"Facilities":             250000,
+		"IT/Technical":           300000,
+		"Athletics & Recreation": 300000,
+	}
+}
+
+// PrivacyRunShares returns how a run splits its epsilon between the
+// commands releasing noisy outputs, by the statistics each releases.
+// Title analyses release the most per record, then pyramids; summaries
+// release one set of statistics per location-year, and system aggregates
+// release a summary, pyramid and titles for every group.
+func PrivacyRunShares() map[string]float64 {
+	return map[string]float64{
+		"calculate_sums":   0.15,
+		"generate_pyramid": 0.25,
+		"analyze_titles":   0.35,
+		"aggregate_system": 0.25,
+	}
+}
+
+// privacyLedgerTimeout is how long a command waits for another to finish
+// charging the ledger
+const privacyLedgerTimeout = 30 * time.Second
+
+// DefaultPrivacyBudgetDelta is the total delta of a new budget ledger
+const DefaultPrivacyBudgetDelta = 1e-5
+
+// NewPrivacyBudget starts a run's budget ledger with the given total
+// epsilon and DefaultPrivacyBudgetDelta
+func NewPrivacyBudget(epsilon float64) *models.PrivacyBudget {
+	return &models.PrivacyBudget{
+		Epsilon: epsilon,
+		Delta:   DefaultPrivacyBudgetDelta,
+		Charges: []models.PrivacyCharge{},
+	}
+}
+
+// ChargePrivacyBudget records a command's spend against the run's budget.
+// A charge that would take the spend past the budget fails without being
+// recorded, so nothing should be released.
+func ChargePrivacyBudget(budget *models.PrivacyBudget, command string, epsilon, delta float64) error {
+	if epsilon <= 0 {
+		return fmt.Errorf("privacy epsilon must be positive")
+	}
+	if delta < 0 {
+		return fmt.Errorf("privacy delta must not be negative")
+	}
+
+	// Allow for rounding when a budget is split evenly
+	const slack = 1e-9
+	if budget.Spent+epsilon > budget.Epsilon+slack {
+		return fmt.Errorf("privacy budget exhausted: %s needs epsilon %g but %g of %g remains",
+			command, epsilon, budget.Epsilon-budget.Spent, budget.Epsilon)
+	}
+	if budget.SpentDelta+delta > budget.Delta+slack {
+		return fmt.Errorf("privacy budget exhausted: %s needs delta %g but %g of %g remains",
+			command, delta, budget.Delta-budget.SpentDelta, budget.Delta)
+	}
+
+	budget.Spent += epsilon
+	budget.SpentDelta += delta
+	budget.Charges = append(budget.Charges, models.PrivacyCharge{
+		Command:   command,
+		Epsilon:   epsilon,
+		Delta:     delta,
+		ChargedAt: time.Now(),
+	})
+	return nil
+}
+
+// ChargePrivacyLedger charges a command's spend against the budget ledger
+// file shared by a run, starting a new ledger with the given total epsilon
+// when there is none. The ledger stays locked while it is read, charged
+// and replaced, so commands run at the same time cannot overwrite each
+// other's charges.
+func ChargePrivacyLedger(ledger string, total float64, command string, epsilon, delta float64) (*models.PrivacyBudget, error) {
+	unlock, err := parser.LockFile(ledger, privacyLedgerTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	budget := NewPrivacyBudget(total)
+	if _, err := os.Stat(ledger); err == nil {
+		budget, err = parser.LoadPrivacyBudget(ledger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ChargePrivacyBudget(budget, command, epsilon, delta); err != nil {
+		return nil, err
+	}
+	if err := parser.ReplaceJSON(ledger, budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// ApplyPrivacyToSummary replaces a summary's counts, sums and percentiles
+// with noisy values computed from the same records, splitting epsilon
+// between the headcount, total pay, pay components and percentiles by
+// summaryPrivacyShares. Statistics that are not released (standard deviation,
+// extremes, inequality and shape measures, the sketch and bootstrap
+// intervals) are cleared. Apply it before any inflation adjustment.
+func ApplyPrivacyToSummary(summary *models.Summary, data *models.WageData, opts PrivacyOptions) error {
+	opts, noise, err := privacySetup(opts)
+	if err != nil {
+		return err
+	}
+	bound := opts.PayBound
+	share := privacyShares(opts.Epsilon, summaryPrivacyShares)
+	release := newPrivacyRelease(opts, 0)
+
+	var grossPays []float64
+	var totalGross, totalBase, totalOvertime, totalAdjust float64
+	for _, record := range data.Records {
+		base, overtime, adjust, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+		grossPays = append(grossPays, gross)
+		totalGross += clip(gross, 0, bound)
+		totalBase += clip(base, -bound, bound)
+		totalOvertime += clip(overtime, -bound, bound)
+		totalAdjust += clip(adjust, -bound, bound)
+	}
+	sort.Float64s(grossPays)
+
+	summary.EmployeeCount = noise.count(len(grossPays), share["employee_count"])
+	addLaplaceQuery(release, "employee_count", share["employee_count"], 1)
+
+	summary.TotalGrossPay = math.Max(0, noise.sum(totalGross, bound, share["total_gross_pay"]))
+	summary.AvgGrossPay = safeRatio(summary.TotalGrossPay, float64(summary.EmployeeCount))
+	addLaplaceQuery(release, "total_gross_pay", share["total_gross_pay"], bound)
+
+	componentShare := share["pay_components"] / 3
+	components := models.PayComponents{
+		TotalBase:        noise.sum(totalBase, bound, componentShare),
+		TotalOvertime:    noise.sum(totalOvertime, bound, componentShare),
+		TotalAdjustments: noise.sum(totalAdjust, bound, componentShare),
+	}
+	components.AvgBase = safeRatio(components.TotalBase, float64(summary.EmployeeCount))
+	components.AvgOvertime = safeRatio(components.TotalOvertime, float64(summary.EmployeeCount))
+	components.AvgAdjustments = safeRatio(components.TotalAdjustments, float64(summary.EmployeeCount))
+	summary.PayComponents = components
+	for _, statistic := range []string{"total_base", "total_overtime", "total_adjustments"} {
+		addLaplaceQuery(release, statistic, componentShare, bound)
+	}
+
+	// Sorting the noisy percentiles keeps them in order without spending
+	// more budget
+	percentileShare := share["percentiles"] / float64(len(PercentileValues))
+	values := make([]float64, len(PercentileValues))
+	for i, p := range PercentileValues {
+		values[i] = noise.quantile(grossPays, p, 0, bound, percentileShare)
+		addQuery(release, formatPercentileKey(p), MechanismExponential, percentileShare, 1, 0)
+	}
+	sort.Float64s(values)
+	summary.Percentiles = make(map[string]float64)
+	for i, p := range PercentileValues {
+		summary.Percentiles[formatPercentileKey(p)] = values[i]
+	}
+	summary.MedianPay = summary.Percentiles[formatPercentileKey(50)]
+
+	summary.StdDev, summary.MinPay, summary.MaxPay = 0, 0, 0
+	summary.Gini, summary.Skewness, summary.Kurtosis = 0, 0, 0
+	summary.Sketch = nil
+	summary.Approximate = false
+	summary.Bootstrap = nil
+	summary.Population = populationDefinition(summary.Population)
+	summary.Privacy = release
+	return nil
+}
+
+// ApplyPrivacyToPyramid rebuilds a pyramid's brackets from the records with
+// noisy headcounts, total pay, medians and top titles, splitting epsilon
+// by pyramidPrivacyShares. Every bracket of the scheme is listed, even
+// when empty, and only titles whose noisy count clears the delta threshold
+// are named. A median whose noise scale exceeds it is withheld (left
+// zero). Totals in a bracket are clipped to its upper edge (to PayBound,
+// or the lower edge when higher, for the top bracket). Pay components,
+// recipient shares and categories are not released. Quantile schemes
+// depend on the data and are refused. Apply it before any inflation
+// adjustment or suppression.
+func ApplyPrivacyToPyramid(pyramid *models.Pyramid, data *models.WageData, opts PrivacyOptions) error {
+	if pyramid.Scheme == nil || pyramid.Scheme.Type == "quantile" {
+		return fmt.Errorf("quantile bracket schemes take their edges from the data and cannot be released privately")
+	}
+	opts, noise, err := privacySetup(opts)
+	if err != nil {
+		return err
+	}
+	bound := opts.PayBound
+	share := privacyShares(opts.Epsilon, pyramidPrivacyShares)
+	release := newPrivacyRelease(opts, opts.Delta)
+
+	definitions, _, err := ResolveBrackets(*pyramid.Scheme, nil)
+	if err != nil {
+		return err
+	}
+
+	wages := make([][]float64, len(definitions))
+	titles := make([]map[string][]float64, len(definitions))
+	for i := range definitions {
+		titles[i] = make(map[string][]float64)
+	}
+	var unbracketed []float64
+
+	for _, record := range data.Records {
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+
+		found := false
+		for i, bracket := range definitions {
+			if bracket.Contains(gross) {
+				wages[i] = append(wages[i], gross)
+				if isKnownTitle(record.Title) {
+					titles[i][record.Title] = append(titles[i][record.Title], gross)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			unbracketed = append(unbracketed, gross)
+		}
+	}
+
+	pyramid.Brackets = []models.WageBracket{}
+	pyramid.TotalEmployees, pyramid.TotalPay = 0, 0
+	largestCap := bound
+	withheldMedians := 0
+	for i, bracket := range definitions {
+		bracketCap := math.Max(bound, bracket.MinValue)
+		if !bracket.OpenEnded {
+			bracketCap = math.Min(bracketCap, bracket.MaxValue)
+		}
+		largestCap = math.Max(largestCap, bracketCap)
+
+		sort.Float64s(wages[i])
+		wageBracket := models.WageBracket{
+			Range:     bracket.Range,
+			MinValue:  bracket.MinValue,
+			MaxValue:  bracket.MaxValue,
+			OpenEnded: bracket.OpenEnded,
+			Count:     noise.count(len(wages[i]), share["bracket_counts"]),
+			TopTitles: noise.selectTitles(titles[i], share["bracket_top_titles"], opts.Delta, 10),
+		}
+		wageBracket.TotalPay = math.Max(0, noise.sum(clippedSum(wages[i], bracketCap), bracketCap, share["bracket_total_pay"]))
+		median := noise.quantile(wages[i], 50, bracket.MinValue, bracketCap, share["bracket_medians"])
+		if wageBracket.Count > 0 {
+			wageBracket.AvgPay = wageBracket.TotalPay / float64(wageBracket.Count)
+			if medianNoiseScale(bracket.MinValue, bracketCap, share["bracket_medians"], wageBracket.Count) <= median {
+				wageBracket.MedianPay = median
+			} else {
+				withheldMedians++
+			}
+		}
+
+		pyramid.TotalEmployees += wageBracket.Count
+		pyramid.TotalPay += wageBracket.TotalPay
+		pyramid.Brackets = append(pyramid.Brackets, wageBracket)
+	}
+
+	// Pay above the last edge of a closed scheme counts as its own cell
+	pyramid.Unbracketed = 0
+	if !pyramid.Scheme.OpenEnded && len(definitions) > 0 {
+		unbracketedCap := math.Max(bound, definitions[len(definitions)-1].MaxValue)
+		largestCap = math.Max(largestCap, unbracketedCap)
+		pyramid.Unbracketed = noise.count(len(unbracketed), share["bracket_counts"])
+		pyramid.TotalEmployees += pyramid.Unbracketed
+		pyramid.TotalPay += math.Max(0, noise.sum(clippedSum(unbracketed, unbracketedCap), unbracketedCap, share["bracket_total_pay"]))
+	}
+
+	for i := range pyramid.Brackets {
+		pyramid.Brackets[i].Percentage = safeRatio(float64(pyramid.Brackets[i].Count), float64(pyramid.TotalEmployees)) * 100
+	}
+
+	addLaplaceQuery(release, "bracket_counts", share["bracket_counts"], 1)
+	addLaplaceQuery(release, "bracket_total_pay", share["bracket_total_pay"], largestCap)
+	addQuery(release, "bracket_medians", MechanismExponential, share["bracket_medians"], 1, 0)
+	release.Queries[len(release.Queries)-1].Withheld = withheldMedians
+	addQuery(release, "bracket_top_titles", MechanismLaplace, share["bracket_top_titles"], 1, privacyThreshold(share["bracket_top_titles"], opts.Delta))
+
+	pyramid.Population = populationDefinition(pyramid.Population)
+	pyramid.Privacy = release
+	return nil
+}
+
+// ApplyPrivacyToTitles rebuilds a title analysis from the records with the
+// grouping and rankings of titleOpts, releasing a noisy number of unique
+// titles and, for titles whose noisy count clears the delta threshold,
+// noisy headcount, total pay and median, splitting epsilon by
+// titlePrivacyShares. Each title's pay is bounded by its category. A total
+// or median whose noise scale exceeds it is withheld (left zero, with the
+// average). Rankings and the minimum count use the noisy values; max and
+// growth rankings need unreleased statistics and are refused. Variants,
+// extremes and spread are not released. Apply it before any inflation
+// adjustment or suppression.
+func ApplyPrivacyToTitles(analysis *models.TitleAnalysis, data *models.WageData, titleOpts TitleOptions, opts PrivacyOptions) error {
+	if titleOpts.Grouping == "" {
+		titleOpts.Grouping = GroupRaw
+	}
+	if titleOpts.Normalizer == nil {
+		titleOpts.Normalizer = taxonomy.DefaultNormalizer()
+	}
+	if len(titleOpts.RankBy) == 0 {
+		titleOpts.RankBy = []string{RankCount}
+	}
+	for _, rankBy := range titleOpts.RankBy {
+		if rankBy == RankMax || rankBy == RankGrowth || titleRankings[rankBy] == nil {
+			return fmt.Errorf("title ranking %q cannot be released privately (want %s, %s, %s or %s)",
+				rankBy, RankCount, RankTotalPay, RankMedian, RankMean)
+		}
+	}
+	keyFor, err := titleGrouping(titleOpts)
+	if err != nil {
+		return err
+	}
+
+	opts, noise, err := privacySetup(opts)
+	if err != nil {
+		return err
+	}
+	share := privacyShares(opts.Epsilon, titlePrivacyShares)
+	release := newPrivacyRelease(opts, opts.Delta)
+	categorizer := newCategoryCache(opts.Taxonomy)
+	release.CategoryBounds = opts.CategoryBounds
+
+	keys := make(map[string]string)
+	wages := make(map[string][]float64)
+	for _, record := range data.Records {
+		if !isKnownTitle(record.Title) {
+			continue
+		}
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+
+		key, ok := keys[record.Title]
+		if !ok {
+			key = keyFor(record.Title)
+			keys[record.Title] = key
+		}
+		wages[key] = append(wages[key], gross)
+	}
+
+	analysis.UniqueTitles = noise.count(len(wages), share["unique_titles"])
+
+	// Bounds come from the title released, never from its records
+	largestBound := 0.0
+	withheldTotals, withheldMedians := 0, 0
+	threshold := privacyThreshold(share["title_counts"], opts.Delta)
+	var eligible []models.TitleStats
+	for title, titleWages := range wages {
+		noisyCount := float64(len(titleWages)) + noise.laplace(1/share["title_counts"])
+		if noisyCount < threshold {
+			continue
+		}
+
+		bound := opts.PayBound
+		if categoryBound, ok := opts.CategoryBounds[categorizer.categorize(title)]; ok && categoryBound < bound {
+			bound = categoryBound
+		}
+		largestBound = math.Max(largestBound, bound)
+
+		sort.Float64s(titleWages)
+		released := models.TitleStats{
+			Title: title,
+			Count: int(math.Round(noisyCount)),
+		}
+
+		total := noise.sum(clippedSum(titleWages, bound), bound, share["title_total_pay"])
+		if bound/share["title_total_pay"] <= total {
+			released.TotalPay = total
+			released.AvgPay = total / float64(released.Count)
+		} else {
+			withheldTotals++
+		}
+
+		median := noise.quantile(titleWages, 50, 0, bound, share["title_medians"])
+		if medianNoiseScale(0, bound, share["title_medians"], released.Count) <= median {
+			released.MedianPay = median
+		} else {
+			withheldMedians++
+		}
+
+		if released.Count >= titleOpts.MinCount {
+			eligible = append(eligible, released)
+		}
+	}
+
+	rankings := make([]models.TitleRanking, len(titleOpts.RankBy))
+	for i, rankBy := range titleOpts.RankBy {
+		rankings[i] = models.TitleRanking{
+			By:     rankBy,
+			Titles: rankTitles(eligible, rankBy, titleOpts.TopN),
+		}
+	}
+	analysis.TopTitles = rankings[0].Titles
+	analysis.RankedBy = rankings[0].By
+	analysis.Rankings = rankings[1:]
+
+	addLaplaceQuery(release, "unique_titles", share["unique_titles"], 1)
+	addQuery(release, "title_counts", MechanismLaplace, share["title_counts"], 1, threshold)
+	addLaplaceQuery(release, "title_total_pay", share["title_total_pay"], largestBound)
+	release.Queries[len(release.Queries)-1].Withheld = withheldTotals
+	addQuery(release, "title_medians", MechanismExponential, share["title_medians"], 1, 0)
+	release.Queries[len(release.Queries)-1].Withheld = withheldMedians
+
+	analysis.Population = populationDefinition(analysis.Population)
+	analysis.Privacy = release
+	return nil
+}
+
+func privacySetup(opts PrivacyOptions) (PrivacyOptions, *privacyNoise, error) {
+	if opts.Epsilon <= 0 {
+		return opts, nil, fmt.Errorf("privacy epsilon must be positive")
+	}
+	if opts.Delta <= 0 {
+		opts.Delta = 1e-6
+	}
+	if opts.Delta >= 1 {
+		return opts, nil, fmt.Errorf("privacy delta must be below 1")
+	}
+	if opts.PayBound <= 0 {
+		opts.PayBound = 500000
+	}
+	if opts.CategoryBounds == nil {
+		opts.CategoryBounds = DefaultPrivacyCategoryBounds()
+	}
+
+	noise, err := newPrivacyNoise()
+	if err != nil {
+		return opts, nil, err
+	}
+	return opts, noise, nil
+}
+
+func newPrivacyRelease(opts PrivacyOptions, delta float64) *models.PrivacyRelease {
+	return &models.PrivacyRelease{
+		Epsilon:  opts.Epsilon,
+		Delta:    delta,
+		Unit:     PrivacyUnit,
+		PayBound: opts.PayBound,
+		Budget:   opts.Budget,
+		Queries:  []models.NoisyQuery{},
+	}
+}
+
+// privacyShares splits epsilon between statistics by their shares
+func privacyShares(epsilon float64, shares map[string]float64) map[string]float64 {
+	split := make(map[string]float64, len(shares))
+	for statistic, share := range shares {
+		split[statistic] = epsilon * share
+	}
+	return split
+}
+
+// addQuery records a noisy statistic on a release
+func addQuery(release *models.PrivacyRelease, statistic, mechanism string, epsilon, sensitivity, threshold float64) {
+	query := models.NoisyQuery{
+		Statistic:   statistic,
+		Mechanism:   mechanism,
+		Epsilon:     epsilon,
+		Sensitivity: sensitivity,
+		Threshold:   threshold,
+	}
+	if mechanism == MechanismLaplace {
+		query.Scale = sensitivity / epsilon
+	}
+	release.Queries = append(release.Queries, query)
+}
+
+func addLaplaceQuery(release *models.PrivacyRelease, statistic string, epsilon, sensitivity float64) {
+	addQuery(release, statistic, MechanismLaplace, epsilon, sensitivity, 0)
+}
+
+// medianNoiseScale is the typical error of a private median in dollars:
+// the exponential mechanism misses by about 2/epsilon ranks, and count
+// values spread evenly over [lower, upper] are that far apart per rank.
+// The median is withheld when this exceeds it.
+func medianNoiseScale(lower, upper, epsilon float64, count int) float64 {
+	if count <= 0 {
+		return math.Inf(1)
+	}
+	return 2 * (upper - lower) / (epsilon * float64(count))
+}
+
+// privacyThreshold is the noisy count a title needs to be listed. With
+// Laplace noise of scale 1/epsilon, a title held by a single record
+// clears it with probability at most delta.
+func privacyThreshold(epsilon, delta float64) float64 {
+	return 1 + math.Log(1/(2*delta))/epsilon
+}
+
+// populationDefinition copies a population filter without its record
+// counts, which are exact
+func populationDefinition(population *models.PopulationFilter) *models.PopulationFilter {
+	if population == nil {
+		return nil
+	}
+	definition := *population
+	definition.Records, definition.Kept, definition.Excluded = 0, 0, 0
+	definition.ExcludedBy = nil
+	return &definition
+}
+
+func clip(value, lower, upper float64) float64 {
+	return math.Min(math.Max(value, lower), upper)
+}
+
+// clippedSum adds values after clipping each to [0, upper]
+func clippedSum(values []float64, upper float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += clip(value, 0, upper)
+	}
+	return total
+}
+
+// privacyNoise draws noise from a source seeded by the operating system.
+// The seed is never recorded, since it would let anyone remove the noise.
+type privacyNoise struct {
+	rng *rand.Rand
+}
+
+func newPrivacyNoise() (*privacyNoise, error) {
+	var seed [8]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		return nil, fmt.Errorf("error seeding privacy noise: %w", err)
+	}
+	return &privacyNoise{rng: rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))}, nil
+}
+
+// laplace draws Laplace noise with the given scale
+func (n *privacyNoise) laplace(scale float64) float64 {
+	return scale * (n.rng.ExpFloat64() - n.rng.ExpFloat64())
+}
+
+// count adds Laplace noise to a count, rounding and clamping at zero
+func (n *privacyNoise) count(value int, epsilon float64) int {
+	noisy := math.Round(float64(value) + n.laplace(1/epsilon))
+	if noisy < 0 {
+		return 0
+	}
+	return int(noisy)
+}
+
+// sum adds Laplace noise to a sum of values bounded by sensitivity
+func (n *privacyNoise) sum(value, sensitivity, epsilon float64) float64 {
+	return value + n.laplace(sensitivity/epsilon)
+}
+
+// quantile picks the p-th percentile of sorted values clipped to
+// [lower, upper] with the exponential mechanism: each gap between
+// neighbouring values is chosen with probability proportional to its width
+// times exp(-epsilon * rank error / 2), and a point is drawn uniformly
+// within it
+func (n *privacyNoise) quantile(sorted []float64, p, lower, upper, epsilon float64) float64 {
+	points := make([]float64, 0, len(sorted)+2)
+	points = append(points, lower)
+	for _, value := range sorted {
+		points = append(points, clip(value, lower, upper))
+	}
+	points = append(points, upper)
+
+	// Sampling by the largest Gumbel-perturbed log weight avoids
+	// overflowing the weights
+	target := p / 100 * float64(len(sorted))
+	best, bestScore := -1, math.Inf(-1)
+	for i := 0; i+1 < len(points); i++ {
+		width := points[i+1] - points[i]
+		if width <= 0 {
+			continue
+		}
+		score := math.Log(width) - epsilon*math.Abs(float64(i)-target)/2 - math.Log(n.rng.ExpFloat64())
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return lower
+	}
+	return points[best] + n.rng.Float64()*(points[best+1]-points[best])
+}
+
+// selectTitles releases noisy counts for the titles whose noisy count
+// clears the threshold for delta, most frequent first, keeping the first
+// limit. Pay is not released for them.
+func (n *privacyNoise) selectTitles(titles map[string][]float64, epsilon, delta float64, limit int) []models.TitleCount {
+	threshold := privacyThreshold(epsilon, delta)
+	selected := []models.TitleCount{}
+	for title, wages := range titles {
+		noisyCount := float64(len(wages)) + n.laplace(1/epsilon)
+		if noisyCount < threshold {
+			continue
+		}
+		selected = append(selected, models.TitleCount{Title: title, Count: int(math.Round(noisyCount))})
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].Count == selected[j].Count {
+			return selected[i].Title < selected[j].Title
+		}
+		return selected[i].Count > selected[j].Count
+	})
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
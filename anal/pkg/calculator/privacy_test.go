@@ -0,0 +1,122 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+)
+
+func TestChargePrivacyBudget(t *testing.T) {
+	type charge struct {
+		epsilon, delta float64
+		ok             bool
+	}
+
+	tests := []struct {
+		name    string
+		charges []charge
+	}{
+		{"within budget", []charge{{0.4, 0, true}, {0.6, 0, true}}},
+		{"refuses epsilon overspend", []charge{{0.7, 0, true}, {0.4, 0, false}, {0.3, 0, true}}},
+		{"refuses delta overspend", []charge{{0.1, 6e-6, true}, {0.1, 6e-6, false}, {0.1, 4e-6, true}}},
+		{"even split within slack", []charge{{1.0 / 3, 0, true}, {1.0 / 3, 0, true}, {1.0 / 3, 0, true}, {1e-6, 0, false}}},
+		{"rejects zero epsilon", []charge{{0, 0, false}}},
+		{"rejects negative epsilon", []charge{{-0.1, 0, false}}},
+		{"rejects negative delta", []charge{{0.1, -1e-6, false}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := NewPrivacyBudget(1)
+			spent, spentDelta, charges := 0.0, 0.0, 0
+
+			for i, c := range tt.charges {
+				err := ChargePrivacyBudget(budget, fmt.Sprintf("command %d", i), c.epsilon, c.delta)
+				if (err == nil) != c.ok {
+					t.Fatalf("charge %d (%g, %g): err = %v, want ok %v", i, c.epsilon, c.delta, err, c.ok)
+				}
+				if c.ok {
+					spent += c.epsilon
+					spentDelta += c.delta
+					charges++
+				}
+
+				// A refused charge leaves the ledger as it was
+				if budget.Spent != spent || budget.SpentDelta != spentDelta || len(budget.Charges) != charges {
+					t.Fatalf("after charge %d: spent %g/%g with %d charges, want %g/%g with %d",
+						i, budget.Spent, budget.SpentDelta, len(budget.Charges), spent, spentDelta, charges)
+				}
+			}
+		})
+	}
+}
+
+func TestChargePrivacyLedgerConcurrent(t *testing.T) {
+	const commands, epsilon = 20, 0.1
+	ledger := filepath.Join(t.TempDir(), "privacy_budget.json")
+
+	var wg sync.WaitGroup
+	errs := make([]error, commands)
+	for i := 0; i < commands; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = ChargePrivacyLedger(ledger, 1, fmt.Sprintf("command %d", i), epsilon, 0)
+		}(i)
+	}
+	wg.Wait()
+
+	charged := 0
+	for _, err := range errs {
+		if err == nil {
+			charged++
+		}
+	}
+	if charged != 10 {
+		t.Errorf("%d charges succeeded, want 10", charged)
+	}
+
+	budget, err := parser.LoadPrivacyBudget(ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(budget.Charges) != charged {
+		t.Errorf("ledger records %d charges, want %d", len(budget.Charges), charged)
+	}
+	if math.Abs(budget.Spent-1) > 1e-9 {
+		t.Errorf("ledger spent = %g, want 1", budget.Spent)
+	}
+
+	// The exhausted ledger refuses further charges
+	if _, err := ChargePrivacyLedger(ledger, 1, "late", epsilon, 0); err == nil {
+		t.Errorf("charge against an exhausted ledger succeeded")
+	}
+}
+
+func TestMedianNoiseScale(t *testing.T) {
+	tests := []struct {
+		name       string
+		upper, eps float64
+		count      int
+		median     float64
+		withheld   bool
+	}{
+		{"large title", 300000, 0.1, 5000, 80000, false},
+		{"small title", 300000, 0.1, 20, 80000, true},
+		{"student bound", 60000, 0.1, 500, 5000, false},
+		{"empty", 300000, 1, 0, 80000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale := medianNoiseScale(0, tt.upper, tt.eps, tt.count)
+			if withheld := scale > tt.median; withheld != tt.withheld {
+				t.Errorf("scale %g for median %g: withheld = %v, want %v", scale, tt.median, withheld, tt.withheld)
+			}
+		})
+	}
+}
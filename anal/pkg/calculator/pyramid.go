@@ -1,78 +1,236 @@
 package calculator
 
 import (
+	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/montanaflynn/stats"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
 )
 
-// BracketDefinition defines a wage bracket
+// BracketDefinition defines a wage bracket. MaxValue is unused when the
+// bracket is open-ended.
 type BracketDefinition struct {
-	Range    string
-	MinValue float64
-	MaxValue float64
+	Range     string
+	MinValue  float64
+	MaxValue  float64
+	OpenEnded bool
+}
+
+// Contains reports whether a wage falls within the bracket
+func (b BracketDefinition) Contains(wage float64) bool {
+	return wage >= b.MinValue && (b.OpenEnded || wage < b.MaxValue)
+}
+
+// DefaultBracketScheme is the scheme used when none is selected
+const DefaultBracketScheme = "standard"
+
+// GetBracketSchemes returns the built-in bracket schemes by name
+func GetBracketSchemes() map[string]models.BracketScheme {
+	return map[string]models.BracketScheme{
+		"standard": {
+			Name:      "standard",
+			Type:      "edges",
+			Edges:     []float64{0, 25000, 50000, 75000, 100000, 150000, 200000, 300000, 500000, 1000000},
+			OpenEnded: true,
+		},
+		"fine": {
+			Name:      "fine",
+			Type:      "edges",
+			Edges:     []float64{0, 10000, 20000, 30000, 40000, 50000, 60000, 70000, 80000, 90000, 100000, 125000, 150000, 175000, 200000, 250000, 300000, 400000, 500000, 750000, 1000000},
+			OpenEnded: true,
+		},
+		"log": {
+			Name:      "log",
+			Type:      "log",
+			Start:     1000,
+			Stop:      1000000,
+			PerDecade: 4,
+			OpenEnded: true,
+		},
+		"quintiles": {
+			Name:      "quintiles",
+			Type:      "quantile",
+			Groups:    5,
+			OpenEnded: true,
+		},
+		"deciles": {
+			Name:      "deciles",
+			Type:      "quantile",
+			Groups:    10,
+			OpenEnded: true,
+		},
+	}
 }
 
 // GetWageBrackets returns the standard wage brackets
 func GetWageBrackets() []BracketDefinition {
-	return []BracketDefinition{
-		{"0-25k", 0, 25000},
-		{"25k-50k", 25000, 50000},
-		{"50k-75k", 50000, 75000},
-		{"75k-100k", 75000, 100000},
-		{"100k-150k", 100000, 150000},
-		{"150k-200k", 150000, 200000},
-		{"200k-300k", 200000, 300000},
-		{"300k-500k", 300000, 500000},
-		{"500k-1M", 500000, 1000000},
-		{"1M+", 1000000, 999999999},
+	brackets, _, _ := ResolveBrackets(GetBracketSchemes()[DefaultBracketScheme], nil)
+	return brackets
+}
+
+// ResolveBrackets turns a scheme into bracket definitions. Quantile schemes
+// derive their edges from the sorted wages; other schemes ignore them.
+// The resolved edges are returned for recording in the output.
+func ResolveBrackets(scheme models.BracketScheme, sortedWages []float64) ([]BracketDefinition, []float64, error) {
+	var edges []float64
+
+	switch scheme.Type {
+	case "edges":
+		edges = append(edges, scheme.Edges...)
+	case "log":
+		if scheme.Start <= 0 || scheme.Stop <= scheme.Start || scheme.PerDecade < 1 {
+			return nil, nil, fmt.Errorf("log bracket scheme %q needs 0 < start < stop and per_decade >= 1", scheme.Name)
+		}
+		edges = append(edges, 0)
+		steps := int(math.Round(math.Log10(scheme.Stop/scheme.Start) * float64(scheme.PerDecade)))
+		for i := 0; i <= steps; i++ {
+			edge := roundSignificant(scheme.Start*math.Pow(10, float64(i)/float64(scheme.PerDecade)), 2)
+			if edge > edges[len(edges)-1] {
+				edges = append(edges, edge)
+			}
+		}
+	case "quantile":
+		if scheme.Groups < 2 {
+			return nil, nil, fmt.Errorf("quantile bracket scheme %q needs at least 2 groups", scheme.Name)
+		}
+		edges = append(edges, 0)
+		for g := 1; g < scheme.Groups && len(sortedWages) > 0; g++ {
+			edge, _ := stats.Percentile(sortedWages, float64(g)/float64(scheme.Groups)*100)
+			if edge > edges[len(edges)-1] {
+				edges = append(edges, edge)
+			}
+		}
+		// The top group always runs to the maximum
+		scheme.OpenEnded = true
+	default:
+		return nil, nil, fmt.Errorf("unknown bracket scheme type %q", scheme.Type)
+	}
+
+	if len(edges) < 2 && !(scheme.OpenEnded && len(edges) == 1) {
+		return nil, nil, fmt.Errorf("bracket scheme %q needs at least two edges", scheme.Name)
+	}
+	if !sort.Float64sAreSorted(edges) {
+		return nil, nil, fmt.Errorf("bracket scheme %q edges must be ascending", scheme.Name)
 	}
+
+	var brackets []BracketDefinition
+	for i := 0; i+1 < len(edges); i++ {
+		brackets = append(brackets, BracketDefinition{
+			Range:    fmt.Sprintf("%s-%s", formatBracketValue(edges[i]), formatBracketValue(edges[i+1])),
+			MinValue: edges[i],
+			MaxValue: edges[i+1],
+		})
+	}
+
+	if scheme.OpenEnded {
+		top := edges[len(edges)-1]
+		brackets = append(brackets, BracketDefinition{
+			Range:     formatBracketValue(top) + "+",
+			MinValue:  top,
+			OpenEnded: true,
+		})
+	}
+
+	return brackets, edges, nil
+}
+
+// CalculatePyramid generates wage distribution pyramid using the standard brackets
+func CalculatePyramid(data *models.WageData, tax *taxonomy.Taxonomy) (*models.Pyramid, error) {
+	return CalculatePyramidWithScheme(data, GetBracketSchemes()[DefaultBracketScheme], tax)
 }
 
-// CalculatePyramid generates wage distribution pyramid
-func CalculatePyramid(data *models.WageData) (*models.Pyramid, error) {
-	brackets := GetWageBrackets()
-	bracketData := make(map[string]*bracketInfo)
+// CalculatePyramidWithScheme generates wage distribution pyramid for a
+// bracket scheme, breaking each bracket down by the categories of tax (the
+// bundled taxonomy when nil)
+func CalculatePyramidWithScheme(data *models.WageData, scheme models.BracketScheme, tax *taxonomy.Taxonomy) (*models.Pyramid, error) {
+	var records []pyramidRecord
+	var grossPays []float64
+
+	for _, record := range data.Records {
+		base, overtime, adjust, gross := parser.ConvertRecordToFloat(record)
+
+		if gross <= 0 {
+			continue
+		}
+
+		records = append(records, pyramidRecord{
+			title:    record.Title,
+			base:     base,
+			overtime: overtime,
+			adjust:   adjust,
+			gross:    gross,
+		})
+		grossPays = append(grossPays, gross)
+	}
+
+	sort.Float64s(grossPays)
+
+	brackets, edges, err := ResolveBrackets(scheme, grossPays)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bracket data is indexed like the brackets, since close edges can
+	// round to the same range label
+	bracketData := make([]*bracketInfo, len(brackets))
+	categorizer := newCategoryCache(tax)
 
 	// Initialize bracket data
-	for _, bracket := range brackets {
-		bracketData[bracket.Range] = &bracketInfo{
+	for i, bracket := range brackets {
+		bracketData[i] = &bracketInfo{
 			definition: bracket,
 			wages:      []float64{},
 			titles:     make(map[string][]float64),
+			categories: make(map[string][]float64),
 		}
 	}
 
 	totalEmployees := 0
 	totalPay := 0.0
+	unbracketed := 0
 
 	// Process each record
-	for _, record := range data.Records {
-		_, _, _, gross := parser.ConvertRecordToFloat(record)
-
-		if gross <= 0 {
-			continue
-		}
+	for _, record := range records {
+		gross := record.gross
 
 		totalEmployees++
 		totalPay += gross
 
 		// Find appropriate bracket
-		for _, bracket := range brackets {
-			if gross >= bracket.MinValue && gross < bracket.MaxValue {
-				bd := bracketData[bracket.Range]
+		found := false
+		for i, bracket := range brackets {
+			if bracket.Contains(gross) {
+				bd := bracketData[i]
 				bd.wages = append(bd.wages, gross)
+				bd.addComponents(record)
+
+				category := categorizer.categorize(record.title)
+				bd.categories[category] = append(bd.categories[category], gross)
 
 				// Track title data
-				if record.Title != "" && record.Title != "*****" {
-					bd.titles[record.Title] = append(bd.titles[record.Title], gross)
+				if record.title != "" && record.title != "*****" {
+					bd.titles[record.title] = append(bd.titles[record.title], gross)
 				}
+				found = true
 				break
 			}
 		}
+
+		if !found {
+			unbracketed++
+		}
+	}
+
+	resolved := scheme
+	resolved.Edges = edges
+	if scheme.Type == "quantile" {
+		resolved.OpenEnded = true
 	}
 
 	// Build pyramid structure
@@ -83,11 +241,16 @@ func CalculatePyramid(data *models.WageData) (*models.Pyramid, error) {
 		TotalEmployees: totalEmployees,
 		TotalPay:       totalPay,
 		Brackets:       []models.WageBracket{},
+		Scheme:         &resolved,
+		Unbracketed:    unbracketed,
+
+		Taxonomy:        categorizer.taxonomy.Name(),
+		TaxonomyVersion: categorizer.taxonomy.Version(),
 	}
 
 	// Calculate statistics for each bracket
-	for _, bracket := range brackets {
-		bd := bracketData[bracket.Range]
+	for i, bracket := range brackets {
+		bd := bracketData[i]
 
 		if len(bd.wages) == 0 {
 			continue
@@ -97,6 +260,7 @@ func CalculatePyramid(data *models.WageData) (*models.Pyramid, error) {
 			Range:      bracket.Range,
 			MinValue:   bracket.MinValue,
 			MaxValue:   bracket.MaxValue,
+			OpenEnded:  bracket.OpenEnded,
 			Count:      len(bd.wages),
 			Percentage: float64(len(bd.wages)) / float64(totalEmployees) * 100,
 		}
@@ -106,8 +270,25 @@ func CalculatePyramid(data *models.WageData) (*models.Pyramid, error) {
 		wageBracket.MedianPay, _ = stats.Median(bd.wages)
 		wageBracket.TotalPay = sumFloat64(bd.wages)
 
-		// Get top titles
+		// Pay component breakdown
+		count := float64(len(bd.wages))
+		wageBracket.PayComponents = models.PayComponents{
+			TotalBase:        bd.totalBase,
+			TotalOvertime:    bd.totalOvertime,
+			TotalAdjustments: bd.totalAdjust,
+			AvgBase:          bd.totalBase / count,
+			AvgOvertime:      bd.totalOvertime / count,
+			AvgAdjustments:   bd.totalAdjust / count,
+		}
+		wageBracket.OvertimeRecipientShare = float64(bd.overtimeRecipients) / count * 100
+		wageBracket.AdjustmentRecipientShare = float64(bd.adjustRecipients) / count * 100
+		if wageBracket.TotalPay > 0 {
+			wageBracket.SupplementShare = (bd.totalOvertime + bd.totalAdjust) / wageBracket.TotalPay * 100
+		}
+
+		// Get top titles and category mix
 		wageBracket.TopTitles = getTopTitles(bd.titles, 10)
+		wageBracket.Categories = getCategoryCounts(bd.categories, len(bd.wages))
 
 		pyramid.Brackets = append(pyramid.Brackets, wageBracket)
 	}
@@ -115,11 +296,41 @@ func CalculatePyramid(data *models.WageData) (*models.Pyramid, error) {
 	return pyramid, nil
 }
 
+// pyramidRecord holds the fields of a record needed for bucketing
+type pyramidRecord struct {
+	title    string
+	base     float64
+	overtime float64
+	adjust   float64
+	gross    float64
+}
+
 // bracketInfo holds temporary data for bracket calculations
 type bracketInfo struct {
 	definition BracketDefinition
 	wages      []float64
 	titles     map[string][]float64
+	categories map[string][]float64
+
+	totalBase          float64
+	totalOvertime      float64
+	totalAdjust        float64
+	overtimeRecipients int
+	adjustRecipients   int
+}
+
+// addComponents accumulates a record's pay components into the bracket
+func (b *bracketInfo) addComponents(record pyramidRecord) {
+	b.totalBase += record.base
+	b.totalOvertime += record.overtime
+	b.totalAdjust += record.adjust
+
+	if record.overtime != 0 {
+		b.overtimeRecipients++
+	}
+	if record.adjust != 0 {
+		b.adjustRecipients++
+	}
 }
 
 // getTopTitles returns the top N titles by frequency
@@ -146,4 +357,28 @@ func getTopTitles(titles map[string][]float64, limit int) []models.TitleCount {
 		return titleStats[:limit]
 	}
 	return titleStats
-}
\ No newline at end of file
+}
+// formatBracketValue renders a bracket edge as a short label (25k, 1M)
+func formatBracketValue(value float64) string {
+	switch {
+	case value >= 1000000:
+		return trimFloat(value/1000000) + "M"
+	case value >= 1000:
+		return trimFloat(value/1000) + "k"
+	default:
+		return trimFloat(value)
+	}
+}
+
+func trimFloat(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
+
+// roundSignificant rounds a value to the given number of significant digits
+func roundSignificant(value float64, digits int) float64 {
+	if value == 0 {
+		return 0
+	}
+	scale := math.Pow(10, float64(digits)-math.Ceil(math.Log10(math.Abs(value))))
+	return math.Round(value*scale) / scale
+}
@@ -9,8 +9,12 @@ import (
 	"github.com/montanaflynn/stats"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/sketch"
 )
 
+// PercentileValues are the percentiles reported in every summary
+var PercentileValues = []float64{25, 50, 75, 90, 95, 99}
+
 // CalculateSummary computes statistical summary for wage data
 func CalculateSummary(data *models.WageData) (*models.Summary, error) {
 	var grossPays []float64
@@ -47,12 +51,18 @@ func CalculateSummary(data *models.WageData) (*models.Summary, error) {
 
 	// Calculate percentiles
 	percentiles := make(map[string]float64)
-	percentileValues := []float64{25, 50, 75, 90, 95, 99}
-	for _, p := range percentileValues {
+	for _, p := range PercentileValues {
 		value, _ := stats.Percentile(grossPays, p)
 		percentiles[formatPercentileKey(p)] = value
 	}
 
+	// Build mergeable sketch for higher-level aggregates
+	digest := sketch.NewTDigest(sketch.DefaultCompression)
+	for _, gross := range grossPays {
+		digest.Add(gross)
+	}
+	digest.Compress()
+
 	// Calculate pay components
 	totalBase := sumFloat64(basePays)
 	totalOvertime := sumFloat64(overtimePays)
@@ -73,6 +83,10 @@ func CalculateSummary(data *models.WageData) (*models.Summary, error) {
 		MinPay:        min,
 		MaxPay:        max,
 		Percentiles:   percentiles,
+		Gini:          CalculateGiniCoefficient(grossPays),
+		Skewness:      CalculateSkewness(grossPays),
+		Kurtosis:      CalculateKurtosis(grossPays),
+		Sketch:        digest,
 		PayComponents: models.PayComponents{
 			TotalBase:        totalBase,
 			TotalOvertime:    totalOvertime,
@@ -97,6 +111,11 @@ func CalculateGiniCoefficient(wages []float64) float64 {
 	copy(sorted, wages)
 	sort.Float64s(sorted)
 
+	return sortedGini(sorted)
+}
+
+// sortedGini is the Gini coefficient of non-empty wages already sorted
+func sortedGini(sorted []float64) float64 {
 	n := float64(len(sorted))
 	index := 0.0
 	gini := 0.0
@@ -114,6 +133,58 @@ func CalculateGiniCoefficient(wages []float64) float64 {
 	return gini / (n * total)
 }
 
+// CalculateTheilIndex calculates the Theil T index of inequality
+func CalculateTheilIndex(wages []float64) float64 {
+	mean, err := stats.Mean(wages)
+	if err != nil || mean <= 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, wage := range wages {
+		if wage <= 0 {
+			continue
+		}
+		ratio := wage / mean
+		sum += ratio * math.Log(ratio)
+	}
+
+	return sum / float64(len(wages))
+}
+
+// CalculateAtkinsonIndex calculates the Atkinson index for an inequality
+// aversion parameter epsilon; higher epsilon weights the bottom more
+func CalculateAtkinsonIndex(wages []float64, epsilon float64) float64 {
+	mean, err := stats.Mean(wages)
+	if err != nil || mean <= 0 {
+		return 0
+	}
+
+	n := float64(len(wages))
+
+	// Epsilon of 1 uses the geometric mean
+	if epsilon == 1 {
+		logSum := 0.0
+		for _, wage := range wages {
+			if wage <= 0 {
+				return 1
+			}
+			logSum += math.Log(wage / mean)
+		}
+		return 1 - math.Exp(logSum/n)
+	}
+
+	sum := 0.0
+	for _, wage := range wages {
+		if wage <= 0 && epsilon > 1 {
+			return 1
+		}
+		sum += math.Pow(wage/mean, 1-epsilon)
+	}
+
+	return 1 - math.Pow(sum/n, 1/(1-epsilon))
+}
+
 // CalculateSkewness calculates the skewness of distribution
 func CalculateSkewness(wages []float64) float64 {
 	if len(wages) < 3 {
@@ -169,6 +240,43 @@ func sumFloat64(values []float64) float64 {
 	return sum
 }
 
+// percentileOf returns a percentile of sorted values, falling back to the
+// minimum when there are too few values to interpolate that far into the tail
+func percentileOf(sorted []float64, p float64) float64 {
+	value, err := stats.Percentile(sorted, p)
+	if err != nil {
+		return sorted[0]
+	}
+	return value
+}
+
+// sortedPercentile is percentileOf for values known to be sorted. It
+// indexes them directly with the interpolation stats.Percentile uses,
+// instead of sorting a copy.
+func sortedPercentile(sorted []float64, p float64) float64 {
+	n := len(sorted)
+	index := p / 100 * float64(n)
+	i := int(index)
+	switch {
+	case n == 1 || p <= 0 || p > 100:
+		return sorted[0]
+	case index == float64(i):
+		return sorted[i-1]
+	case index > 1:
+		return (sorted[i-1] + sorted[i]) / 2
+	}
+	return sorted[0]
+}
+
+// sortedMedian is stats.Median for non-empty values known to be sorted
+func sortedMedian(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[n/2]
+}
+
 func formatPercentileKey(p float64) string {
 	return fmt.Sprintf("p%d", int(p))
 }
\ No newline at end of file
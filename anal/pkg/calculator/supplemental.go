@@ -0,0 +1,231 @@
+package calculator
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// Pay components reported by AnalyzeSupplementalPay
+const (
+	ComponentOvertime    = "overtime"
+	ComponentAdjustments = "adjustments"
+)
+
+// AnalyzeSupplementalPay reports how overtime and adjustments are spread
+// across the employees of a location-year, with the topN titles and
+// categories by dollars. The taxonomy defaults to the bundled one.
+func AnalyzeSupplementalPay(data *models.WageData, tax *taxonomy.Taxonomy, topN int) (*models.SupplementalPayAnalysis, error) {
+	if tax == nil {
+		tax = taxonomy.Default()
+	}
+
+	categorizer := newCategoryCache(tax)
+	overtime := newComponentData()
+	adjustments := newComponentData()
+	titleEmployees := make(map[string]int)
+	categoryEmployees := make(map[string]int)
+
+	employeeCount := 0
+	totalGross := 0.0
+
+	for _, record := range data.Records {
+		_, overtimePay, adjustPay, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+
+		title := record.Title
+		if !isKnownTitle(title) {
+			title = ""
+		}
+		category := categorizer.categorize(record.Title)
+
+		titleEmployees[title]++
+		categoryEmployees[category]++
+		overtime.add(title, category, overtimePay)
+		adjustments.add(title, category, adjustPay)
+
+		employeeCount++
+		totalGross += gross
+	}
+
+	if employeeCount == 0 {
+		return nil, nil
+	}
+
+	analysis := &models.SupplementalPayAnalysis{
+		Location:        data.Location,
+		Year:            data.Year,
+		GeneratedAt:     time.Now(),
+		EmployeeCount:   employeeCount,
+		TotalGrossPay:   totalGross,
+		Taxonomy:        tax.Name(),
+		TaxonomyVersion: tax.Version(),
+		Overtime:        overtime.concentration(ComponentOvertime, employeeCount, totalGross, titleEmployees, categoryEmployees, topN),
+		Adjustments:     adjustments.concentration(ComponentAdjustments, employeeCount, totalGross, titleEmployees, categoryEmployees, topN),
+	}
+
+	return analysis, nil
+}
+
+// ApplySupplementalPayChanges fills each analysis's change from the same
+// location's previous year of data, when present
+func ApplySupplementalPayChanges(analyses []*models.SupplementalPayAnalysis) {
+	byLocation := make(map[string][]*models.SupplementalPayAnalysis)
+	for _, analysis := range analyses {
+		byLocation[analysis.Location] = append(byLocation[analysis.Location], analysis)
+	}
+
+	for _, series := range byLocation {
+		sort.Slice(series, func(i, j int) bool {
+			return series[i].Year < series[j].Year
+		})
+
+		for i := 1; i < len(series); i++ {
+			previous, current := series[i-1], series[i]
+			current.Overtime.Change = componentChange(previous.Year, &previous.Overtime, &current.Overtime)
+			current.Adjustments.Change = componentChange(previous.Year, &previous.Adjustments, &current.Adjustments)
+		}
+	}
+}
+
+// componentChange compares a component with the previous year
+func componentChange(previousYear int, previous, current *models.ComponentConcentration) *models.ComponentChange {
+	return &models.ComponentChange{
+		PreviousYear:         previousYear,
+		RecipientsGrowth:     percentChange(float64(previous.Recipients), float64(current.Recipients)),
+		RecipientShareChange: current.RecipientShare - previous.RecipientShare,
+		TotalGrowth:          percentChange(previous.Total, current.Total),
+		MedianGrowth:         percentChange(previous.MedianPerRecipient, current.MedianPerRecipient),
+		Top10ShareChange:     current.Top10Share - previous.Top10Share,
+	}
+}
+
+// componentData accumulates one pay component across records
+type componentData struct {
+	amounts    []float64
+	negative   int
+	titles     map[string]*componentGroupData
+	categories map[string]*componentGroupData
+}
+
+// componentGroupData accumulates a component's recipients within a group
+type componentGroupData struct {
+	recipients int
+	total      float64
+}
+
+func newComponentData() *componentData {
+	return &componentData{
+		titles:     make(map[string]*componentGroupData),
+		categories: make(map[string]*componentGroupData),
+	}
+}
+
+// add records one employee's amount; only positive amounts are recipients
+func (c *componentData) add(title, category string, amount float64) {
+	if amount < 0 {
+		c.negative++
+		return
+	}
+	if amount == 0 {
+		return
+	}
+
+	c.amounts = append(c.amounts, amount)
+	if title != "" {
+		addToGroup(c.titles, title, amount)
+	}
+	addToGroup(c.categories, category, amount)
+}
+
+func addToGroup(groups map[string]*componentGroupData, key string, amount float64) {
+	group, exists := groups[key]
+	if !exists {
+		group = &componentGroupData{}
+		groups[key] = group
+	}
+	group.recipients++
+	group.total += amount
+}
+
+// concentration summarizes the component's recipients
+func (c *componentData) concentration(component string, employeeCount int, totalGross float64, titleEmployees, categoryEmployees map[string]int, topN int) models.ComponentConcentration {
+	total := sumFloat64(c.amounts)
+	result := models.ComponentConcentration{
+		Component:      component,
+		Recipients:     len(c.amounts),
+		RecipientShare: float64(len(c.amounts)) / float64(employeeCount) * 100,
+		NegativeCount:  c.negative,
+		Total:          total,
+		TopTitles:      componentGroups(c.titles, titleEmployees, total, topN),
+		TopCategories:  componentGroups(c.categories, categoryEmployees, total, topN),
+	}
+	if len(c.amounts) == 0 {
+		return result
+	}
+
+	// Largest amounts first for the top shares
+	sort.Sort(sort.Reverse(sort.Float64Slice(c.amounts)))
+
+	result.ShareOfGross = safeRatio(result.Total, totalGross) * 100
+	result.AvgPerRecipient = result.Total / float64(len(c.amounts))
+	result.MedianPerRecipient, _ = stats.Median(c.amounts)
+	result.MaxPerRecipient = c.amounts[0]
+	result.Gini = CalculateGiniCoefficient(c.amounts)
+	result.Top1Share = topShare(c.amounts, result.Total, 0.01)
+	result.Top10Share = topShare(c.amounts, result.Total, 0.10)
+
+	result.Percentiles = make(map[string]float64)
+	for _, p := range PercentileValues {
+		value, _ := stats.Percentile(c.amounts, p)
+		result.Percentiles[formatPercentileKey(p)] = value
+	}
+
+	return result
+}
+
+// topShare returns the percent of total held by the largest fraction of
+// amounts sorted in descending order, counting at least one recipient
+func topShare(descending []float64, total, fraction float64) float64 {
+	n := int(math.Ceil(float64(len(descending)) * fraction))
+	if n < 1 {
+		n = 1
+	}
+	return safeRatio(sumFloat64(descending[:n]), total) * 100
+}
+
+// componentGroups returns the top N groups by component dollars, with
+// shares of the component's total
+func componentGroups(groups map[string]*componentGroupData, employees map[string]int, total float64, topN int) []models.ComponentGroup {
+	result := []models.ComponentGroup{}
+	for name, group := range groups {
+		result = append(result, models.ComponentGroup{
+			Name:            name,
+			Employees:       employees[name],
+			Recipients:      group.recipients,
+			RecipientShare:  safeRatio(float64(group.recipients), float64(employees[name])) * 100,
+			Total:           group.total,
+			Share:           safeRatio(group.total, total) * 100,
+			AvgPerRecipient: group.total / float64(group.recipients),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total == result[j].Total {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Total > result[j].Total
+	})
+
+	if len(result) > topN {
+		result = result[:topN]
+	}
+	return result
+}
@@ -0,0 +1,980 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// Small-cell actions
+const (
+	SuppressDrop    = "suppress"
+	SuppressCoarsen = "coarsen"
+)
+
+// Kinds of cells a suppression policy covers
+const (
+	CellsTitleStats  = "title_stats"
+	CellsTitleCounts = "title_counts"
+	CellsBrackets    = "brackets"
+)
+
+// Markers on suppressed or merged brackets
+const (
+	BracketSuppressed = "suppressed"
+	BracketSecondary  = "secondary"
+	BracketMerged     = "merged"
+)
+
+// CombinedCellTitle labels the row that coarsened title cells are combined into
+const CombinedCellTitle = "Other (combined small cells)"
+
+// GetSuppressionPolicies returns the built-in suppression policies. "none"
+// publishes every cell, which is what analyses do when no policy is given.
+func GetSuppressionPolicies() map[string]models.SuppressionPolicy {
+	return map[string]models.SuppressionPolicy{
+		"none": {
+			Name:        "none",
+			Description: "Publishes every cell",
+		},
+		"suppress": {
+			Name:        "suppress",
+			Description: "Drops titles and withholds bracket pay for cells of fewer than 5 employees",
+			TitleStats:  models.SuppressionRule{MinCount: 5, Action: SuppressDrop},
+			TitleCounts: models.SuppressionRule{MinCount: 5, Action: SuppressDrop},
+			Brackets:    models.SuppressionRule{MinCount: 5, Action: SuppressDrop},
+		},
+		"coarsen": {
+			Name:        "coarsen",
+			Description: "Combines titles and merges brackets with fewer than 5 employees",
+			TitleStats:  models.SuppressionRule{MinCount: 5, Action: SuppressCoarsen},
+			TitleCounts: models.SuppressionRule{MinCount: 5, Action: SuppressCoarsen},
+			Brackets:    models.SuppressionRule{MinCount: 5, Action: SuppressCoarsen},
+		},
+		"strict": {
+			Name:        "strict",
+			Description: "Combines titles and merges brackets with fewer than 11 employees",
+			TitleStats:  models.SuppressionRule{MinCount: 11, Action: SuppressCoarsen},
+			TitleCounts: models.SuppressionRule{MinCount: 11, Action: SuppressCoarsen},
+			Brackets:    models.SuppressionRule{MinCount: 11, Action: SuppressCoarsen},
+		},
+	}
+}
+
+// ApplySuppressionToTitles applies the policy's title statistics rule to
+// every ranked list of titles in place and records the policy on the
+// analysis. Apply it last, after any inflation adjustment.
+func ApplySuppressionToTitles(analysis *models.TitleAnalysis, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	suppressed := make(map[string]int)
+	analysis.TopTitles = suppressTitleStats(analysis.TopTitles, applied.TitleStats, suppressed)
+	for i := range analysis.Rankings {
+		analysis.Rankings[i].Titles = suppressTitleStats(analysis.Rankings[i].Titles, applied.TitleStats, suppressed)
+	}
+	recordSuppressedTitles(applied, CellsTitleStats, applied.TitleStats.Action, suppressed)
+
+	analysis.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToPyramid applies the policy's bracket rule to the
+// pyramid's brackets, then its title counts rule to each bracket's top
+// titles and categories, and records the policy on the pyramid. Apply it
+// last, after any inflation adjustment.
+func ApplySuppressionToPyramid(pyramid *models.Pyramid, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	rule := applied.Brackets
+	if rule.MinCount > 0 {
+		if rule.Action == SuppressCoarsen {
+			pyramid.Brackets = coarsenBrackets(applied, pyramid.Brackets, rule.MinCount)
+		} else {
+			suppressBrackets(applied, pyramid.Brackets, rule.MinCount)
+		}
+	}
+
+	suppressed := make(map[string]int)
+	for i := range pyramid.Brackets {
+		bracket := &pyramid.Brackets[i]
+		bracket.TopTitles = suppressTitleCounts(bracket.TopTitles, applied.TitleCounts, suppressed)
+		bracket.Categories = suppressCategoryCounts(bracket.Categories, applied.TitleCounts, bracket.Count)
+	}
+	recordSuppressedTitles(applied, CellsTitleCounts, applied.TitleCounts.Action, suppressed)
+
+	pyramid.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToCategories applies the policy's title counts rule to
+// the uncategorized titles in place and records the policy on the analysis
+func ApplySuppressionToCategories(analysis *models.CategoryAnalysis, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	suppressed := make(map[string]int)
+	analysis.UncategorizedTitles = suppressTitleCounts(analysis.UncategorizedTitles, applied.TitleCounts, suppressed)
+	recordSuppressedTitles(applied, CellsTitleCounts, applied.TitleCounts.Action, suppressed)
+
+	analysis.Suppression = applied
+	return nil
+}
+
+// ComparisonMinCount returns the headcount a cell needs for its pay to be
+// published by a comparison of title cells, such as the locations of a
+// title comparison or the levels of a compression report: minCount, raised
+// to the policy's title statistics minimum so no published cell is smaller
+func ComparisonMinCount(minCount int, policy models.SuppressionPolicy) int {
+	if policy.TitleStats.MinCount > minCount {
+		return policy.TitleStats.MinCount
+	}
+	return minCount
+}
+
+// ApplySuppressionToTitleComparisons applies the policy's title counts rule
+// to the locations each title lists as below the comparison threshold, and
+// records the policy on the report. Cells are counted once per title and
+// location. The report must have been compared with at least
+// ComparisonMinCount employees per location, since pooled statistics cannot
+// be recomputed without the cells' wages.
+func ApplySuppressionToTitleComparisons(report *models.TitleComparisonReport, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+	if report.MinCount < applied.TitleStats.MinCount {
+		return fmt.Errorf("suppression policy %q needs titles compared with a minimum count of at least %d, not %d",
+			applied.Name, applied.TitleStats.MinCount, report.MinCount)
+	}
+
+	suppressed := make(map[string]int)
+	for i := range report.Titles {
+		title := &report.Titles[i]
+		locations := make(map[string]int)
+		title.BelowThreshold = suppressLocationCounts(title.BelowThreshold, applied.TitleCounts, locations)
+		for location, count := range locations {
+			suppressed[title.Title+"|"+location] = count
+		}
+	}
+	recordSuppressedTitles(applied, CellsTitleCounts, applied.TitleCounts.Action, suppressed)
+
+	report.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToTrajectories applies the policy's title statistics
+// rule to each year's point of every title trajectory and to the renames
+// matched between them, and records the policy on the report. A title's
+// span, growth and whether it was introduced or retired are recomputed
+// from the points that remain, as are the report's counts of introduced
+// and retired titles; a title with no points left is dropped.
+func ApplySuppressionToTrajectories(report *models.TitleTrajectoryReport, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	rule := applied.TitleStats
+	suppressed := make(map[string]int)
+	if rule.MinCount > 0 {
+		report.Introduced, report.Retired = 0, 0
+		kept := []models.TitleTrajectory{}
+		combined := make(map[int]*models.TitleYearPoint)
+		for _, trajectory := range report.Titles {
+			var points []models.TitleYearPoint
+			for _, point := range trajectory.Points {
+				if point.Count >= rule.MinCount {
+					points = append(points, point)
+					continue
+				}
+				suppressed[fmt.Sprintf("%s|%d", trajectory.Title, point.Year)] = point.Count
+				if combined[point.Year] == nil {
+					combined[point.Year] = &models.TitleYearPoint{Year: point.Year}
+				}
+				combined[point.Year].Count += point.Count
+				combined[point.Year].TotalPay += point.TotalPay
+			}
+			if len(points) == 0 {
+				continue
+			}
+
+			trimTrajectory(&trajectory, points, report.FirstYear, report.LastYear)
+			trajectory.RenamedFrom, trajectory.RenamedTo = nil, nil
+			if trajectory.Introduced {
+				report.Introduced++
+			}
+			if trajectory.Retired {
+				report.Retired++
+			}
+			kept = append(kept, trajectory)
+		}
+
+		if rule.Action == SuppressCoarsen {
+			if trajectory, ok := combinedTrajectory(combined, rule.MinCount); ok {
+				kept = append(kept, trajectory)
+			}
+		}
+		report.Titles = kept
+
+		renames := []models.TitleRename{}
+		for _, rename := range report.Renames {
+			if rename.FromCount >= rule.MinCount && rename.ToCount >= rule.MinCount {
+				renames = append(renames, rename)
+			}
+		}
+		report.Renames = renames
+		linkRenames(report)
+	}
+	recordSuppressedTitles(applied, CellsTitleStats, rule.Action, suppressed)
+
+	report.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToFlows applies the policy's title statistics rule to
+// the gainers, losers, births and deaths of every flow in the report, and
+// records the policy on the report. A title is a small cell in a flow when
+// either year it is held in has fewer than the minimum count.
+func ApplySuppressionToFlows(report *models.HeadcountFlowReport, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	suppressed := make(map[string]int)
+	for i := range report.Flows {
+		flow := &report.Flows[i]
+		titles := make(map[string]int)
+		flow.Gainers = suppressTitleFlows(flow.Gainers, applied.TitleStats, titles)
+		flow.Losers = suppressTitleFlows(flow.Losers, applied.TitleStats, titles)
+		flow.Births = suppressTitleFlows(flow.Births, applied.TitleStats, titles)
+		flow.Deaths = suppressTitleFlows(flow.Deaths, applied.TitleStats, titles)
+		for title, count := range titles {
+			suppressed[fmt.Sprintf("%s|%d", title, flow.Year)] = count
+		}
+	}
+	recordSuppressedTitles(applied, CellsTitleStats, applied.TitleStats.Action, suppressed)
+
+	report.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToHistogram applies the policy's title counts rule to
+// each category's count in every bin and in the overflow, as it applies to
+// the categories of pyramid brackets, and records the policy on the
+// histogram. A category with any cell changed loses its density estimate,
+// which would still show the employees in those cells.
+func ApplySuppressionToHistogram(histogram *models.WageHistogram, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	rule := applied.TitleCounts
+	suppressed := make(map[string]int)
+	if rule.MinCount > 0 {
+		bins := len(histogram.Histogram.Counts)
+		if rule.Action == SuppressCoarsen && categoryHistogram(histogram.Categories, OtherCategory) < 0 {
+			histogram.Categories = append(histogram.Categories, models.CategoryHistogram{
+				Category: OtherCategory,
+				Counts:   make([]int, bins),
+			})
+		}
+
+		// The bin after the last holds the overflow
+		changed := make(map[string]bool)
+		for bin := 0; bin <= bins; bin++ {
+			cell := func(category *models.CategoryHistogram) *int {
+				if bin == bins {
+					return &category.Overflow
+				}
+				return &category.Counts[bin]
+			}
+
+			var counts []models.CategoryCount
+			total := 0
+			for i := range histogram.Categories {
+				category := &histogram.Categories[i]
+				if count := *cell(category); count > 0 {
+					counts = append(counts, models.CategoryCount{Category: category.Category, Count: count})
+					total += count
+					if count < rule.MinCount {
+						suppressed[fmt.Sprintf("%s|%d", category.Category, bin)] = count
+					}
+				}
+			}
+
+			published := make(map[string]int)
+			for _, count := range suppressCategoryCounts(counts, rule, total) {
+				published[count.Category] = count.Count
+			}
+			for i := range histogram.Categories {
+				category := &histogram.Categories[i]
+				if count := cell(category); *count != published[category.Category] {
+					*count = published[category.Category]
+					changed[category.Category] = true
+				}
+			}
+		}
+
+		kept := []models.CategoryHistogram{}
+		for _, category := range histogram.Categories {
+			if changed[category.Category] {
+				category.Count = category.Overflow
+				for _, count := range category.Counts {
+					category.Count += count
+				}
+				category.Share = safeRatio(float64(category.Count), float64(histogram.Count)) * 100
+				category.Density = binDensity(category.Counts, histogram.Histogram.Edges, histogram.Histogram.Scale, category.Count)
+				category.Bandwidth, category.KDE = 0, nil
+			}
+			if category.Count > 0 {
+				kept = append(kept, category)
+			}
+		}
+
+		// Largest categories first, as CalculateHistograms orders them
+		sort.Slice(kept, func(i, j int) bool {
+			if kept[i].Count == kept[j].Count {
+				return kept[i].Category < kept[j].Category
+			}
+			return kept[i].Count > kept[j].Count
+		})
+		histogram.Categories = kept
+	}
+	recordSuppressedTitles(applied, CellsTitleCounts, rule.Action, suppressed)
+
+	histogram.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToSupplementalPay applies the policy's title counts rule
+// to the top titles and categories of overtime and adjustments, by their
+// number of recipients, and records the policy on the analysis. Coarsened
+// titles are combined into one row and coarsened categories join Other.
+// Apply it after ApplySupplementalPayChanges.
+func ApplySuppressionToSupplementalPay(analysis *models.SupplementalPayAnalysis, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	rule := applied.TitleCounts
+	suppressed := make(map[string]int)
+	for _, component := range []*models.ComponentConcentration{&analysis.Overtime, &analysis.Adjustments} {
+		titles := make(map[string]int)
+		component.TopTitles = suppressComponentGroups(component.TopTitles, rule, CombinedCellTitle, titles)
+		component.TopCategories = suppressComponentGroups(component.TopCategories, rule, OtherCategory, titles)
+		for name, count := range titles {
+			suppressed[component.Component+"|"+name] = count
+		}
+	}
+	recordSuppressedTitles(applied, CellsTitleCounts, rule.Action, suppressed)
+
+	analysis.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToCompression records the policy on a pay compression
+// report. Levels below the report's MinCount are already left out, so the
+// report must have been built with at least ComparisonMinCount employees
+// per level.
+func ApplySuppressionToCompression(report *models.PayCompression, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+	if report.MinCount < applied.TitleStats.MinCount {
+		return fmt.Errorf("suppression policy %q needs levels compared with a minimum count of at least %d, not %d",
+			applied.Name, applied.TitleStats.MinCount, report.MinCount)
+	}
+
+	report.Suppression = applied
+	return nil
+}
+
+// ApplySuppressionToTaxonomyReport applies the policy's title counts rule
+// to the titles and categories of a taxonomy report, and records the policy
+// on the report. Coarsened titles are combined into one unclassified row
+// and coarsened categories join Other. Rule usage and conflict counts still
+// cover every title.
+func ApplySuppressionToTaxonomyReport(report *models.TaxonomyReport, policy models.SuppressionPolicy) error {
+	applied, err := suppressionDefaults(policy)
+	if err != nil {
+		return err
+	}
+
+	rule := applied.TitleCounts
+	suppressed := make(map[string]int)
+	report.Titles = suppressTitleRules(report.Titles, rule, suppressed)
+	report.Categories = suppressCategoryCounts(report.Categories, rule, report.Records)
+	recordSuppressedTitles(applied, CellsTitleCounts, rule.Action, suppressed)
+
+	report.Suppression = applied
+	return nil
+}
+
+func suppressionDefaults(policy models.SuppressionPolicy) (*models.SuppressionPolicy, error) {
+	rules := []struct {
+		cells string
+		rule  *models.SuppressionRule
+	}{
+		{CellsTitleStats, &policy.TitleStats},
+		{CellsTitleCounts, &policy.TitleCounts},
+		{CellsBrackets, &policy.Brackets},
+	}
+	for _, r := range rules {
+		if r.rule.MinCount < 0 {
+			return nil, fmt.Errorf("suppression policy %q: %s min_count must not be negative", policy.Name, r.cells)
+		}
+		if r.rule.MinCount > 0 && r.rule.Action == "" {
+			r.rule.Action = SuppressDrop
+		}
+		if r.rule.Action != "" && r.rule.Action != SuppressDrop && r.rule.Action != SuppressCoarsen {
+			return nil, fmt.Errorf("suppression policy %q: unknown %s action %q (want %s or %s)", policy.Name, r.cells, r.rule.Action, SuppressDrop, SuppressCoarsen)
+		}
+	}
+
+	policy.Suppressed = nil
+	return &policy, nil
+}
+
+// recordSuppressed adds suppressed cells to the policy's tally
+func recordSuppressed(p *models.SuppressionPolicy, cells, action string, count, employees int) {
+	if count == 0 {
+		return
+	}
+	for i := range p.Suppressed {
+		if p.Suppressed[i].Cells == cells && p.Suppressed[i].Action == action {
+			p.Suppressed[i].Count += count
+			p.Suppressed[i].Employees += employees
+			return
+		}
+	}
+	p.Suppressed = append(p.Suppressed, models.SuppressedCells{
+		Cells:     cells,
+		Action:    action,
+		Count:     count,
+		Employees: employees,
+	})
+}
+
+// recordSuppressedTitles tallies suppressed cells keyed by title
+func recordSuppressedTitles(p *models.SuppressionPolicy, cells, action string, suppressed map[string]int) {
+	employees := 0
+	for _, count := range suppressed {
+		employees += count
+	}
+	recordSuppressed(p, cells, action, len(suppressed), employees)
+}
+
+// suppressTitleStats drops titles with fewer than the rule's minimum count
+// or, when coarsening, combines them into one row placed last. The
+// combined row keeps exact counts and totals but no medians, extremes or
+// spread, and is itself dropped if still too small. Each title removed is
+// added to suppressed.
+func suppressTitleStats(titles []models.TitleStats, rule models.SuppressionRule, suppressed map[string]int) []models.TitleStats {
+	if rule.MinCount == 0 {
+		return titles
+	}
+
+	kept := []models.TitleStats{}
+	combined := models.TitleStats{Title: CombinedCellTitle}
+	for _, title := range titles {
+		if title.Count >= rule.MinCount {
+			kept = append(kept, title)
+			continue
+		}
+		suppressed[title.Title] = title.Count
+		combined.Count += title.Count
+		combined.PrevCount += title.PrevCount
+		combined.TotalPay += title.TotalPay
+		combined.RealTotalPay += title.RealTotalPay
+	}
+
+	if rule.Action == SuppressCoarsen && combined.Count >= rule.MinCount {
+		combined.AvgPay = combined.TotalPay / float64(combined.Count)
+		combined.RealAvgPay = combined.RealTotalPay / float64(combined.Count)
+		if combined.PrevCount > 0 {
+			combined.CountGrowth = percentChange(float64(combined.PrevCount), float64(combined.Count))
+		}
+		kept = append(kept, combined)
+	}
+
+	return kept
+}
+
+// suppressTitleCounts is suppressTitleStats for title counts
+func suppressTitleCounts(titles []models.TitleCount, rule models.SuppressionRule, suppressed map[string]int) []models.TitleCount {
+	if rule.MinCount == 0 {
+		return titles
+	}
+
+	kept := []models.TitleCount{}
+	combined := models.TitleCount{Title: CombinedCellTitle}
+	var totalPay, realTotalPay float64
+	for _, title := range titles {
+		if title.Count >= rule.MinCount {
+			kept = append(kept, title)
+			continue
+		}
+		suppressed[title.Title] = title.Count
+		combined.Count += title.Count
+		totalPay += title.AvgPay * float64(title.Count)
+		realTotalPay += title.RealAvgPay * float64(title.Count)
+	}
+
+	if rule.Action == SuppressCoarsen && combined.Count >= rule.MinCount {
+		combined.AvgPay = totalPay / float64(combined.Count)
+		combined.RealAvgPay = realTotalPay / float64(combined.Count)
+		kept = append(kept, combined)
+	}
+
+	return kept
+}
+
+// suppressLocationCounts applies a title counts rule to the locations a
+// title comparison lists below its threshold. Coarsened locations are
+// combined into one cell.
+func suppressLocationCounts(locations []models.LocationCount, rule models.SuppressionRule, suppressed map[string]int) []models.LocationCount {
+	if rule.MinCount == 0 {
+		return locations
+	}
+
+	var kept []models.LocationCount
+	combined := models.LocationCount{Location: CombinedCellTitle}
+	for _, location := range locations {
+		if location.Count >= rule.MinCount {
+			kept = append(kept, location)
+			continue
+		}
+		suppressed[location.Location] = location.Count
+		combined.Count += location.Count
+	}
+
+	if rule.Action == SuppressCoarsen && combined.Count >= rule.MinCount {
+		kept = append(kept, combined)
+	}
+
+	return kept
+}
+
+// suppressComponentGroups applies a title counts rule to the groups
+// receiving a pay component, by their number of recipients. Coarsened
+// groups are combined into the group called combinedName, which is added
+// if not listed and is itself dropped if still too small.
+func suppressComponentGroups(groups []models.ComponentGroup, rule models.SuppressionRule, combinedName string, suppressed map[string]int) []models.ComponentGroup {
+	if rule.MinCount == 0 {
+		return groups
+	}
+
+	kept := []models.ComponentGroup{}
+	combined := models.ComponentGroup{Name: combinedName}
+	for _, group := range groups {
+		if group.Recipients >= rule.MinCount {
+			kept = append(kept, group)
+			continue
+		}
+		suppressed[group.Name] = group.Recipients
+		combined.Employees += group.Employees
+		combined.Recipients += group.Recipients
+		combined.Total += group.Total
+		combined.Share += group.Share
+	}
+
+	if rule.Action != SuppressCoarsen || combined.Recipients == 0 {
+		return kept
+	}
+	for i := range kept {
+		if kept[i].Name == combinedName {
+			combined.Employees += kept[i].Employees
+			combined.Recipients += kept[i].Recipients
+			combined.Total += kept[i].Total
+			combined.Share += kept[i].Share
+			kept = append(kept[:i], kept[i+1:]...)
+			break
+		}
+	}
+	if combined.Recipients >= rule.MinCount {
+		combined.RecipientShare = safeRatio(float64(combined.Recipients), float64(combined.Employees)) * 100
+		combined.AvgPerRecipient = combined.Total / float64(combined.Recipients)
+		kept = append(kept, combined)
+	}
+
+	return kept
+}
+
+// suppressTitleRules applies a title counts rule to the titles of a
+// taxonomy report. Coarsened titles are combined into one row placed last,
+// with no classification or locations.
+func suppressTitleRules(titles []models.TitleRuleReport, rule models.SuppressionRule, suppressed map[string]int) []models.TitleRuleReport {
+	if rule.MinCount == 0 {
+		return titles
+	}
+
+	kept := []models.TitleRuleReport{}
+	combined := models.TitleRuleReport{TitleClassification: models.TitleClassification{Title: CombinedCellTitle}}
+	totalPay := 0.0
+	for _, title := range titles {
+		if title.Count >= rule.MinCount {
+			kept = append(kept, title)
+			continue
+		}
+		suppressed[title.Title] = title.Count
+		combined.Count += title.Count
+		totalPay += title.AvgPay * float64(title.Count)
+	}
+
+	if rule.Action == SuppressCoarsen && combined.Count >= rule.MinCount {
+		combined.AvgPay = totalPay / float64(combined.Count)
+		kept = append(kept, combined)
+	}
+
+	return kept
+}
+
+// suppressTitleFlows applies a title statistics rule to a list of title
+// flows. Coarsened titles are combined into one row placed last, with
+// exact counts and payroll effects but no medians.
+func suppressTitleFlows(flows []models.TitleFlow, rule models.SuppressionRule, suppressed map[string]int) []models.TitleFlow {
+	if rule.MinCount == 0 {
+		return flows
+	}
+
+	small := func(flow models.TitleFlow) bool {
+		return (flow.PreviousCount > 0 && flow.PreviousCount < rule.MinCount) ||
+			(flow.Count > 0 && flow.Count < rule.MinCount)
+	}
+
+	kept := []models.TitleFlow{}
+	combined := models.TitleFlow{Title: CombinedCellTitle}
+	for _, flow := range flows {
+		if !small(flow) {
+			kept = append(kept, flow)
+			continue
+		}
+		suppressed[flow.Title] = flow.Count
+		if flow.PreviousCount > flow.Count {
+			suppressed[flow.Title] = flow.PreviousCount
+		}
+		combined.PreviousCount += flow.PreviousCount
+		combined.Count += flow.Count
+		combined.Change += flow.Change
+		combined.PayrollChange += flow.PayrollChange
+		combined.HeadcountEffect += flow.HeadcountEffect
+		combined.PerCapitaEffect += flow.PerCapitaEffect
+	}
+
+	if rule.Action == SuppressCoarsen && combined.PreviousCount+combined.Count > 0 && !small(combined) {
+		kept = append(kept, combined)
+	}
+
+	return kept
+}
+
+// trimTrajectory narrows a trajectory to the points left after
+// suppression. Introduced and Retired are kept while their endpoint is,
+// since for the system they also depend on which locations hold the title;
+// otherwise they follow the points that remain, so a withheld first or last
+// year no longer shows.
+func trimTrajectory(trajectory *models.TitleTrajectory, points []models.TitleYearPoint, firstYear, lastYear int) {
+	if points[0].Year != trajectory.FirstYear {
+		trajectory.FirstYear = points[0].Year
+		trajectory.Introduced = trajectory.FirstYear > firstYear
+	}
+	if last := points[len(points)-1].Year; last != trajectory.LastYear {
+		trajectory.LastYear = last
+		trajectory.Retired = trajectory.LastYear < lastYear
+	}
+	trajectory.Years = len(points)
+	trajectory.Points = points
+	trajectory.MedianChange = pointGrowth(points)
+}
+
+// combinedTrajectory builds the trajectory of coarsened title points,
+// keeping the years whose combined point is large enough. Combined points
+// have exact counts and totals but no medians or p90.
+func combinedTrajectory(points map[int]*models.TitleYearPoint, minCount int) (models.TitleTrajectory, bool) {
+	var years []int
+	for year, point := range points {
+		if point.Count >= minCount {
+			years = append(years, year)
+		}
+	}
+	if len(years) == 0 {
+		return models.TitleTrajectory{}, false
+	}
+	sort.Ints(years)
+
+	trajectory := models.TitleTrajectory{
+		Title:     CombinedCellTitle,
+		FirstYear: years[0],
+		LastYear:  years[len(years)-1],
+		Years:     len(years),
+	}
+	for _, year := range years {
+		point := *points[year]
+		point.AvgPay = point.TotalPay / float64(point.Count)
+		if point.Count > trajectory.PeakCount {
+			trajectory.PeakCount = point.Count
+		}
+		trajectory.Points = append(trajectory.Points, point)
+	}
+	pointGrowth(trajectory.Points)
+
+	return trajectory, true
+}
+
+// categoryHistogram returns the index of a category's histogram, or -1
+func categoryHistogram(categories []models.CategoryHistogram, name string) int {
+	for i := range categories {
+		if categories[i].Category == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// suppressCategoryCounts applies a title counts rule to a bracket's
+// category mix. Coarsened categories are combined into the Other
+// category.
+func suppressCategoryCounts(categories []models.CategoryCount, rule models.SuppressionRule, bracketCount int) []models.CategoryCount {
+	if rule.MinCount == 0 || len(categories) == 0 {
+		return categories
+	}
+
+	counts := make([]models.TitleCount, len(categories))
+	for i, category := range categories {
+		counts[i] = models.TitleCount{Title: category.Category, Count: category.Count, AvgPay: category.AvgPay}
+	}
+	merged := mergeCategoryCounts(suppressTitleCounts(counts, rule, make(map[string]int)), bracketCount)
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// mergeCategoryCounts turns title counts back into category counts,
+// folding the combined row into the Other category and sorting as
+// getCategoryCounts does
+func mergeCategoryCounts(counts []models.TitleCount, bracketCount int) []models.CategoryCount {
+	byCategory := make(map[string]*models.CategoryCount)
+	for _, count := range counts {
+		name := count.Title
+		if name == CombinedCellTitle {
+			name = OtherCategory
+		}
+		category, exists := byCategory[name]
+		if !exists {
+			category = &models.CategoryCount{Category: name}
+			byCategory[name] = category
+		}
+		total := category.AvgPay*float64(category.Count) + count.AvgPay*float64(count.Count)
+		category.Count += count.Count
+		category.AvgPay = total / float64(category.Count)
+	}
+
+	var result []models.CategoryCount
+	for _, category := range byCategory {
+		category.Percentage = safeRatio(float64(category.Count), float64(bracketCount)) * 100
+		result = append(result, *category)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].Category < result[j].Category
+		}
+		return result[i].Count > result[j].Count
+	})
+	return result
+}
+
+// suppressBrackets withholds the pay of brackets with fewer than minCount
+// employees, keeping their counts. The pay of the withheld brackets
+// together could be recovered from the pyramid's total, so the next
+// smallest brackets are withheld too until at least two brackets and
+// minCount employees are withheld.
+func suppressBrackets(p *models.SuppressionPolicy, brackets []models.WageBracket, minCount int) {
+	withheld, employees := 0, 0
+	for i := range brackets {
+		if brackets[i].Count > 0 && brackets[i].Count < minCount {
+			recordSuppressed(p, CellsBrackets, SuppressDrop, 1, brackets[i].Count)
+			withholdBracket(&brackets[i], BracketSuppressed)
+			withheld++
+			employees += brackets[i].Count
+		}
+	}
+
+	for withheld > 0 && (withheld < 2 || employees < minCount) {
+		secondary := -1
+		for i := range brackets {
+			if brackets[i].Suppression != "" || brackets[i].Count == 0 {
+				continue
+			}
+			if secondary < 0 || brackets[i].Count < brackets[secondary].Count {
+				secondary = i
+			}
+		}
+		if secondary < 0 {
+			return
+		}
+		recordSuppressed(p, CellsBrackets, SuppressDrop, 1, brackets[secondary].Count)
+		withholdBracket(&brackets[secondary], BracketSecondary)
+		withheld++
+		employees += brackets[secondary].Count
+	}
+}
+
+// coarsenBrackets merges each bracket with fewer than minCount employees
+// into its lower neighbour (the upper one for the first bracket) until
+// every bracket is large enough. A lone bracket that is still too small
+// has its pay withheld.
+func coarsenBrackets(p *models.SuppressionPolicy, brackets []models.WageBracket, minCount int) []models.WageBracket {
+	for {
+		small := -1
+		for i := range brackets {
+			if brackets[i].Count > 0 && brackets[i].Count < minCount {
+				small = i
+				break
+			}
+		}
+		if small < 0 {
+			return brackets
+		}
+
+		if len(brackets) == 1 {
+			recordSuppressed(p, CellsBrackets, SuppressDrop, 1, brackets[0].Count)
+			withholdBracket(&brackets[0], BracketSuppressed)
+			return brackets
+		}
+
+		recordSuppressed(p, CellsBrackets, SuppressCoarsen, 1, brackets[small].Count)
+		lower := small - 1
+		if small == 0 {
+			lower = 0
+		}
+		merged := mergeBrackets(brackets[lower], brackets[lower+1])
+		brackets = append(brackets[:lower], append([]models.WageBracket{merged}, brackets[lower+2:]...)...)
+	}
+}
+
+// withholdBracket clears a bracket's pay, titles and categories
+func withholdBracket(bracket *models.WageBracket, marker string) {
+	*bracket = models.WageBracket{
+		Range:       bracket.Range,
+		MinValue:    bracket.MinValue,
+		MaxValue:    bracket.MaxValue,
+		OpenEnded:   bracket.OpenEnded,
+		Count:       bracket.Count,
+		Percentage:  bracket.Percentage,
+		TopTitles:   []models.TitleCount{},
+		Suppression: marker,
+	}
+}
+
+// mergeBrackets combines two adjacent brackets. Counts, totals and averages
+// are exact; the median is unknown and left zero, and the top titles are
+// merged from each bracket's own top titles.
+func mergeBrackets(lower, upper models.WageBracket) models.WageBracket {
+	count := lower.Count + upper.Count
+	merged := models.WageBracket{
+		MinValue:    lower.MinValue,
+		MaxValue:    upper.MaxValue,
+		OpenEnded:   upper.OpenEnded,
+		Count:       count,
+		Percentage:  lower.Percentage + upper.Percentage,
+		TotalPay:    lower.TotalPay + upper.TotalPay,
+		Suppression: BracketMerged,
+	}
+	if merged.OpenEnded {
+		merged.Range = formatBracketValue(merged.MinValue) + "+"
+	} else {
+		merged.Range = fmt.Sprintf("%s-%s", formatBracketValue(merged.MinValue), formatBracketValue(merged.MaxValue))
+	}
+	merged.AvgPay = safeRatio(merged.TotalPay, float64(count))
+
+	merged.PayComponents = mergePayComponents(lower.PayComponents, upper.PayComponents, count)
+	merged.OvertimeRecipientShare = safeRatio(lower.OvertimeRecipientShare*float64(lower.Count)+upper.OvertimeRecipientShare*float64(upper.Count), float64(count))
+	merged.AdjustmentRecipientShare = safeRatio(lower.AdjustmentRecipientShare*float64(lower.Count)+upper.AdjustmentRecipientShare*float64(upper.Count), float64(count))
+	merged.SupplementShare = safeRatio(merged.PayComponents.TotalOvertime+merged.PayComponents.TotalAdjustments, merged.TotalPay) * 100
+
+	limit := len(lower.TopTitles)
+	if len(upper.TopTitles) > limit {
+		limit = len(upper.TopTitles)
+	}
+	merged.TopTitles = mergeTitleCounts(append(append([]models.TitleCount(nil), lower.TopTitles...), upper.TopTitles...), limit)
+
+	var categories []models.TitleCount
+	for _, category := range append(append([]models.CategoryCount(nil), lower.Categories...), upper.Categories...) {
+		categories = append(categories, models.TitleCount{Title: category.Category, Count: category.Count, AvgPay: category.AvgPay})
+	}
+	if len(categories) > 0 {
+		merged.Categories = mergeCategoryCounts(categories, count)
+	}
+
+	if lower.RealPayComponents != nil && upper.RealPayComponents != nil {
+		merged.RealTotalPay = lower.RealTotalPay + upper.RealTotalPay
+		merged.RealAvgPay = safeRatio(merged.RealTotalPay, float64(count))
+		components := mergePayComponents(*lower.RealPayComponents, *upper.RealPayComponents, count)
+		merged.RealPayComponents = &components
+	}
+
+	return merged
+}
+
+// mergePayComponents adds two pay breakdowns and averages over count
+func mergePayComponents(a, b models.PayComponents, count int) models.PayComponents {
+	merged := models.PayComponents{
+		TotalBase:        a.TotalBase + b.TotalBase,
+		TotalOvertime:    a.TotalOvertime + b.TotalOvertime,
+		TotalAdjustments: a.TotalAdjustments + b.TotalAdjustments,
+	}
+	merged.AvgBase = safeRatio(merged.TotalBase, float64(count))
+	merged.AvgOvertime = safeRatio(merged.TotalOvertime, float64(count))
+	merged.AvgAdjustments = safeRatio(merged.TotalAdjustments, float64(count))
+	return merged
+}
+
+// mergeTitleCounts combines counts of the same title, most frequent first,
+// keeping the first limit
+func mergeTitleCounts(titles []models.TitleCount, limit int) []models.TitleCount {
+	byTitle := make(map[string]*models.TitleCount)
+	var order []string
+	for _, title := range titles {
+		existing, ok := byTitle[title.Title]
+		if !ok {
+			copied := title
+			byTitle[title.Title] = &copied
+			order = append(order, title.Title)
+			continue
+		}
+		count := float64(existing.Count + title.Count)
+		existing.AvgPay = (existing.AvgPay*float64(existing.Count) + title.AvgPay*float64(title.Count)) / count
+		existing.RealAvgPay = (existing.RealAvgPay*float64(existing.Count) + title.RealAvgPay*float64(title.Count)) / count
+		existing.Count += title.Count
+	}
+
+	merged := []models.TitleCount{}
+	for _, title := range order {
+		merged = append(merged, *byTitle[title])
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Count == merged[j].Count {
+			return merged[i].Title < merged[j].Title
+		}
+		return merged[i].Count > merged[j].Count
+	})
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
@@ -0,0 +1,433 @@
+package calculator
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// testBrackets builds brackets with the given counts, each paid count ×
+// $10,000 more than the last so every cell's pay differs
+func testBrackets(counts ...int) []models.WageBracket {
+	brackets := make([]models.WageBracket, len(counts))
+	for i, count := range counts {
+		brackets[i] = models.WageBracket{
+			Range:    fmt.Sprintf("%d", i),
+			MinValue: float64(i) * 10000,
+			MaxValue: float64(i+1) * 10000,
+			Count:    count,
+			TotalPay: float64(count) * (float64(i)*10000 + 5000),
+		}
+		brackets[i].AvgPay = safeRatio(brackets[i].TotalPay, float64(count))
+	}
+	return brackets
+}
+
+func bracketMarkers(brackets []models.WageBracket) []string {
+	markers := make([]string, len(brackets))
+	for i, bracket := range brackets {
+		markers[i] = bracket.Suppression
+	}
+	return markers
+}
+
+func TestSuppressBracketsComplementary(t *testing.T) {
+	const s, c = BracketSuppressed, BracketSecondary
+
+	tests := []struct {
+		name    string
+		counts  []int
+		markers []string
+	}{
+		{"nothing small", []int{20, 30, 40}, []string{"", "", ""}},
+		{"one small cell takes the smallest other", []int{40, 3, 20, 30}, []string{"", s, c, ""}},
+		{"two small cells under the minimum together", []int{1, 30, 2, 25}, []string{s, "", s, c}},
+		{"two small cells over the minimum together", []int{3, 30, 4, 25}, []string{s, "", s, ""}},
+		{"empty brackets are never withheld", []int{0, 2, 0, 50, 8}, []string{"", s, "", "", c}},
+		{"ties take the first bracket", []int{10, 1, 10}, []string{c, s, ""}},
+		{"runs out of brackets", []int{2, 0, 1}, []string{s, "", s}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &models.SuppressionPolicy{}
+			brackets := testBrackets(tt.counts...)
+			suppressBrackets(policy, brackets, 5)
+
+			if got := bracketMarkers(brackets); !reflect.DeepEqual(got, tt.markers) {
+				t.Fatalf("markers = %q, want %q", got, tt.markers)
+			}
+
+			withheld, employees := 0, 0
+			for i, bracket := range brackets {
+				if bracket.Count != tt.counts[i] {
+					t.Errorf("bracket %d count = %d, want %d kept", i, bracket.Count, tt.counts[i])
+				}
+				if bracket.Suppression == "" {
+					continue
+				}
+				if bracket.TotalPay != 0 || bracket.AvgPay != 0 || bracket.MedianPay != 0 {
+					t.Errorf("bracket %d still shows pay", i)
+				}
+				withheld++
+				employees += bracket.Count
+			}
+
+			// A lone withheld bracket's pay could be recovered from the total
+			if withheld == 1 {
+				t.Errorf("only one bracket withheld")
+			}
+
+			tallied := 0
+			for _, cells := range policy.Suppressed {
+				tallied += cells.Employees
+			}
+			if tallied != employees {
+				t.Errorf("tally covers %d employees, want %d", tallied, employees)
+			}
+		})
+	}
+}
+
+func TestCoarsenBrackets(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts []int
+		want   []int
+	}{
+		{"nothing small", []int{20, 30, 40}, []int{20, 30, 40}},
+		{"merges down", []int{20, 3, 40}, []int{23, 40}},
+		{"first merges up", []int{2, 30, 40}, []int{32, 40}},
+		{"chains until large enough", []int{1, 1, 1, 1, 1, 30}, []int{5, 30}},
+		{"lone small bracket", []int{1, 2}, []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brackets := testBrackets(tt.counts...)
+			total := 0.0
+			for _, bracket := range brackets {
+				total += bracket.TotalPay
+			}
+
+			merged := coarsenBrackets(&models.SuppressionPolicy{}, brackets, 5)
+
+			var counts []int
+			mergedTotal := 0.0
+			for _, bracket := range merged {
+				counts = append(counts, bracket.Count)
+				mergedTotal += bracket.TotalPay
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Fatalf("counts = %v, want %v", counts, tt.want)
+			}
+
+			// A lone bracket that is still small is withheld instead
+			if len(merged) == 1 && merged[0].Count < 5 {
+				if merged[0].Suppression != BracketSuppressed || merged[0].TotalPay != 0 {
+					t.Errorf("lone small bracket not withheld: %+v", merged[0])
+				}
+				return
+			}
+			if mergedTotal != total {
+				t.Errorf("total pay = %v, want %v", mergedTotal, total)
+			}
+			for i := 1; i < len(merged); i++ {
+				if merged[i].MinValue != merged[i-1].MaxValue {
+					t.Errorf("bracket %d starts at %v, want %v", i, merged[i].MinValue, merged[i-1].MaxValue)
+				}
+			}
+		})
+	}
+}
+
+func TestSuppressTitleFlows(t *testing.T) {
+	flows := []models.TitleFlow{
+		{Title: "A", PreviousCount: 40, Count: 50, Change: 10, PayrollChange: 100, Median: 60000},
+		{Title: "B", PreviousCount: 30, Count: 2, Change: -28, PayrollChange: 200, Median: 70000},
+		{Title: "C", Count: 3, Change: 3, PayrollChange: 300, Median: 80000},
+		{Title: "D", Count: 4, Change: 4, PayrollChange: 400, Median: 90000},
+		{Title: "E", Count: 12, Change: 12, PayrollChange: 500, Median: 50000},
+		{Title: "F", PreviousCount: 3, Count: 40, Change: 37, PayrollChange: 600, Median: 65000},
+	}
+
+	tests := []struct {
+		name   string
+		action string
+		titles []string
+	}{
+		{"suppress", SuppressDrop, []string{"A", "E"}},
+		{"coarsen", SuppressCoarsen, []string{"A", "E", CombinedCellTitle}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suppressed := make(map[string]int)
+			rule := models.SuppressionRule{MinCount: 5, Action: tt.action}
+			kept := suppressTitleFlows(append([]models.TitleFlow(nil), flows...), rule, suppressed)
+
+			var titles []string
+			for _, flow := range kept {
+				titles = append(titles, flow.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Fatalf("titles = %v, want %v", titles, tt.titles)
+			}
+			if want := map[string]int{"B": 30, "C": 3, "D": 4, "F": 40}; !reflect.DeepEqual(suppressed, want) {
+				t.Errorf("suppressed = %v, want %v", suppressed, want)
+			}
+
+			if tt.action == SuppressCoarsen {
+				combined := kept[len(kept)-1]
+				if combined.PreviousCount != 33 || combined.Count != 49 || combined.PayrollChange != 1500 {
+					t.Errorf("combined row = %+v", combined)
+				}
+				if combined.Median != 0 || combined.PreviousMedian != 0 {
+					t.Errorf("combined row shows medians: %+v", combined)
+				}
+			}
+		})
+	}
+}
+
+func TestApplySuppressionToHistogram(t *testing.T) {
+	newHistogram := func() *models.WageHistogram {
+		return &models.WageHistogram{
+			Count: 75,
+			Histogram: models.Histogram{
+				Scale:  ScaleLinear,
+				Edges:  []float64{0, 10000, 20000, 30000},
+				Counts: []int{30, 35, 8},
+			},
+			Categories: []models.CategoryHistogram{
+				{Category: "Student", Count: 45, Counts: []int{25, 20, 0}, KDE: []float64{1}},
+				{Category: "Academic", Count: 22, Counts: []int{3, 12, 5}, Overflow: 2, KDE: []float64{1}},
+				{Category: OtherCategory, Count: 8, Counts: []int{2, 3, 3}, KDE: []float64{1}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy string
+		counts map[string][]int
+	}{
+		{"suppress", "suppress", map[string][]int{
+			"Student":  {25, 20, 0},
+			"Academic": {0, 12, 5},
+		}},
+		{"coarsen", "coarsen", map[string][]int{
+			"Student":     {25, 20, 0},
+			"Academic":    {0, 12, 5},
+			OtherCategory: {5, 0, 0},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			histogram := newHistogram()
+			if err := ApplySuppressionToHistogram(histogram, GetSuppressionPolicies()[tt.policy]); err != nil {
+				t.Fatal(err)
+			}
+
+			counts := make(map[string][]int)
+			for _, category := range histogram.Categories {
+				counts[category.Category] = category.Counts
+
+				total := category.Overflow
+				for bin, count := range category.Counts {
+					total += count
+					if count > 0 && count < 5 {
+						t.Errorf("%s bin %d shows %d employees", category.Category, bin, count)
+					}
+				}
+				if category.Count != total {
+					t.Errorf("%s count = %d, want %d", category.Category, category.Count, total)
+				}
+				if category.Overflow > 0 && category.Overflow < 5 {
+					t.Errorf("%s overflow shows %d employees", category.Category, category.Overflow)
+				}
+				if changed := category.Category != "Student"; changed != (category.KDE == nil) {
+					t.Errorf("%s KDE kept = %v", category.Category, category.KDE != nil)
+				}
+			}
+			if !reflect.DeepEqual(counts, tt.counts) {
+				t.Errorf("counts = %v, want %v", counts, tt.counts)
+			}
+		})
+	}
+}
+
+func TestTitleComparisonsStrict(t *testing.T) {
+	// ANL 2 has 12, 15 and 6 employees across the locations
+	var datasets []*models.WageData
+	for location, count := range map[string]int{"Merced": 12, "Riverside": 15, "Santa Cruz": 6} {
+		data := &models.WageData{Location: location, Year: 2024}
+		for i := 0; i < count; i++ {
+			pay := fmt.Sprintf("%d", 50000+1000*i)
+			data.Records = append(data.Records, models.WageRecord{Title: "ANL 2", BasePay: pay, GrossPay: pay})
+		}
+		datasets = append(datasets, data)
+	}
+	strict := GetSuppressionPolicies()["strict"]
+
+	opts := TitleComparisonOptions{Grouping: GroupRaw, MinCount: 5}
+	report, err := CompareTitlesAcrossLocations(2024, datasets, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplySuppressionToTitleComparisons(report, strict); err == nil {
+		t.Errorf("applied strict to a comparison with a minimum count of 5")
+	}
+
+	opts.MinCount = ComparisonMinCount(opts.MinCount, strict)
+	report, err = CompareTitlesAcrossLocations(2024, datasets, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplySuppressionToTitleComparisons(report, strict); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.MinCount != 11 || len(report.Titles) != 1 {
+		t.Fatalf("min count %d with %d titles, want 11 with 1", report.MinCount, len(report.Titles))
+	}
+	comparison := report.Titles[0]
+	for _, location := range comparison.ByLocation {
+		if location.Count < 11 {
+			t.Errorf("%s publishes pay for %d employees", location.Location, location.Count)
+		}
+	}
+	if comparison.Count != 27 || comparison.Locations != 2 {
+		t.Errorf("pooled %d employees over %d locations, want 27 over 2", comparison.Count, comparison.Locations)
+	}
+	if len(comparison.BelowThreshold) != 0 {
+		t.Errorf("below threshold = %+v, want the 6-person location withheld", comparison.BelowThreshold)
+	}
+}
+
+func TestApplySuppressionToTrajectories(t *testing.T) {
+	points := func(counts map[int]int) []models.TitleYearPoint {
+		var result []models.TitleYearPoint
+		for year := 2020; year <= 2024; year++ {
+			if count, ok := counts[year]; ok {
+				result = append(result, models.TitleYearPoint{Year: year, Count: count, MedianPay: float64(50000 + 1000*count)})
+			}
+		}
+		return result
+	}
+
+	report := &models.TitleTrajectoryReport{
+		FirstYear: 2020,
+		LastYear:  2024,
+		Years:     []int{2020, 2021, 2022, 2023, 2024},
+		Titles: []models.TitleTrajectory{
+			// Small in its first and last years
+			{Title: "A", FirstYear: 2020, LastYear: 2023, Years: 4, Retired: true, PeakCount: 12,
+				Points: points(map[int]int{2020: 3, 2021: 10, 2022: 12, 2023: 2})},
+			// Not introduced in the system's shared coverage; its endpoints stay
+			{Title: "B", FirstYear: 2021, LastYear: 2024, Years: 4, PeakCount: 20,
+				Points: points(map[int]int{2021: 20, 2022: 20, 2023: 20, 2024: 20})},
+			// Small in a middle year only
+			{Title: "C", FirstYear: 2020, LastYear: 2024, Years: 5, PeakCount: 30,
+				Points: points(map[int]int{2020: 30, 2021: 30, 2022: 4, 2023: 30, 2024: 30})},
+		},
+		Introduced: 0,
+		Retired:    1,
+	}
+
+	if err := ApplySuppressionToTrajectories(report, GetSuppressionPolicies()["suppress"]); err != nil {
+		t.Fatal(err)
+	}
+
+	type span struct {
+		first, last, years  int
+		introduced, retired bool
+	}
+	want := map[string]span{
+		"A": {2021, 2022, 2, true, true},
+		"B": {2021, 2024, 4, false, false},
+		"C": {2020, 2024, 4, false, false},
+	}
+	for _, trajectory := range report.Titles {
+		got := span{trajectory.FirstYear, trajectory.LastYear, trajectory.Years, trajectory.Introduced, trajectory.Retired}
+		if got != want[trajectory.Title] {
+			t.Errorf("%s = %+v, want %+v", trajectory.Title, got, want[trajectory.Title])
+		}
+		if trajectory.Years != len(trajectory.Points) {
+			t.Errorf("%s has %d points for %d years", trajectory.Title, len(trajectory.Points), trajectory.Years)
+		}
+	}
+	if report.Introduced != 1 || report.Retired != 1 {
+		t.Errorf("introduced %d, retired %d; want 1 and 1", report.Introduced, report.Retired)
+	}
+}
+
+func TestApplySuppressionToSupplementalPay(t *testing.T) {
+	newAnalysis := func() *models.SupplementalPayAnalysis {
+		return &models.SupplementalPayAnalysis{
+			Overtime: models.ComponentConcentration{
+				Component: "overtime",
+				TopTitles: []models.ComponentGroup{
+					{Name: "NURSE 2", Employees: 40, Recipients: 30, Total: 300000, Share: 60},
+					{Name: "CHANCELLOR", Employees: 1, Recipients: 1, Total: 90000, Share: 18},
+					{Name: "POLICE OFCR", Employees: 4, Recipients: 4, Total: 80000, Share: 16},
+				},
+				TopCategories: []models.ComponentGroup{
+					{Name: "Health Care", Employees: 40, Recipients: 30, Total: 300000, Share: 60},
+					{Name: OtherCategory, Employees: 10, Recipients: 3, Total: 30000, Share: 6},
+					{Name: "Executive", Employees: 1, Recipients: 1, Total: 90000, Share: 18},
+				},
+			},
+		}
+	}
+
+	names := func(groups []models.ComponentGroup) []string {
+		var result []string
+		for _, group := range groups {
+			result = append(result, group.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name       string
+		policy     string
+		titles     []string
+		categories []string
+	}{
+		{"suppress", "suppress", []string{"NURSE 2"}, []string{"Health Care"}},
+		{"coarsen", "coarsen", []string{"NURSE 2", CombinedCellTitle}, []string{"Health Care"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := newAnalysis()
+			if err := ApplySuppressionToSupplementalPay(analysis, GetSuppressionPolicies()[tt.policy]); err != nil {
+				t.Fatal(err)
+			}
+
+			overtime := analysis.Overtime
+			if got := names(overtime.TopTitles); !reflect.DeepEqual(got, tt.titles) {
+				t.Errorf("titles = %v, want %v", got, tt.titles)
+			}
+			if got := names(overtime.TopCategories); !reflect.DeepEqual(got, tt.categories) {
+				t.Errorf("categories = %v, want %v", got, tt.categories)
+			}
+			for _, group := range append(overtime.TopTitles, overtime.TopCategories...) {
+				if group.Recipients < 5 {
+					t.Errorf("%s shows %d recipients", group.Name, group.Recipients)
+				}
+			}
+
+			if tt.policy == "coarsen" {
+				combined := overtime.TopTitles[len(overtime.TopTitles)-1]
+				if combined.Recipients != 5 || combined.Total != 170000 || combined.AvgPerRecipient != 34000 {
+					t.Errorf("combined row = %+v", combined)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package calculator
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// TitleTally accumulates occurrences of a title across files
+type TitleTally struct {
+	Count     int
+	TotalPay  float64
+	Locations map[string]bool
+}
+
+// TallyTitles adds every known, paid title in a file to tallies
+func TallyTitles(data *models.WageData, tallies map[string]*TitleTally) {
+	for _, record := range data.Records {
+		if !isKnownTitle(record.Title) {
+			continue
+		}
+
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
+		}
+
+		tally, exists := tallies[record.Title]
+		if !exists {
+			tally = &TitleTally{Locations: make(map[string]bool)}
+			tallies[record.Title] = tally
+		}
+		tally.Count++
+		tally.TotalPay += gross
+		tally.Locations[data.Location] = true
+	}
+}
+
+// ExplainTaxonomy classifies every tallied title and reports which rule
+// decided it, how often each rule fired, rules that never matched and
+// titles whose category was decided only by rule order
+func ExplainTaxonomy(tax *taxonomy.Taxonomy, tallies map[string]*TitleTally, files int) *models.TaxonomyReport {
+	report := &models.TaxonomyReport{
+		Taxonomy:     tax.Name(),
+		Version:      tax.Version(),
+		GeneratedAt:  time.Now(),
+		Files:        files,
+		UniqueTitles: len(tallies),
+		UnusedRules:  []string{},
+	}
+
+	usage := make(map[string]*models.RuleUsage)
+	for _, rule := range tax.Rules() {
+		usage[rule.ID] = &models.RuleUsage{
+			RuleID:   rule.ID,
+			Category: rule.Category,
+			Priority: rule.Priority,
+		}
+	}
+
+	categoryCounts := make(map[string]int)
+	categoryPay := make(map[string]float64)
+
+	for title, tally := range tallies {
+		classification := tax.Classify(title)
+
+		var locations []string
+		for location := range tally.Locations {
+			locations = append(locations, location)
+		}
+		sort.Strings(locations)
+
+		report.Titles = append(report.Titles, models.TitleRuleReport{
+			TitleClassification: *classification,
+			Count:               tally.Count,
+			AvgPay:              tally.TotalPay / float64(tally.Count),
+			Locations:           locations,
+		})
+		report.Records += tally.Count
+
+		if classification.Rule != nil {
+			rule := usage[classification.Rule.RuleID]
+			rule.Titles++
+			rule.Records += tally.Count
+		}
+		for _, overridden := range classification.Overridden {
+			usage[overridden.RuleID].Overridden++
+		}
+		if classification.Conflict {
+			report.Conflicts++
+		}
+
+		category := classification.Category
+		if len(classification.Path) > 1 {
+			category = strings.Join(classification.Path[:2], taxonomy.Separator)
+		}
+		categoryCounts[category] += tally.Count
+		categoryPay[category] += tally.TotalPay
+	}
+
+	// Conflicts first, then the most common titles
+	sort.Slice(report.Titles, func(i, j int) bool {
+		a, b := report.Titles[i], report.Titles[j]
+		if a.Conflict != b.Conflict {
+			return a.Conflict
+		}
+		if a.Count == b.Count {
+			return a.Title < b.Title
+		}
+		return a.Count > b.Count
+	})
+
+	for _, rule := range tax.Rules() {
+		report.Rules = append(report.Rules, *usage[rule.ID])
+		if usage[rule.ID].Titles == 0 {
+			report.UnusedRules = append(report.UnusedRules, rule.ID)
+		}
+	}
+
+	for category, count := range categoryCounts {
+		report.Categories = append(report.Categories, models.CategoryCount{
+			Category:   category,
+			Count:      count,
+			Percentage: float64(count) / float64(report.Records) * 100,
+			AvgPay:     categoryPay[category] / float64(count),
+		})
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		if report.Categories[i].Count == report.Categories[j].Count {
+			return report.Categories[i].Category < report.Categories[j].Category
+		}
+		return report.Categories[i].Count > report.Categories[j].Count
+	})
+
+	return report
+}
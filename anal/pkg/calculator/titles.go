@@ -1,17 +1,83 @@
 package calculator
 
 import (
+	"fmt"
 	"sort"
 	"time"
 
 	"github.com/montanaflynn/stats"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
 	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
 )
 
+// Title groupings for AnalyzeTitlesWithOptions
+const (
+	GroupRaw        = "raw"
+	GroupNormalized = "normalized"
+	GroupFamily     = "family"
+)
+
+// Title rankings for TitleOptions.RankBy
+const (
+	RankCount    = "count"
+	RankTotalPay = "total_pay"
+	RankMedian   = "median"
+	RankMean     = "mean"
+	RankMax      = "max"
+	RankGrowth   = "growth"
+)
+
+// TitleOptions controls how AnalyzeTitlesWithOptions groups and ranks
+// titles. Normalizer defaults to the bundled title dictionary. RankBy lists
+// the rankings to emit, each limited to TopN titles; the first fills
+// TopTitles and defaults to count. Titles with fewer than MinCount
+// employees are left out of every ranking. Growth ranks by headcount growth
+// over Previous, the same location's prior year, and skips titles absent
+// from it.
+type TitleOptions struct {
+	TopN       int
+	Grouping   string
+	Normalizer *taxonomy.Normalizer
+	RankBy     []string
+	MinCount   int
+	Previous   *models.WageData
+}
+
 // AnalyzeTitles generates comprehensive title statistics
 func AnalyzeTitles(data *models.WageData, topN int) (*models.TitleAnalysis, error) {
+	return AnalyzeTitlesWithOptions(data, TitleOptions{TopN: topN, Grouping: GroupRaw})
+}
+
+// AnalyzeTitlesWithOptions generates title statistics grouped by raw,
+// normalized or family title. Grouped titles list the raw titles merged
+// into them as variants.
+func AnalyzeTitlesWithOptions(data *models.WageData, opts TitleOptions) (*models.TitleAnalysis, error) {
+	if opts.Grouping == "" {
+		opts.Grouping = GroupRaw
+	}
+	if opts.Normalizer == nil {
+		opts.Normalizer = taxonomy.DefaultNormalizer()
+	}
+
+	if len(opts.RankBy) == 0 {
+		opts.RankBy = []string{RankCount}
+	}
+	for _, rankBy := range opts.RankBy {
+		if titleRankings[rankBy] == nil {
+			return nil, fmt.Errorf("unknown title ranking %q (want %s, %s, %s, %s, %s or %s)",
+				rankBy, RankCount, RankTotalPay, RankMedian, RankMean, RankMax, RankGrowth)
+		}
+	}
+
+	keyFor, err := titleGrouping(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	titleMap := make(map[string]*titleData)
+	keys := make(map[string]string)
+	topN := opts.TopN
 
 	// Aggregate data by title
 	for _, record := range data.Records {
@@ -24,14 +90,22 @@ func AnalyzeTitles(data *models.WageData, topN int) (*models.TitleAnalysis, erro
 			continue
 		}
 
-		if _, exists := titleMap[record.Title]; !exists {
-			titleMap[record.Title] = &titleData{
-				title: record.Title,
-				wages: []float64{},
+		key, ok := keys[record.Title]
+		if !ok {
+			key = keyFor(record.Title)
+			keys[record.Title] = key
+		}
+
+		if _, exists := titleMap[key]; !exists {
+			titleMap[key] = &titleData{
+				title:    key,
+				wages:    []float64{},
+				variants: make(map[string]bool),
 			}
 		}
 
-		titleMap[record.Title].wages = append(titleMap[record.Title].wages, gross)
+		titleMap[key].wages = append(titleMap[key].wages, gross)
+		titleMap[key].variants[record.Title] = true
 	}
 
 	// Calculate statistics for each title
@@ -61,22 +135,38 @@ func AnalyzeTitles(data *models.WageData, topN int) (*models.TitleAnalysis, erro
 			MaxPay:    maxPay,
 			StdDev:    stdDev,
 			TotalPay:  totalPay,
+			Variants:  data.variantList(opts.Grouping),
 		})
 	}
 
-	// Sort by count (most common titles first)
-	sort.Slice(titleStats, func(i, j int) bool {
-		// Primary sort by count, secondary by average pay
-		if titleStats[i].Count == titleStats[j].Count {
-			return titleStats[i].AvgPay > titleStats[j].AvgPay
+	// Compare against the previous year for growth
+	if opts.Previous != nil {
+		previous := previousTitles(opts.Previous, keyFor, keys)
+		for i := range titleStats {
+			prev, ok := previous[titleStats[i].Title]
+			if !ok {
+				continue
+			}
+			titleStats[i].PrevCount = prev.count
+			titleStats[i].CountGrowth = percentChange(float64(prev.count), float64(titleStats[i].Count))
+			titleStats[i].MedianGrowth = percentChange(prev.median, titleStats[i].MedianPay)
 		}
-		return titleStats[i].Count > titleStats[j].Count
-	})
+	}
 
-	// Limit to top N titles
-	topTitles := titleStats
-	if len(titleStats) > topN {
-		topTitles = titleStats[:topN]
+	// Drop titles below the headcount threshold from the rankings
+	var eligible []models.TitleStats
+	for _, title := range titleStats {
+		if title.Count >= opts.MinCount {
+			eligible = append(eligible, title)
+		}
+	}
+
+	rankings := make([]models.TitleRanking, len(opts.RankBy))
+	for i, rankBy := range opts.RankBy {
+		rankings[i] = models.TitleRanking{
+			By:     rankBy,
+			Titles: rankTitles(eligible, rankBy, topN),
+		}
 	}
 
 	analysis := &models.TitleAnalysis{
@@ -84,86 +174,142 @@ func AnalyzeTitles(data *models.WageData, topN int) (*models.TitleAnalysis, erro
 		Year:         data.Year,
 		GeneratedAt:  time.Now(),
 		UniqueTitles: len(titleMap),
-		TopTitles:    topTitles,
+		TopTitles:    rankings[0].Titles,
+		Grouping:     opts.Grouping,
+		RankedBy:     rankings[0].By,
+		MinCount:     opts.MinCount,
+		Rankings:     rankings[1:],
+	}
+	if opts.Grouping != GroupRaw {
+		analysis.Normalization = opts.Normalizer.Name() + "@" + opts.Normalizer.Version()
 	}
 
 	return analysis, nil
 }
 
-// CategorizeTitle attempts to categorize a job title
-func CategorizeTitle(title string) string {
-	// Convert to uppercase for comparison
-	upperTitle := title
+// titleRankings orders titles for each ranking, best first
+var titleRankings = map[string]func(a, b models.TitleStats) bool{
+	RankCount: func(a, b models.TitleStats) bool {
+		// Primary sort by count, secondary by average pay
+		if a.Count == b.Count {
+			return a.AvgPay > b.AvgPay
+		}
+		return a.Count > b.Count
+	},
+	RankTotalPay: func(a, b models.TitleStats) bool { return a.TotalPay > b.TotalPay },
+	RankMedian:   func(a, b models.TitleStats) bool { return a.MedianPay > b.MedianPay },
+	RankMean:     func(a, b models.TitleStats) bool { return a.AvgPay > b.AvgPay },
+	RankMax:      func(a, b models.TitleStats) bool { return a.MaxPay > b.MaxPay },
+	RankGrowth:   func(a, b models.TitleStats) bool { return a.CountGrowth > b.CountGrowth },
+}
 
-	// Academic titles
-	academicKeywords := []string{"PROF", "LECTURER", "INSTRUCTOR", "TEACHER", "DEAN", "CHAIR", "RESEARCHER", "POST DOC", "STUDENT"}
-	for _, keyword := range academicKeywords {
-		if contains(upperTitle, keyword) {
-			return "Academic"
+// rankTitles returns the top N titles for a ranking, breaking ties by
+// count and then title
+func rankTitles(titles []models.TitleStats, rankBy string, topN int) []models.TitleStats {
+	ranked := make([]models.TitleStats, 0, len(titles))
+	for _, title := range titles {
+		if rankBy == RankGrowth && title.PrevCount == 0 {
+			continue
 		}
+		ranked = append(ranked, title)
 	}
 
-	// Medical titles
-	medicalKeywords := []string{"PHYSICIAN", "NURSE", "DOCTOR", "SURGEON", "MEDICAL", "CLINICAL", "THERAPIST", "PHARMACY", "HEALTH"}
-	for _, keyword := range medicalKeywords {
-		if contains(upperTitle, keyword) {
-			return "Medical"
+	less := titleRankings[rankBy]
+	sort.Slice(ranked, func(i, j int) bool {
+		if less(ranked[i], ranked[j]) {
+			return true
 		}
+		if less(ranked[j], ranked[i]) {
+			return false
+		}
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Title < ranked[j].Title
+	})
+
+	// Limit to top N titles
+	if len(ranked) > topN {
+		ranked = ranked[:topN]
 	}
+	return ranked
+}
+
+// previousTitle is a title's headcount and median pay in the prior year
+type previousTitle struct {
+	count  int
+	median float64
+}
 
-	// Executive titles
-	execKeywords := []string{"PRESIDENT", "VICE PRESIDENT", "VP ", "CHIEF", "CEO", "CFO", "CTO", "DIRECTOR", "EXECUTIVE"}
-	for _, keyword := range execKeywords {
-		if contains(upperTitle, keyword) {
-			return "Executive"
+// previousTitles groups a prior year's records the same way as the
+// current year
+func previousTitles(data *models.WageData, keyFor func(string) string, keys map[string]string) map[string]previousTitle {
+	wages := make(map[string][]float64)
+	for _, record := range data.Records {
+		if !isKnownTitle(record.Title) {
+			continue
 		}
-	}
 
-	// IT titles
-	itKeywords := []string{"PROGRAMMER", "DEVELOPER", "ENGINEER", "ANALYST", "DATA", "IT ", "SOFTWARE", "SYSTEM", "NETWORK", "DATABASE"}
-	for _, keyword := range itKeywords {
-		if contains(upperTitle, keyword) {
-			return "IT/Technical"
+		_, _, _, gross := parser.ConvertRecordToFloat(record)
+		if gross <= 0 {
+			continue
 		}
-	}
 
-	// Administrative titles
-	adminKeywords := []string{"ADMIN", "ASSISTANT", "COORDINATOR", "MANAGER", "CLERK", "SECRETARY", "RECEPTIONIST", "OFFICE"}
-	for _, keyword := range adminKeywords {
-		if contains(upperTitle, keyword) {
-			return "Administrative"
+		key, ok := keys[record.Title]
+		if !ok {
+			key = keyFor(record.Title)
+			keys[record.Title] = key
 		}
+		wages[key] = append(wages[key], gross)
 	}
 
-	// Facilities/Operations
-	facilityKeywords := []string{"CUSTODIAN", "MAINTENANCE", "GROUNDS", "FACILITIES", "SECURITY", "POLICE", "PARKING", "UTILITY"}
-	for _, keyword := range facilityKeywords {
-		if contains(upperTitle, keyword) {
-			return "Facilities"
-		}
+	previous := make(map[string]previousTitle, len(wages))
+	for title, titleWages := range wages {
+		median, _ := stats.Median(titleWages)
+		previous[title] = previousTitle{count: len(titleWages), median: median}
 	}
+	return previous
+}
 
-	return "Other"
+// CategorizeTitle returns the top-level category of a job title using the
+// bundled taxonomy
+func CategorizeTitle(title string) string {
+	return taxonomy.Default().Category(title)
 }
 
-// titleData holds temporary data for title calculations
-type titleData struct {
-	title string
-	wages []float64
+// titleGrouping returns the function mapping raw titles to group keys
+func titleGrouping(opts TitleOptions) (func(string) string, error) {
+	normalizer := opts.Normalizer
+
+	switch opts.Grouping {
+	case GroupRaw:
+		return func(title string) string { return title }, nil
+	case GroupNormalized:
+		return func(title string) string { return normalizer.Normalize(title).Title }, nil
+	case GroupFamily:
+		return func(title string) string { return normalizer.Normalize(title).Family }, nil
+	default:
+		return nil, fmt.Errorf("unknown title grouping %q (want %s, %s or %s)", opts.Grouping, GroupRaw, GroupNormalized, GroupFamily)
+	}
 }
 
-// contains checks if a string contains a substring
-func contains(str, substr string) bool {
-	return len(str) >= len(substr) && str[:len(substr)] == substr ||
-		   len(str) > len(substr) && containsHelper(str[1:], substr)
+// titleData holds temporary data for title calculations
+type titleData struct {
+	title    string
+	wages    []float64
+	variants map[string]bool
 }
 
-func containsHelper(str, substr string) bool {
-	if len(str) < len(substr) {
-		return false
+// variantList returns the sorted raw titles merged into a group
+func (t *titleData) variantList(grouping string) []string {
+	if grouping == GroupRaw {
+		return nil
 	}
-	if str[:len(substr)] == substr {
-		return true
+
+	variants := make([]string, 0, len(t.variants))
+	for variant := range t.variants {
+		variants = append(variants, variant)
 	}
-	return containsHelper(str[1:], substr)
-}
\ No newline at end of file
+	sort.Strings(variants)
+	return variants
+}
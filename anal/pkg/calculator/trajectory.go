@@ -0,0 +1,450 @@
+package calculator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/parser"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/taxonomy"
+)
+
+// TitleTrajectoryOptions controls title trajectories. Rename candidates
+// need at least MinCount employees on both sides and headcount and median
+// pay within RenameTolerance of each other (0.2 means within 20%).
+type TitleTrajectoryOptions struct {
+	Grouping        string
+	Normalizer      *taxonomy.Normalizer
+	MinCount        int
+	RenameTolerance float64
+}
+
+// TitleYear holds per-title statistics for one year, keyed by location and
+// then title, along with the percentiles of each location's pay. All
+// locations combined are stored under SystemLocation.
+type TitleYear struct {
+	Year        int
+	Points      map[string]map[string]models.TitleYearPoint
+	Percentiles map[string]map[string]float64
+
+	// wages keeps each location's title wages so system-wide events can be
+	// compared over the locations two years share
+	wages map[string]map[string][]float64
+}
+
+// SummarizeTitleYear reduces one year of wage data to per-title statistics
+// at each location and system-wide
+func SummarizeTitleYear(year int, datasets []*models.WageData, opts TitleTrajectoryOptions) (*TitleYear, error) {
+	opts = titleTrajectoryDefaults(opts)
+
+	keyFor, err := titleGrouping(TitleOptions{Grouping: opts.Grouping, Normalizer: opts.Normalizer})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]string)
+	systemWages := make(map[string][]float64)
+	var allWages []float64
+	titleYear := &TitleYear{
+		Year:        year,
+		Points:      make(map[string]map[string]models.TitleYearPoint),
+		Percentiles: make(map[string]map[string]float64),
+		wages:       make(map[string]map[string][]float64),
+	}
+
+	for _, data := range datasets {
+		if data.Year != year {
+			continue
+		}
+
+		wages := make(map[string][]float64)
+		for _, record := range data.Records {
+			if !isKnownTitle(record.Title) {
+				continue
+			}
+
+			_, _, _, gross := parser.ConvertRecordToFloat(record)
+			if gross <= 0 {
+				continue
+			}
+
+			key, ok := keys[record.Title]
+			if !ok {
+				key = keyFor(record.Title)
+				keys[record.Title] = key
+			}
+			wages[key] = append(wages[key], gross)
+		}
+
+		if len(wages) == 0 {
+			continue
+		}
+
+		var locationWages []float64
+		points := make(map[string]models.TitleYearPoint, len(wages))
+		for title, titleWages := range wages {
+			points[title] = titleYearPoint(year, titleWages)
+			systemWages[title] = append(systemWages[title], titleWages...)
+			locationWages = append(locationWages, titleWages...)
+		}
+		titleYear.Points[data.Location] = points
+		titleYear.wages[data.Location] = wages
+		titleYear.Percentiles[data.Location] = payPercentiles(locationWages)
+		allWages = append(allWages, locationWages...)
+	}
+
+	if len(titleYear.Points) == 0 {
+		return nil, nil
+	}
+
+	system := make(map[string]models.TitleYearPoint, len(systemWages))
+	for title, wages := range systemWages {
+		system[title] = titleYearPoint(year, wages)
+	}
+	titleYear.Points[SystemLocation] = system
+	titleYear.Percentiles[SystemLocation] = payPercentiles(allWages)
+
+	return titleYear, nil
+}
+
+// payPercentiles sorts wages and returns their standard percentiles
+func payPercentiles(wages []float64) map[string]float64 {
+	sort.Float64s(wages)
+	percentiles := make(map[string]float64, len(PercentileValues))
+	for _, p := range PercentileValues {
+		percentiles[formatPercentileKey(p)] = percentileOf(wages, p)
+	}
+	return percentiles
+}
+
+// CalculateTitleTrajectories builds a trajectory report for every location
+// and one for all locations combined, flagging titles introduced or retired
+// within each location's years of data and likely renames between them.
+// System-wide events only compare the locations with data in both years, so
+// a location joining or leaving the data does not introduce or retire its
+// titles.
+func CalculateTitleTrajectories(years []*TitleYear, opts TitleTrajectoryOptions) ([]*models.TitleTrajectoryReport, error) {
+	opts = titleTrajectoryDefaults(opts)
+
+	sort.Slice(years, func(i, j int) bool {
+		return years[i].Year < years[j].Year
+	})
+	shared := newSharedYears(years)
+
+	// Collect each location's years and title points in year order
+	coverage := make(map[string][]int)
+	series := make(map[string]map[string][]models.TitleYearPoint)
+	for _, titleYear := range years {
+		for location, points := range titleYear.Points {
+			coverage[location] = append(coverage[location], titleYear.Year)
+			if _, exists := series[location]; !exists {
+				series[location] = make(map[string][]models.TitleYearPoint)
+			}
+			for title, point := range points {
+				series[location][title] = append(series[location][title], point)
+			}
+		}
+	}
+
+	var reports []*models.TitleTrajectoryReport
+	for location, titles := range series {
+		locationYears := coverage[location]
+		report := &models.TitleTrajectoryReport{
+			Location:    location,
+			GeneratedAt: time.Now(),
+			Grouping:    opts.Grouping,
+			FirstYear:   locationYears[0],
+			LastYear:    locationYears[len(locationYears)-1],
+			Years:       locationYears,
+			MinCount:    opts.MinCount,
+			Renames:     []models.TitleRename{},
+		}
+		if opts.Grouping != GroupRaw {
+			report.Normalization = opts.Normalizer.Name() + "@" + opts.Normalizer.Version()
+		}
+
+		endpoint := trajectoryEndpoint
+		if location == SystemLocation {
+			endpoint = shared.endpoint
+		}
+
+		for title, points := range titles {
+			trajectory := titleTrajectory(title, points, report.FirstYear, report.LastYear)
+			if location == SystemLocation {
+				trajectory.Introduced = trajectory.Introduced && shared.holds(title, trajectory.FirstYear, -1)
+				trajectory.Retired = trajectory.Retired && shared.holds(title, trajectory.LastYear, 1)
+			}
+			if trajectory.Introduced {
+				report.Introduced++
+			}
+			if trajectory.Retired {
+				report.Retired++
+			}
+			report.Titles = append(report.Titles, trajectory)
+		}
+
+		// Most widely held titles first
+		sort.Slice(report.Titles, func(i, j int) bool {
+			if report.Titles[i].PeakCount == report.Titles[j].PeakCount {
+				return report.Titles[i].Title < report.Titles[j].Title
+			}
+			return report.Titles[i].PeakCount > report.Titles[j].PeakCount
+		})
+
+		report.Renames = matchRenames(report.Titles, locationYears, endpoint, opts)
+		linkRenames(report)
+
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Location < reports[j].Location
+	})
+
+	return reports, nil
+}
+
+func titleTrajectoryDefaults(opts TitleTrajectoryOptions) TitleTrajectoryOptions {
+	if opts.Grouping == "" {
+		opts.Grouping = GroupRaw
+	}
+	if opts.Normalizer == nil {
+		opts.Normalizer = taxonomy.DefaultNormalizer()
+	}
+	if opts.MinCount < 1 {
+		opts.MinCount = 1
+	}
+	if opts.RenameTolerance <= 0 {
+		opts.RenameTolerance = 0.2
+	}
+	return opts
+}
+
+// titleYearPoint summarizes one title's wages for a year
+func titleYearPoint(year int, wages []float64) models.TitleYearPoint {
+	sort.Float64s(wages)
+	avgPay, _ := stats.Mean(wages)
+	medianPay, _ := stats.Median(wages)
+
+	return models.TitleYearPoint{
+		Year:      year,
+		Count:     len(wages),
+		AvgPay:    avgPay,
+		MedianPay: medianPay,
+		P90Pay:    percentileOf(wages, 90),
+		TotalPay:  sumFloat64(wages),
+	}
+}
+
+// titleTrajectory fills in growth for a title's points and flags whether it
+// appeared after firstYear or disappeared before lastYear
+func titleTrajectory(title string, points []models.TitleYearPoint, firstYear, lastYear int) models.TitleTrajectory {
+	trajectory := models.TitleTrajectory{
+		Title:     title,
+		FirstYear: points[0].Year,
+		LastYear:  points[len(points)-1].Year,
+		Years:     len(points),
+	}
+	trajectory.Introduced = trajectory.FirstYear > firstYear
+	trajectory.Retired = trajectory.LastYear < lastYear
+
+	for i := range points {
+		if points[i].Count > trajectory.PeakCount {
+			trajectory.PeakCount = points[i].Count
+		}
+	}
+
+	trajectory.MedianChange = pointGrowth(points)
+	trajectory.Points = points
+
+	return trajectory
+}
+
+// pointGrowth fills in each point's growth from the point before it and
+// returns the percent change in median pay from the first point to the last
+func pointGrowth(points []models.TitleYearPoint) float64 {
+	for i := range points {
+		points[i].CountGrowth, points[i].MedianGrowth = 0, 0
+		if i > 0 {
+			points[i].CountGrowth = percentChange(float64(points[i-1].Count), float64(points[i].Count))
+			points[i].MedianGrowth = percentChange(points[i-1].MedianPay, points[i].MedianPay)
+		}
+	}
+
+	return percentChange(points[0].MedianPay, points[len(points)-1].MedianPay)
+}
+
+// renameEndpoint returns a title's point in a year when comparing it with
+// another year, and whether the title is held there
+type renameEndpoint func(trajectory *models.TitleTrajectory, year, otherYear int) (models.TitleYearPoint, bool)
+
+// trajectoryEndpoint is a location's own point for a title's first or last
+// year
+func trajectoryEndpoint(trajectory *models.TitleTrajectory, year, _ int) (models.TitleYearPoint, bool) {
+	if trajectory.FirstYear == year {
+		return trajectory.Points[0], true
+	}
+	return trajectory.Points[len(trajectory.Points)-1], true
+}
+
+// sharedYears compares adjacent years of the combined series over the
+// locations with data in both
+type sharedYears struct {
+	byYear map[int]*TitleYear
+	years  []int
+}
+
+func newSharedYears(years []*TitleYear) sharedYears {
+	shared := sharedYears{byYear: make(map[int]*TitleYear, len(years))}
+	for _, titleYear := range years {
+		shared.byYear[titleYear.Year] = titleYear
+		shared.years = append(shared.years, titleYear.Year)
+	}
+	return shared
+}
+
+// holds reports whether a title is held in a year at the locations that
+// also have data in the previous (step -1) or next (step 1) year
+func (s sharedYears) holds(title string, year, step int) bool {
+	i := sort.SearchInts(s.years, year) + step
+	if i < 0 || i >= len(s.years) {
+		return false
+	}
+	_, ok := s.endpoint(&models.TitleTrajectory{Title: title}, year, s.years[i])
+	return ok
+}
+
+// endpoint summarizes a title's wages in year at the locations that also
+// have data in otherYear
+func (s sharedYears) endpoint(trajectory *models.TitleTrajectory, year, otherYear int) (models.TitleYearPoint, bool) {
+	this, other := s.byYear[year], s.byYear[otherYear]
+	if this == nil || other == nil {
+		return models.TitleYearPoint{}, false
+	}
+
+	var wages []float64
+	for location, titles := range this.wages {
+		if _, ok := other.wages[location]; ok {
+			wages = append(wages, titles[trajectory.Title]...)
+		}
+	}
+	if len(wages) == 0 {
+		return models.TitleYearPoint{}, false
+	}
+	return titleYearPoint(year, wages), true
+}
+
+// matchRenames pairs titles retired after one year of data with titles
+// introduced in the next, best match first, using each title at most once.
+// Headcount and median pay come from the endpoint for each year.
+func matchRenames(titles []models.TitleTrajectory, years []int, endpoint renameEndpoint, opts TitleTrajectoryOptions) []models.TitleRename {
+	nextYear := make(map[int]int)
+	for i := 1; i < len(years); i++ {
+		nextYear[years[i-1]] = years[i]
+	}
+
+	retiredBy := make(map[int][]*models.TitleTrajectory)
+	introducedIn := make(map[int][]*models.TitleTrajectory)
+	for i := range titles {
+		trajectory := &titles[i]
+		if trajectory.Retired {
+			retiredBy[trajectory.LastYear] = append(retiredBy[trajectory.LastYear], trajectory)
+		}
+		if trajectory.Introduced {
+			introducedIn[trajectory.FirstYear] = append(introducedIn[trajectory.FirstYear], trajectory)
+		}
+	}
+
+	renames := []models.TitleRename{}
+	for _, lastYear := range years {
+		firstYear, ok := nextYear[lastYear]
+		if !ok {
+			continue
+		}
+
+		var candidates []models.TitleRename
+		for _, from := range retiredBy[lastYear] {
+			last, ok := endpoint(from, lastYear, firstYear)
+			if !ok || last.Count < opts.MinCount {
+				continue
+			}
+
+			for _, to := range introducedIn[firstYear] {
+				first, ok := endpoint(to, firstYear, lastYear)
+				if !ok || first.Count < opts.MinCount {
+					continue
+				}
+
+				countSimilarity := similarity(float64(last.Count), float64(first.Count))
+				paySimilarity := similarity(last.MedianPay, first.MedianPay)
+				if countSimilarity < 1-opts.RenameTolerance || paySimilarity < 1-opts.RenameTolerance {
+					continue
+				}
+
+				candidates = append(candidates, models.TitleRename{
+					From:            from.Title,
+					To:              to.Title,
+					LastYear:        lastYear,
+					FirstYear:       firstYear,
+					FromCount:       last.Count,
+					ToCount:         first.Count,
+					FromMedianPay:   last.MedianPay,
+					ToMedianPay:     first.MedianPay,
+					CountSimilarity: countSimilarity,
+					PaySimilarity:   paySimilarity,
+					Score:           countSimilarity * paySimilarity,
+				})
+			}
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].Score == candidates[j].Score {
+				if candidates[i].From == candidates[j].From {
+					return candidates[i].To < candidates[j].To
+				}
+				return candidates[i].From < candidates[j].From
+			}
+			return candidates[i].Score > candidates[j].Score
+		})
+
+		matchedFrom := make(map[string]bool)
+		matchedTo := make(map[string]bool)
+		for _, candidate := range candidates {
+			if matchedFrom[candidate.From] || matchedTo[candidate.To] {
+				continue
+			}
+			matchedFrom[candidate.From] = true
+			matchedTo[candidate.To] = true
+			renames = append(renames, candidate)
+		}
+	}
+
+	return renames
+}
+
+// linkRenames records matched renames on both titles' trajectories
+func linkRenames(report *models.TitleTrajectoryReport) {
+	index := make(map[string]int, len(report.Titles))
+	for i, trajectory := range report.Titles {
+		index[trajectory.Title] = i
+	}
+
+	for _, rename := range report.Renames {
+		from := &report.Titles[index[rename.From]]
+		from.RenamedTo = append(from.RenamedTo, rename.To)
+		to := &report.Titles[index[rename.To]]
+		to.RenamedFrom = append(to.RenamedFrom, rename.From)
+	}
+}
+
+// similarity returns the smaller of two positive values over the larger
+func similarity(a, b float64) float64 {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	if a > b {
+		return b / a
+	}
+	return a / b
+}
@@ -0,0 +1,100 @@
+package calculator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/inflation"
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// CalculateTrends builds per-location time series from yearly summaries.
+// The adjuster is optional; when set, real-dollar fields are filled in.
+func CalculateTrends(summaries []*models.Summary, adjuster *inflation.Adjuster) ([]*models.Trend, error) {
+	byLocation := make(map[string][]*models.Summary)
+	for _, summary := range summaries {
+		byLocation[summary.Location] = append(byLocation[summary.Location], summary)
+	}
+
+	var trends []*models.Trend
+
+	for location, series := range byLocation {
+		sort.Slice(series, func(i, j int) bool {
+			return series[i].Year < series[j].Year
+		})
+
+		trend := &models.Trend{
+			Location:    location,
+			GeneratedAt: time.Now(),
+			FirstYear:   series[0].Year,
+			LastYear:    series[len(series)-1].Year,
+			Points:      []models.TrendPoint{},
+		}
+
+		if adjuster != nil {
+			cpi := adjuster.SeriesFor(location)
+			trend.Inflation = &models.InflationAdjustment{
+				Series:   cpi.ID,
+				Version:  cpi.Version,
+				BaseYear: adjuster.BaseYear(),
+			}
+		}
+
+		for i, summary := range series {
+			point := models.TrendPoint{
+				Year:          summary.Year,
+				EmployeeCount: summary.EmployeeCount,
+				TotalGrossPay: summary.TotalGrossPay,
+				AvgGrossPay:   summary.AvgGrossPay,
+				MedianPay:     summary.MedianPay,
+			}
+
+			if summary.TotalGrossPay > 0 {
+				point.OvertimeShare = summary.PayComponents.TotalOvertime / summary.TotalGrossPay * 100
+			}
+
+			if adjuster != nil {
+				factor, err := adjuster.Factor(location, summary.Year)
+				if err != nil {
+					return nil, err
+				}
+				point.CPIFactor = factor
+				point.RealTotalGrossPay = summary.TotalGrossPay * factor
+				point.RealAvgGrossPay = summary.AvgGrossPay * factor
+				point.RealMedianPay = summary.MedianPay * factor
+			}
+
+			if i > 0 {
+				prev := trend.Points[i-1]
+				point.EmployeeGrowth = percentChange(float64(prev.EmployeeCount), float64(point.EmployeeCount))
+				point.PayrollGrowth = percentChange(prev.TotalGrossPay, point.TotalGrossPay)
+				point.AvgPayGrowth = percentChange(prev.AvgGrossPay, point.AvgGrossPay)
+				point.MedianGrowth = percentChange(prev.MedianPay, point.MedianPay)
+
+				if adjuster != nil {
+					point.RealPayrollGrowth = percentChange(prev.RealTotalGrossPay, point.RealTotalGrossPay)
+					point.RealAvgPayGrowth = percentChange(prev.RealAvgGrossPay, point.RealAvgGrossPay)
+					point.RealMedianGrowth = percentChange(prev.RealMedianPay, point.RealMedianPay)
+				}
+			}
+
+			trend.Points = append(trend.Points, point)
+		}
+
+		trends = append(trends, trend)
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		return trends[i].Location < trends[j].Location
+	})
+
+	return trends, nil
+}
+
+// percentChange returns the percent change from old to new
+func percentChange(old, new float64) float64 {
+	if old == 0 {
+		return 0
+	}
+	return (new - old) / old * 100
+}
@@ -0,0 +1,209 @@
+package inflation
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// SchemeRegional selects a CPI series per location instead of a single series
+const SchemeRegional = "regional"
+
+//go:embed data/*.json
+var bundledSeries embed.FS
+
+// Series is a versioned table of annual CPI index values
+type Series struct {
+	ID      string          `json:"id"`
+	Name    string          `json:"name"`
+	Version string          `json:"version"`
+	Source  string          `json:"source"`
+	Base    string          `json:"base"`
+	Values  map[int]float64 `json:"values"`
+}
+
+// regionalSeries maps locations to the closest regional CPI series.
+// Locations not listed here fall back to the statewide California series.
+var regionalSeries = map[string]string{
+	"Berkeley":      "sf-bay",
+	"San Francisco": "sf-bay",
+	"Santa Cruz":    "sf-bay",
+	"UCOP":          "sf-bay",
+	"UC SF Law":     "sf-bay",
+	"Los Angeles":   "los-angeles",
+	"ASUCLA":        "los-angeles",
+	"Irvine":        "los-angeles",
+	"Riverside":     "los-angeles",
+}
+
+// BundledSeries returns all CPI series shipped with the package
+func BundledSeries() ([]*Series, error) {
+	entries, err := bundledSeries.ReadDir("data")
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundled CPI data: %w", err)
+	}
+
+	var series []*Series
+	for _, entry := range entries {
+		raw, err := bundledSeries.ReadFile("data/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("error reading bundled CPI file %s: %w", entry.Name(), err)
+		}
+
+		s, err := decodeSeries(raw, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, s)
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].ID < series[j].ID
+	})
+
+	return series, nil
+}
+
+// LoadSeries returns the bundled CPI series with the given id
+func LoadSeries(id string) (*Series, error) {
+	series, err := BundledSeries()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range series {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown CPI series %q", id)
+}
+
+// LoadSeriesFile loads a CPI series from a JSON file on disk
+func LoadSeriesFile(filepath string) (*Series, error) {
+	raw, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening CPI file %s: %w", filepath, err)
+	}
+
+	return decodeSeries(raw, filepath)
+}
+
+func decodeSeries(raw []byte, name string) (*Series, error) {
+	var s Series
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("error decoding CPI series from %s: %w", name, err)
+	}
+
+	if s.ID == "" || len(s.Values) == 0 {
+		return nil, fmt.Errorf("CPI series in %s is missing an id or values", name)
+	}
+
+	return &s, nil
+}
+
+// Adjuster converts nominal dollars into real dollars of a base year
+type Adjuster struct {
+	scheme   string
+	baseYear int
+	series   map[string]*Series
+}
+
+// NewAdjuster creates an adjuster for a bundled series id, a path to a
+// series JSON file, or SchemeRegional for per-location series
+func NewAdjuster(scheme string, baseYear int) (*Adjuster, error) {
+	adjuster := &Adjuster{
+		scheme:   scheme,
+		baseYear: baseYear,
+		series:   make(map[string]*Series),
+	}
+
+	switch {
+	case scheme == SchemeRegional:
+		ids := []string{"california"}
+		for _, id := range regionalSeries {
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			s, err := LoadSeries(id)
+			if err != nil {
+				return nil, err
+			}
+			adjuster.series[s.ID] = s
+		}
+	case strings.HasSuffix(scheme, ".json"):
+		s, err := LoadSeriesFile(scheme)
+		if err != nil {
+			return nil, err
+		}
+		adjuster.series[s.ID] = s
+		adjuster.scheme = s.ID
+	default:
+		s, err := LoadSeries(scheme)
+		if err != nil {
+			return nil, err
+		}
+		adjuster.series[s.ID] = s
+	}
+
+	// Every series must cover the base year
+	for _, s := range adjuster.series {
+		if _, ok := s.Values[baseYear]; !ok {
+			return nil, fmt.Errorf("CPI series %q has no value for base year %d", s.ID, baseYear)
+		}
+	}
+
+	return adjuster, nil
+}
+
+// BaseYear returns the year whose dollars real values are expressed in
+func (a *Adjuster) BaseYear() int {
+	return a.baseYear
+}
+
+// SeriesFor returns the CPI series used for a location
+func (a *Adjuster) SeriesFor(location string) *Series {
+	if a.scheme != SchemeRegional {
+		return a.series[a.scheme]
+	}
+
+	if id, ok := regionalSeries[location]; ok {
+		return a.series[id]
+	}
+	return a.series["california"]
+}
+
+// Factor returns the multiplier converting a location-year's nominal
+// dollars into base-year dollars
+func (a *Adjuster) Factor(location string, year int) (float64, error) {
+	series := a.SeriesFor(location)
+
+	index, ok := series.Values[year]
+	if !ok || index == 0 {
+		return 0, fmt.Errorf("CPI series %q has no value for %d", series.ID, year)
+	}
+
+	return series.Values[a.baseYear] / index, nil
+}
+
+// Describe returns the adjustment metadata recorded in analysis outputs
+func (a *Adjuster) Describe(location string, year int) (*models.InflationAdjustment, error) {
+	factor, err := a.Factor(location, year)
+	if err != nil {
+		return nil, err
+	}
+
+	series := a.SeriesFor(location)
+	return &models.InflationAdjustment{
+		Series:   series.ID,
+		Version:  series.Version,
+		BaseYear: a.baseYear,
+		Factor:   factor,
+	}, nil
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/sketch"
+)
 
 // WageRecord represents a single employee wage record
 type WageRecord struct {
@@ -25,6 +29,14 @@ type WageData struct {
 	Records       []WageRecord `json:"records"`
 }
 
+// LocationGroup defines a set of locations aggregated into one
+// pseudo-location. An empty Include list means every location.
+type LocationGroup struct {
+	Name    string   `json:"name"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
 // PayComponents breakdown of pay types
 type PayComponents struct {
 	TotalBase        float64 `json:"total_base"`
@@ -49,45 +61,284 @@ type Summary struct {
 	MaxPay         float64           `json:"max_pay"`
 	Percentiles    map[string]float64 `json:"percentiles"`
 	PayComponents  PayComponents     `json:"pay_components"`
+	Gini           float64           `json:"gini"`
+	Skewness       float64           `json:"skewness"`
+	Kurtosis       float64           `json:"kurtosis"`
+	Locations      []string          `json:"locations,omitempty"`
+	Population     *PopulationFilter `json:"population,omitempty"`
+
+	// Sketch is a mergeable quantile digest of gross pay. Approximate is set
+	// when percentiles were estimated by merging sketches.
+	Sketch      *sketch.TDigest `json:"sketch,omitempty"`
+	Approximate bool            `json:"approximate,omitempty"`
+
+	// Bootstrap confidence intervals, present when resampling was requested
+	Bootstrap *SummaryBootstrap `json:"bootstrap,omitempty"`
+
+	// Privacy describes the noise added when released privately
+	Privacy *PrivacyRelease `json:"privacy,omitempty"`
+
+	// Real-dollar values, present when a CPI adjustment was applied
+	Inflation         *InflationAdjustment `json:"inflation,omitempty"`
+	RealTotalGrossPay float64              `json:"real_total_gross_pay,omitempty"`
+	RealAvgGrossPay   float64              `json:"real_avg_gross_pay,omitempty"`
+	RealMedianPay     float64              `json:"real_median_gross_pay,omitempty"`
+	RealStdDev        float64              `json:"real_std_dev,omitempty"`
+	RealMinPay        float64              `json:"real_min_pay,omitempty"`
+	RealMaxPay        float64              `json:"real_max_pay,omitempty"`
+	RealPercentiles   map[string]float64   `json:"real_percentiles,omitempty"`
+	RealPayComponents *PayComponents       `json:"real_pay_components,omitempty"`
+}
+
+// PopulationFilter selects which paid records an analysis covers. Records
+// need positive gross pay plus at least MinGrossPay and MinBasePay, must not
+// fall in ExcludeCategories of the taxonomy, and when MinFTE is set must
+// have an estimated FTE of at least MinFTE. Estimated FTE is base pay over a
+// full-time reference: the FTEPercentile of base pay for the record's title
+// ("title" basis, for titles with at least FTEMinTitleCount employees) or
+// FullTimeBasePay ("fixed" basis, and the fallback for small titles). In
+// output the filter also records how many records it kept and excluded.
+type PopulationFilter struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description,omitempty"`
+	MinGrossPay       float64  `json:"min_gross_pay,omitempty"`
+	MinBasePay        float64  `json:"min_base_pay,omitempty"`
+	ExcludeCategories []string `json:"exclude_categories,omitempty"`
+	Taxonomy          string   `json:"taxonomy,omitempty"`
+	MinFTE            float64  `json:"min_fte,omitempty"`
+	FTEBasis          string   `json:"fte_basis,omitempty"`
+	FTEPercentile     float64  `json:"fte_percentile,omitempty"`
+	FTEMinTitleCount  int      `json:"fte_min_title_count,omitempty"`
+	FullTimeBasePay   float64  `json:"full_time_base_pay,omitempty"`
+
+	// Counts from applying the filter. ExcludedBy counts each excluded
+	// record under the first criterion it failed.
+	Records    int            `json:"records,omitempty"`
+	Kept       int            `json:"kept,omitempty"`
+	Excluded   int            `json:"excluded,omitempty"`
+	ExcludedBy map[string]int `json:"excluded_by,omitempty"`
+}
+
+// PrivacyRelease records the differential privacy noise behind an output.
+// Each payroll record is protected (Unit), pay is clipped to PayBound
+// before summing (titles to the smaller of it and their category's bound
+// in CategoryBounds), and the output's Epsilon and Delta are split across
+// the noisy Queries. Budget is the total epsilon of the run the output
+// belongs to.
+type PrivacyRelease struct {
+	Epsilon        float64            `json:"epsilon"`
+	Delta          float64            `json:"delta,omitempty"`
+	Unit           string             `json:"unit"`
+	PayBound       float64            `json:"pay_bound"`
+	CategoryBounds map[string]float64 `json:"category_bounds,omitempty"`
+	Budget         float64            `json:"budget,omitempty"`
+	Queries        []NoisyQuery       `json:"queries"`
+}
+
+// NoisyQuery is one statistic released with noise. Laplace noise has
+// Scale sensitivity/epsilon; the exponential mechanism picks percentiles
+// by rank. Lists whose members come from the data (titles) only include
+// members whose noisy count reaches Threshold. Withheld counts the values
+// left zero because their noise scale exceeded them.
+type NoisyQuery struct {
+	Statistic   string  `json:"statistic"`
+	Mechanism   string  `json:"mechanism"`
+	Epsilon     float64 `json:"epsilon"`
+	Sensitivity float64 `json:"sensitivity"`
+	Scale       float64 `json:"scale,omitempty"`
+	Threshold   float64 `json:"threshold,omitempty"`
+	Withheld    int     `json:"withheld,omitempty"`
+}
+
+// PrivacyBudget is the ledger of a run's differential privacy budget,
+// shared by the commands that release noisy outputs. Each command charges
+// its epsilon and delta before releasing anything.
+type PrivacyBudget struct {
+	Epsilon    float64         `json:"epsilon"`
+	Delta      float64         `json:"delta"`
+	Spent      float64         `json:"spent"`
+	SpentDelta float64         `json:"spent_delta"`
+	Charges    []PrivacyCharge `json:"charges"`
+}
+
+// PrivacyCharge is one command's spend against a PrivacyBudget
+type PrivacyCharge struct {
+	Command   string    `json:"command"`
+	Epsilon   float64   `json:"epsilon"`
+	Delta     float64   `json:"delta,omitempty"`
+	ChargedAt time.Time `json:"charged_at"`
+}
+
+// SuppressionRule sets the smallest cell a published output may show.
+// Cells covering fewer than MinCount employees are either dropped
+// ("suppress") or combined with other cells ("coarsen"). A MinCount of
+// zero leaves the cells as they are.
+type SuppressionRule struct {
+	MinCount int    `json:"min_count,omitempty"`
+	Action   string `json:"action,omitempty"`
+}
+
+// SuppressionPolicy applies small-cell rules before publishing: TitleStats
+// to per-title statistics, trajectories and flows, TitleCounts to title,
+// location and category counts, and Brackets to pyramid brackets. In output the policy also
+// lists what each rule suppressed.
+type SuppressionPolicy struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	TitleStats  SuppressionRule `json:"title_stats"`
+	TitleCounts SuppressionRule `json:"title_counts"`
+	Brackets    SuppressionRule `json:"brackets"`
+
+	Suppressed []SuppressedCells `json:"suppressed,omitempty"`
+}
+
+// SuppressedCells counts the cells of one kind that a rule removed or
+// combined, and the employees they covered. A cell dropped from several
+// lists of the same output is counted once.
+type SuppressedCells struct {
+	Cells     string `json:"cells"`
+	Action    string `json:"action"`
+	Count     int    `json:"count"`
+	Employees int    `json:"employees"`
+}
+
+// ConfidenceInterval bounds an estimate. StdError is the standard
+// deviation of the estimate across bootstrap resamples.
+type ConfidenceInterval struct {
+	Lower    float64 `json:"lower"`
+	Upper    float64 `json:"upper"`
+	StdError float64 `json:"std_error"`
+}
+
+// SummaryBootstrap holds percentile bootstrap confidence intervals for a
+// summary's statistics. Samples resamples were drawn with replacement using
+// a random source seeded with Seed, so the intervals can be reproduced.
+type SummaryBootstrap struct {
+	Samples     int                           `json:"samples"`
+	Confidence  float64                       `json:"confidence"`
+	Seed        int64                         `json:"seed"`
+	Method      string                        `json:"method"`
+	Mean        ConfidenceInterval            `json:"mean"`
+	Median      ConfidenceInterval            `json:"median"`
+	Gini        ConfidenceInterval            `json:"gini"`
+	Percentiles map[string]ConfidenceInterval `json:"percentiles"`
+}
+
+// InflationAdjustment records the CPI conversion behind real-dollar fields
+type InflationAdjustment struct {
+	Series   string  `json:"series"`
+	Version  string  `json:"version"`
+	BaseYear int     `json:"base_year"`
+	Factor   float64 `json:"factor,omitempty"`
 }
 
 // TitleCount represents job title frequency
 type TitleCount struct {
-	Title   string  `json:"title"`
-	Count   int     `json:"count"`
-	AvgPay  float64 `json:"avg_pay"`
+	Title      string  `json:"title"`
+	Count      int     `json:"count"`
+	AvgPay     float64 `json:"avg_pay"`
+	RealAvgPay float64 `json:"real_avg_pay,omitempty"`
 }
 
-// WageBracket represents a wage range bracket
+// WageBracket represents a wage range bracket. MaxValue is zero when the
+// bracket is open-ended.
 type WageBracket struct {
 	Range       string       `json:"range"`
 	MinValue    float64      `json:"min_value"`
 	MaxValue    float64      `json:"max_value"`
+	OpenEnded   bool         `json:"open_ended,omitempty"`
 	Count       int          `json:"count"`
 	Percentage  float64      `json:"percentage"`
 	AvgPay      float64      `json:"avg_pay"`
 	MedianPay   float64      `json:"median_pay"`
 	TotalPay    float64      `json:"total_pay"`
 	TopTitles   []TitleCount `json:"top_titles"`
+
+	// Pay component breakdown. Recipient shares are the percent of employees
+	// in the bracket with nonzero overtime or adjustments; SupplementShare is
+	// the percent of bracket pay from overtime and adjustments combined.
+	PayComponents            PayComponents   `json:"pay_components"`
+	OvertimeRecipientShare   float64         `json:"overtime_recipient_share"`
+	AdjustmentRecipientShare float64         `json:"adjustment_recipient_share"`
+	SupplementShare          float64         `json:"supplement_share"`
+	Categories               []CategoryCount `json:"categories,omitempty"`
+
+	RealAvgPay        float64        `json:"real_avg_pay,omitempty"`
+	RealMedianPay     float64        `json:"real_median_pay,omitempty"`
+	RealTotalPay      float64        `json:"real_total_pay,omitempty"`
+	RealPayComponents *PayComponents `json:"real_pay_components,omitempty"`
+
+	// Suppression is "suppressed" when the bracket's pay was withheld for
+	// having too few employees, "secondary" when it was withheld so that
+	// pay could not be worked out from the totals, and "merged" when
+	// small brackets were combined into this one
+	Suppression string `json:"suppression,omitempty"`
+}
+
+// BracketScheme describes how pyramid brackets are built. Type is "edges"
+// for explicit edge lists, "log" for log-spaced edges from Start to Stop, or
+// "quantile" for equal-population groups. In pyramid output Edges holds the
+// resolved edges actually used.
+type BracketScheme struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Edges     []float64 `json:"edges,omitempty"`
+	Start     float64   `json:"start,omitempty"`
+	Stop      float64   `json:"stop,omitempty"`
+	PerDecade int       `json:"per_decade,omitempty"`
+	Groups    int       `json:"groups,omitempty"`
+	OpenEnded bool      `json:"open_ended"`
 }
 
 // Pyramid contains wage distribution data
 type Pyramid struct {
-	Location       string        `json:"location"`
-	Year           int           `json:"year"`
-	GeneratedAt    time.Time     `json:"generated_at"`
-	TotalEmployees int           `json:"total_employees"`
-	TotalPay       float64       `json:"total_pay"`
-	Brackets       []WageBracket `json:"brackets"`
+	Location       string            `json:"location"`
+	Year           int               `json:"year"`
+	GeneratedAt    time.Time         `json:"generated_at"`
+	TotalEmployees int               `json:"total_employees"`
+	TotalPay       float64           `json:"total_pay"`
+	Brackets       []WageBracket     `json:"brackets"`
+	Locations      []string          `json:"locations,omitempty"`
+	Scheme         *BracketScheme    `json:"scheme,omitempty"`
+	Unbracketed    int               `json:"unbracketed,omitempty"`
+	Population     *PopulationFilter `json:"population,omitempty"`
+
+	Taxonomy        string `json:"taxonomy,omitempty"`
+	TaxonomyVersion string `json:"taxonomy_version,omitempty"`
+
+	Inflation    *InflationAdjustment `json:"inflation,omitempty"`
+	RealTotalPay float64              `json:"real_total_pay,omitempty"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+	Privacy     *PrivacyRelease    `json:"privacy,omitempty"`
 }
 
-// TitleAnalysis contains job title statistics
+// TitleAnalysis contains job title statistics. Grouping is "raw",
+// "normalized" or "family"; Normalization names the title dictionary used
+// when titles were normalized. TopTitles is ranked by RankedBy; any
+// further rankings requested are listed in Rankings.
 type TitleAnalysis struct {
-	Location      string       `json:"location"`
-	Year          int          `json:"year"`
-	GeneratedAt   time.Time    `json:"generated_at"`
-	UniqueTitles  int          `json:"unique_titles"`
-	TopTitles     []TitleStats `json:"top_titles"`
+	Location      string               `json:"location"`
+	Year          int                  `json:"year"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+	UniqueTitles  int                  `json:"unique_titles"`
+	TopTitles     []TitleStats         `json:"top_titles"`
+	Locations     []string             `json:"locations,omitempty"`
+	Inflation     *InflationAdjustment `json:"inflation,omitempty"`
+	Grouping      string               `json:"grouping,omitempty"`
+	Normalization string               `json:"normalization,omitempty"`
+	RankedBy      string               `json:"ranked_by,omitempty"`
+	MinCount      int                  `json:"min_count,omitempty"`
+	Rankings      []TitleRanking       `json:"rankings,omitempty"`
+	Population    *PopulationFilter    `json:"population,omitempty"`
+	Suppression   *SuppressionPolicy   `json:"suppression,omitempty"`
+	Privacy       *PrivacyRelease      `json:"privacy,omitempty"`
+}
+
+// TitleRanking is an additional ranked list of titles in a TitleAnalysis
+type TitleRanking struct {
+	By     string       `json:"by"`
+	Titles []TitleStats `json:"titles"`
 }
 
 // TitleStats contains statistics for a specific job title
@@ -100,4 +351,820 @@ type TitleStats struct {
 	MaxPay     float64 `json:"max_pay"`
 	StdDev     float64 `json:"std_dev"`
 	TotalPay   float64 `json:"total_pay"`
-}
\ No newline at end of file
+	Variants   []string `json:"variants,omitempty"`
+
+	PrevCount    int     `json:"prev_count,omitempty"`
+	CountGrowth  float64 `json:"count_growth,omitempty"`
+	MedianGrowth float64 `json:"median_growth,omitempty"`
+
+	RealAvgPay    float64 `json:"real_avg_pay,omitempty"`
+	RealMedianPay float64 `json:"real_median_pay,omitempty"`
+	RealMinPay    float64 `json:"real_min_pay,omitempty"`
+	RealMaxPay    float64 `json:"real_max_pay,omitempty"`
+	RealTotalPay  float64 `json:"real_total_pay,omitempty"`
+}
+
+// NormalizedTitle splits a raw payroll title into a canonical title, the
+// family it belongs to across ranks and levels, and the parts stripped
+// from it. RankOrder sorts ranks around the unranked title at zero.
+type NormalizedTitle struct {
+	Raw        string   `json:"raw"`
+	Title      string   `json:"title"`
+	Family     string   `json:"family"`
+	Rank       string   `json:"rank,omitempty"`
+	RankOrder  int      `json:"rank_order,omitempty"`
+	Level      int      `json:"level,omitempty"`
+	Step       int      `json:"step,omitempty"`
+	Qualifiers []string `json:"qualifiers,omitempty"`
+}
+
+// TitleLocationPay is a title's pay distribution at one location.
+// MedianRank is 1 for the location paying the highest median.
+type TitleLocationPay struct {
+	Location            string             `json:"location"`
+	Count               int                `json:"count"`
+	AvgPay              float64            `json:"avg_pay"`
+	MedianPay           float64            `json:"median_pay"`
+	MinPay              float64            `json:"min_pay"`
+	MaxPay              float64            `json:"max_pay"`
+	TotalPay            float64            `json:"total_pay"`
+	Percentiles         map[string]float64 `json:"percentiles"`
+	MedianRank          int                `json:"median_rank"`
+	RatioToPooledMedian float64            `json:"ratio_to_pooled_median"`
+}
+
+// LocationCount is a location's headcount for a title below the
+// comparison threshold. No pay is published for these cells.
+type LocationCount struct {
+	Location string `json:"location"`
+	Count    int    `json:"count"`
+}
+
+// TitleComparison compares one title's pay across the locations meeting
+// the headcount threshold. Pooled statistics cover those locations only;
+// MedianSpread is the highest location median over the lowest.
+type TitleComparison struct {
+	Title          string             `json:"title"`
+	Count          int                `json:"count"`
+	Locations      int                `json:"locations"`
+	PooledAvgPay   float64            `json:"pooled_avg_pay"`
+	PooledMedian   float64            `json:"pooled_median"`
+	MedianSpread   float64            `json:"median_spread"`
+	ByLocation     []TitleLocationPay `json:"by_location"`
+	BelowThreshold []LocationCount    `json:"below_threshold,omitempty"`
+	Variants       []string           `json:"variants,omitempty"`
+}
+
+// TitleComparisonReport compares title pay across locations for a year
+type TitleComparisonReport struct {
+	Year          int               `json:"year"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Grouping      string            `json:"grouping"`
+	Normalization string            `json:"normalization,omitempty"`
+	MinCount      int               `json:"min_count"`
+	MinLocations  int               `json:"min_locations"`
+	Locations     []string          `json:"locations"`
+	Titles        []TitleComparison `json:"titles"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
+
+// TitleYearPoint holds one year of a title's pay at a location. Growth
+// fields are percent change from the previous year the title was present.
+type TitleYearPoint struct {
+	Year         int     `json:"year"`
+	Count        int     `json:"count"`
+	AvgPay       float64 `json:"avg_pay"`
+	MedianPay    float64 `json:"median_pay"`
+	P90Pay       float64 `json:"p90_pay"`
+	TotalPay     float64 `json:"total_pay"`
+	CountGrowth  float64 `json:"count_growth"`
+	MedianGrowth float64 `json:"median_growth"`
+}
+
+// TitleTrajectory is a title's time series at a location. Introduced and
+// Retired are relative to the years the location has data; MedianChange
+// is the percent change in median pay from the first year to the last.
+type TitleTrajectory struct {
+	Title        string           `json:"title"`
+	FirstYear    int              `json:"first_year"`
+	LastYear     int              `json:"last_year"`
+	Years        int              `json:"years"`
+	Introduced   bool             `json:"introduced"`
+	Retired      bool             `json:"retired"`
+	PeakCount    int              `json:"peak_count"`
+	MedianChange float64          `json:"median_change"`
+	RenamedFrom  []string         `json:"renamed_from,omitempty"`
+	RenamedTo    []string         `json:"renamed_to,omitempty"`
+	Points       []TitleYearPoint `json:"points"`
+}
+
+// TitleRename pairs a title retired after LastYear with a title introduced
+// in FirstYear whose headcount and median pay are similar. Similarities are
+// the smaller value over the larger; Score is their product.
+type TitleRename struct {
+	From            string  `json:"from"`
+	To              string  `json:"to"`
+	LastYear        int     `json:"last_year"`
+	FirstYear       int     `json:"first_year"`
+	FromCount       int     `json:"from_count"`
+	ToCount         int     `json:"to_count"`
+	FromMedianPay   float64 `json:"from_median_pay"`
+	ToMedianPay     float64 `json:"to_median_pay"`
+	CountSimilarity float64 `json:"count_similarity"`
+	PaySimilarity   float64 `json:"pay_similarity"`
+	Score           float64 `json:"score"`
+}
+
+// TitleTrajectoryReport contains every title's time series at a location
+type TitleTrajectoryReport struct {
+	Location      string            `json:"location"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Grouping      string            `json:"grouping"`
+	Normalization string            `json:"normalization,omitempty"`
+	FirstYear     int               `json:"first_year"`
+	LastYear      int               `json:"last_year"`
+	Years         []int             `json:"years"`
+	MinCount      int               `json:"min_count"`
+	Introduced    int               `json:"introduced"`
+	Retired       int               `json:"retired"`
+	Renames       []TitleRename     `json:"renames"`
+	Titles        []TitleTrajectory `json:"titles"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
+
+// TitleFlow is a title's change in headcount and pay between two years.
+// PayrollChange is split into a headcount effect and a per-capita pay
+// effect that sum to it; a new title's payroll is all headcount effect.
+type TitleFlow struct {
+	Title           string  `json:"title"`
+	PreviousCount   int     `json:"previous_count"`
+	Count           int     `json:"count"`
+	Change          int     `json:"change"`
+	PreviousMedian  float64 `json:"previous_median,omitempty"`
+	Median          float64 `json:"median,omitempty"`
+	MedianGrowth    float64 `json:"median_growth,omitempty"`
+	PayrollChange   float64 `json:"payroll_change"`
+	HeadcountEffect float64 `json:"headcount_effect"`
+	PerCapitaEffect float64 `json:"per_capita_effect"`
+}
+
+// PayrollDecomposition splits a payroll change into a headcount effect,
+// the change in headcount valued at the average per-capita pay of both
+// years, and a per-capita effect, the change in per-capita pay applied to
+// the average headcount. The effects sum to Change; shares are percentages
+// of Change.
+type PayrollDecomposition struct {
+	PreviousPayroll   float64 `json:"previous_payroll"`
+	Payroll           float64 `json:"payroll"`
+	Change            float64 `json:"change"`
+	Growth            float64 `json:"growth"`
+	PreviousPerCapita float64 `json:"previous_per_capita"`
+	PerCapita         float64 `json:"per_capita"`
+	PerCapitaGrowth   float64 `json:"per_capita_growth"`
+	HeadcountEffect   float64 `json:"headcount_effect"`
+	PerCapitaEffect   float64 `json:"per_capita_effect"`
+	HeadcountShare    float64 `json:"headcount_share"`
+	PerCapitaShare    float64 `json:"per_capita_share"`
+}
+
+// PayShift compares the pay distribution of two years. Growth is the
+// percent change of each percentile; ContinuingMedianGrowth is the median
+// of the median pay growth of titles present in both years.
+type PayShift struct {
+	Previous               map[string]float64 `json:"previous"`
+	Current                map[string]float64 `json:"current"`
+	Growth                 map[string]float64 `json:"growth"`
+	ContinuingMedianGrowth float64            `json:"continuing_median_growth"`
+}
+
+// HeadcountFlow estimates aggregate flows between two years of a location
+// from title headcounts, since individuals cannot be followed. GrossGain and
+// GrossLoss sum the growth of growing and shrinking titles, including new
+// and discontinued ones; Reallocation is the movement between titles beyond
+// the net change. For all locations combined, flows only cover locations
+// with data in both years; AddedLocations and DroppedLocations list the
+// locations left out for having data in only one of them.
+type HeadcountFlow struct {
+	PreviousYear      int                  `json:"previous_year"`
+	Year              int                  `json:"year"`
+	PreviousHeadcount int                  `json:"previous_headcount"`
+	Headcount         int                  `json:"headcount"`
+	NetChange         int                  `json:"net_change"`
+	NetGrowth         float64              `json:"net_growth"`
+	GrossGain         int                  `json:"gross_gain"`
+	GrossLoss         int                  `json:"gross_loss"`
+	Reallocation      int                  `json:"reallocation"`
+	ContinuingTitles  int                  `json:"continuing_titles"`
+	TitleBirths       int                  `json:"title_births"`
+	TitleDeaths       int                  `json:"title_deaths"`
+	BirthHeadcount    int                  `json:"birth_headcount"`
+	DeathHeadcount    int                  `json:"death_headcount"`
+	Payroll           PayrollDecomposition `json:"payroll"`
+	PayShift          PayShift             `json:"pay_shift"`
+	Gainers           []TitleFlow          `json:"gainers"`
+	Losers            []TitleFlow          `json:"losers"`
+	Births            []TitleFlow          `json:"births"`
+	Deaths            []TitleFlow          `json:"deaths"`
+	AddedLocations    []string             `json:"added_locations,omitempty"`
+	DroppedLocations  []string             `json:"dropped_locations,omitempty"`
+}
+
+// HeadcountFlowReport contains the flows between each pair of adjacent
+// years of data for a location
+type HeadcountFlowReport struct {
+	Location      string          `json:"location"`
+	GeneratedAt   time.Time       `json:"generated_at"`
+	Grouping      string          `json:"grouping"`
+	Normalization string          `json:"normalization,omitempty"`
+	FirstYear     int             `json:"first_year"`
+	LastYear      int             `json:"last_year"`
+	TopN          int             `json:"top_n"`
+	Flows         []HeadcountFlow `json:"flows"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
+
+// RaiseBand counts raises within a range of percentages
+type RaiseBand struct {
+	Label string  `json:"label"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// RaiseDistribution summarizes the year-over-year base pay change, in
+// percent, of employees linked across two years
+type RaiseDistribution struct {
+	Count       int                `json:"count"`
+	Mean        float64            `json:"mean"`
+	Median      float64            `json:"median"`
+	Percentiles map[string]float64 `json:"percentiles"`
+	PayCutShare float64            `json:"pay_cut_share"`
+	Bands       []RaiseBand        `json:"bands"`
+}
+
+// YearLinkage summarizes the named employees of a location linked to the
+// next year of data. Only aggregate counts and distributions are kept;
+// individual links are never written. Rates are percentages of linked
+// employees, except LinkRate, which is of NamedRecords. Raise
+// distributions are omitted when too few employees qualify.
+type YearLinkage struct {
+	Location        string             `json:"location"`
+	PreviousYear    int                `json:"previous_year"`
+	Year            int                `json:"year"`
+	NamedRecords    int                `json:"named_records"`
+	RedactedRecords int                `json:"redacted_records"`
+	Linked          int                `json:"linked"`
+	LinkRate        float64            `json:"link_rate"`
+	Ambiguous       int                `json:"ambiguous"`
+	MeanConfidence  float64            `json:"mean_confidence"`
+	CampusMoves     int                `json:"campus_moves"`
+	TitleChanges    int                `json:"title_changes"`
+	TitleChangeRate float64            `json:"title_change_rate"`
+	Promotions      int                `json:"promotions"`
+	PromotionRate   float64            `json:"promotion_rate"`
+	Raises          *RaiseDistribution `json:"raises,omitempty"`
+	PromotedRaises  *RaiseDistribution `json:"promoted_raises,omitempty"`
+	SameTitleRaises *RaiseDistribution `json:"same_title_raises,omitempty"`
+}
+
+// LinkageReport contains a location's employee linkage statistics for each
+// pair of consecutive years, with the settings used to link records
+type LinkageReport struct {
+	Location        string        `json:"location"`
+	GeneratedAt     time.Time     `json:"generated_at"`
+	Normalization   string        `json:"normalization"`
+	MinConfidence   float64       `json:"min_confidence"`
+	AmbiguityMargin float64       `json:"ambiguity_margin"`
+	MinBasePay      float64       `json:"min_base_pay"`
+	MinLinks        int           `json:"min_links"`
+	Years           []YearLinkage `json:"years"`
+}
+
+// PercentileRank places a pay amount within a population. Percentile is
+// the percent of employees paid less plus half of those paid the same.
+type PercentileRank struct {
+	Population int     `json:"population"`
+	Below      int     `json:"below"`
+	Equal      int     `json:"equal"`
+	Percentile float64 `json:"percentile"`
+	MedianPay  float64 `json:"median_pay"`
+}
+
+// PayBracket is the pyramid bracket containing a looked-up pay amount
+type PayBracket struct {
+	Scheme     string  `json:"scheme"`
+	Range      string  `json:"range"`
+	MinValue   float64 `json:"min_value"`
+	MaxValue   float64 `json:"max_value"`
+	OpenEnded  bool    `json:"open_ended,omitempty"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// ComparableTitle is a title whose median pay is close to a looked-up
+// amount. Difference is the title median minus the amount; Percentile is
+// the amount's rank within the title.
+type ComparableTitle struct {
+	Title      string  `json:"title"`
+	Count      int     `json:"count"`
+	MedianPay  float64 `json:"median_pay"`
+	Difference float64 `json:"difference"`
+	Percentile float64 `json:"percentile"`
+}
+
+// PercentileLookup answers where a pay amount ranks for a location-year,
+// optionally within a category and title. Source is "records" when ranked
+// against raw records and "summary" when estimated from a summary and
+// pyramid, in which case Approximate is set. MinCount is the fewest
+// employees a category, title or comparable title rank may cover.
+type PercentileLookup struct {
+	Pay              float64           `json:"pay"`
+	Location         string            `json:"location"`
+	Year             int               `json:"year"`
+	Category         string            `json:"category,omitempty"`
+	Title            string            `json:"title,omitempty"`
+	Source           string            `json:"source"`
+	Approximate      bool              `json:"approximate,omitempty"`
+	MinCount         int               `json:"min_count,omitempty"`
+	Overall          PercentileRank    `json:"overall"`
+	WithinTitle      *PercentileRank   `json:"within_title,omitempty"`
+	MatchedTitles    []string          `json:"matched_titles,omitempty"`
+	Bracket          *PayBracket       `json:"bracket,omitempty"`
+	ComparableTitles []ComparableTitle `json:"comparable_titles,omitempty"`
+}
+
+// EarnerRecord is one employee's pay in an outlier report. Name is empty
+// when the source data redacted it.
+type EarnerRecord struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name,omitempty"`
+	Title       string  `json:"title"`
+	BasePay     float64 `json:"base_pay"`
+	OvertimePay float64 `json:"overtime_pay"`
+	AdjustPay   float64 `json:"adjust_pay"`
+	GrossPay    float64 `json:"gross_pay"`
+}
+
+// PayOutlier is a record far from its title's median gross pay. Deviation
+// is the distance from the median in median absolute deviations, negative
+// below the median.
+type PayOutlier struct {
+	EarnerRecord
+	TitleCount  int     `json:"title_count"`
+	TitleMedian float64 `json:"title_median"`
+	TitleMAD    float64 `json:"title_mad"`
+	Deviation   float64 `json:"deviation"`
+}
+
+// OvertimeTitle is a title whose total overtime exceeds its total base pay.
+// OvertimeRatio is overtime over base and zero when no base pay was paid.
+type OvertimeTitle struct {
+	Title           string  `json:"title"`
+	Count           int     `json:"count"`
+	TotalBase       float64 `json:"total_base"`
+	TotalOvertime   float64 `json:"total_overtime"`
+	OvertimeRatio   float64 `json:"overtime_ratio"`
+	RecordsOverBase int     `json:"records_over_base"`
+}
+
+// OutlierReport lists extreme individual records for a location-year.
+// Outliers holds the MaxOutliers records deviating most; OutlierCount is
+// the number found before that limit.
+type OutlierReport struct {
+	Location       string          `json:"location"`
+	Year           int             `json:"year"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+	MADThreshold   float64         `json:"mad_threshold"`
+	MinTitleCount  int             `json:"min_title_count"`
+	NamesHidden    bool            `json:"names_hidden,omitempty"`
+	TopEarners     []EarnerRecord  `json:"top_earners"`
+	OutlierCount   int             `json:"outlier_count"`
+	Outliers       []PayOutlier    `json:"outliers"`
+	OvertimeTitles []OvertimeTitle `json:"overtime_titles"`
+}
+
+// ComponentGroup is one title's or category's share of a pay component.
+// Share is the percent of the component's dollars; RecipientShare is the
+// percent of the group's employees receiving it.
+type ComponentGroup struct {
+	Name            string  `json:"name"`
+	Employees       int     `json:"employees"`
+	Recipients      int     `json:"recipients"`
+	RecipientShare  float64 `json:"recipient_share"`
+	Total           float64 `json:"total"`
+	Share           float64 `json:"share"`
+	AvgPerRecipient float64 `json:"avg_per_recipient"`
+}
+
+// ComponentChange compares a pay component with the location's previous
+// year. Growth fields are percent change; share fields are the difference
+// in percentage points.
+type ComponentChange struct {
+	PreviousYear         int     `json:"previous_year"`
+	RecipientsGrowth     float64 `json:"recipients_growth"`
+	RecipientShareChange float64 `json:"recipient_share_change"`
+	TotalGrowth          float64 `json:"total_growth"`
+	MedianGrowth         float64 `json:"median_growth"`
+	Top10ShareChange     float64 `json:"top10_share_change"`
+}
+
+// ComponentConcentration describes who receives a pay component such as
+// overtime. Recipients are employees with a positive amount; negative
+// amounts (reversals) are counted separately and left out of the recipient
+// statistics. Top1Share and Top10Share are the percent of dollars held by
+// the top 1% and 10% of recipients; Gini is measured among recipients.
+type ComponentConcentration struct {
+	Component          string             `json:"component"`
+	Recipients         int                `json:"recipients"`
+	RecipientShare     float64            `json:"recipient_share"`
+	NegativeCount      int                `json:"negative_count,omitempty"`
+	Total              float64            `json:"total"`
+	ShareOfGross       float64            `json:"share_of_gross"`
+	AvgPerRecipient    float64            `json:"avg_per_recipient"`
+	MedianPerRecipient float64            `json:"median_per_recipient"`
+	MaxPerRecipient    float64            `json:"max_per_recipient"`
+	Percentiles        map[string]float64 `json:"percentiles,omitempty"`
+	Gini               float64            `json:"gini"`
+	Top1Share          float64            `json:"top1_share"`
+	Top10Share         float64            `json:"top10_share"`
+	TopTitles          []ComponentGroup   `json:"top_titles"`
+	TopCategories      []ComponentGroup   `json:"top_categories"`
+	Change             *ComponentChange   `json:"change,omitempty"`
+}
+
+// SupplementalPayAnalysis reports how overtime and adjustments are
+// concentrated among employees of a location-year
+type SupplementalPayAnalysis struct {
+	Location        string                 `json:"location"`
+	Year            int                    `json:"year"`
+	GeneratedAt     time.Time              `json:"generated_at"`
+	EmployeeCount   int                    `json:"employee_count"`
+	TotalGrossPay   float64                `json:"total_gross_pay"`
+	Taxonomy        string                 `json:"taxonomy"`
+	TaxonomyVersion string                 `json:"taxonomy_version"`
+	Overtime        ComponentConcentration `json:"overtime"`
+	Adjustments     ComponentConcentration `json:"adjustments"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
+
+// CompressionLevel is one level of a title family, such as "ASSISTANT
+// PROFESSOR" or "ANALYST 3"
+type CompressionLevel struct {
+	Title     string  `json:"title"`
+	Rank      string  `json:"rank,omitempty"`
+	RankOrder int     `json:"rank_order,omitempty"`
+	Level     int     `json:"level,omitempty"`
+	Count     int     `json:"count"`
+	MedianPay float64 `json:"median_pay"`
+}
+
+// LevelRatio compares the median pay of a family's higher level with a
+// lower one. Inverted is set when the lower level out-earns the higher.
+type LevelRatio struct {
+	Lower    string  `json:"lower"`
+	Higher   string  `json:"higher"`
+	Ratio    float64 `json:"ratio"`
+	Inverted bool    `json:"inverted,omitempty"`
+}
+
+// FamilyCompression describes the pay spread across the levels of a title
+// family, lowest level first. TopToEntry is the ratio of the highest
+// level's median to the lowest's; values near 1 indicate compression.
+type FamilyCompression struct {
+	Family     string             `json:"family"`
+	Qualifiers []string           `json:"qualifiers,omitempty"`
+	Count      int                `json:"count"`
+	Levels     []CompressionLevel `json:"levels"`
+	Adjacent   []LevelRatio       `json:"adjacent"`
+	TopToEntry float64            `json:"top_to_entry"`
+	Inversions int                `json:"inversions"`
+}
+
+// PayCompression reports pay ratios between the levels of title families
+// for a location-year. Levels come from the title normalizer's ranks and
+// level numbers; levels with fewer than MinCount employees are left out.
+type PayCompression struct {
+	Location         string              `json:"location"`
+	Year             int                 `json:"year"`
+	GeneratedAt      time.Time           `json:"generated_at"`
+	PayBasis         string              `json:"pay_basis"`
+	Normalization    string              `json:"normalization"`
+	MinCount         int                 `json:"min_count"`
+	FamilyCount      int                 `json:"family_count"`
+	InvertedFamilies int                 `json:"inverted_families"`
+	MedianTopToEntry float64             `json:"median_top_to_entry"`
+	MedianAdjacent   float64             `json:"median_adjacent"`
+	Families         []FamilyCompression `json:"families"`
+	Population       *PopulationFilter   `json:"population,omitempty"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
+
+// TrendPoint holds one year of a location's time series. Growth fields are
+// percent change from the previous point and zero for the first year.
+type TrendPoint struct {
+	Year           int     `json:"year"`
+	EmployeeCount  int     `json:"employee_count"`
+	TotalGrossPay  float64 `json:"total_gross_pay"`
+	AvgGrossPay    float64 `json:"avg_gross_pay"`
+	MedianPay      float64 `json:"median_gross_pay"`
+	EmployeeGrowth float64 `json:"employee_growth"`
+	PayrollGrowth  float64 `json:"payroll_growth"`
+	AvgPayGrowth   float64 `json:"avg_pay_growth"`
+	MedianGrowth   float64 `json:"median_growth"`
+	OvertimeShare  float64 `json:"overtime_share"`
+
+	CPIFactor         float64 `json:"cpi_factor,omitempty"`
+	RealTotalGrossPay float64 `json:"real_total_gross_pay,omitempty"`
+	RealAvgGrossPay   float64 `json:"real_avg_gross_pay,omitempty"`
+	RealMedianPay     float64 `json:"real_median_gross_pay,omitempty"`
+	RealPayrollGrowth float64 `json:"real_payroll_growth,omitempty"`
+	RealAvgPayGrowth  float64 `json:"real_avg_pay_growth,omitempty"`
+	RealMedianGrowth  float64 `json:"real_median_growth,omitempty"`
+}
+
+// Trend contains the year-over-year series for a location
+type Trend struct {
+	Location    string               `json:"location"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	FirstYear   int                  `json:"first_year"`
+	LastYear    int                  `json:"last_year"`
+	Points      []TrendPoint         `json:"points"`
+	Inflation   *InflationAdjustment `json:"inflation,omitempty"`
+}
+
+// SeriesPoint is one year's value of a series
+type SeriesPoint struct {
+	Year  int     `json:"year"`
+	Value float64 `json:"value"`
+}
+
+// ForecastPoint is a projected value with its prediction interval
+type ForecastPoint struct {
+	Year  int     `json:"year"`
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// ForecastModel is one model fitted to a series. Fit metrics compare the
+// fitted values with history in the series' own units; HoldoutMAPE is the
+// error projecting the last years of history from a fit without them.
+type ForecastModel struct {
+	Model       string             `json:"model"`
+	Parameters  map[string]float64 `json:"parameters"`
+	RMSE        float64            `json:"rmse"`
+	MAE         float64            `json:"mae"`
+	MAPE        float64            `json:"mape"`
+	RSquared    float64            `json:"r_squared"`
+	AIC         float64            `json:"aic"`
+	HoldoutMAPE float64            `json:"holdout_mape,omitempty"`
+	Fitted      []SeriesPoint      `json:"fitted"`
+	Projections []ForecastPoint    `json:"projections"`
+}
+
+// ForecastSeries holds the models fitted to one metric of a location. Best
+// names the model with the lowest holdout error, or the lowest AIC when the
+// history is too short to hold years out.
+type ForecastSeries struct {
+	Metric  string          `json:"metric"`
+	History []SeriesPoint   `json:"history"`
+	Models  []ForecastModel `json:"models"`
+	Best    string          `json:"best"`
+}
+
+// Forecast contains projections of a location's headcount, payroll and
+// median pay. Dollar series are in real dollars when Inflation is set.
+type Forecast struct {
+	Location    string               `json:"location"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	FirstYear   int                  `json:"first_year"`
+	LastYear    int                  `json:"last_year"`
+	Horizon     int                  `json:"horizon"`
+	Confidence  float64              `json:"confidence"`
+	Holdout     int                  `json:"holdout"`
+	Series      []ForecastSeries     `json:"series"`
+	Inflation   *InflationAdjustment `json:"inflation,omitempty"`
+}
+// Histogram bins gross pay between Edges, which has one more entry than
+// Counts. Density divides each count by the total and the bin width, in
+// dollars on the "linear" scale or log10 dollars on the "log" scale, so the
+// bars integrate to the share of records within the edges. Overflow counts
+// pay above the last edge.
+type Histogram struct {
+	Scale         string    `json:"scale"`
+	BinWidth      float64   `json:"bin_width,omitempty"`
+	BinsPerDecade int       `json:"bins_per_decade,omitempty"`
+	Edges         []float64 `json:"edges"`
+	Counts        []int     `json:"counts"`
+	Density       []float64 `json:"density"`
+	Overflow      int       `json:"overflow"`
+}
+
+// DensityEstimate is a Gaussian kernel density estimate of gross pay
+// evaluated at X dollars. On the log scale the kernel is applied to log10
+// pay, Bandwidth is in log10 dollars and Density is per log10 dollar.
+type DensityEstimate struct {
+	Kernel    string    `json:"kernel"`
+	Scale     string    `json:"scale"`
+	Bandwidth float64   `json:"bandwidth"`
+	X         []float64 `json:"x"`
+	Density   []float64 `json:"density"`
+}
+
+// CategoryHistogram is a category's distribution on the same histogram
+// edges and density grid as the location-year it belongs to. KDE is left
+// out when small-cell suppression changed any of the category's counts.
+type CategoryHistogram struct {
+	Category  string    `json:"category"`
+	Count     int       `json:"count"`
+	Share     float64   `json:"share"`
+	Counts    []int     `json:"counts"`
+	Density   []float64 `json:"density"`
+	Overflow  int       `json:"overflow"`
+	Bandwidth float64   `json:"bandwidth,omitempty"`
+	KDE       []float64 `json:"kde,omitempty"`
+}
+
+// WageHistogram holds chart-ready distributions of gross pay for a
+// location-year, overall and per top-level job category
+type WageHistogram struct {
+	Location        string              `json:"location"`
+	Year            int                 `json:"year"`
+	GeneratedAt     time.Time           `json:"generated_at"`
+	Count           int                 `json:"count"`
+	Histogram       Histogram           `json:"histogram"`
+	KDE             DensityEstimate     `json:"kde"`
+	Categories      []CategoryHistogram `json:"categories"`
+	Taxonomy        string              `json:"taxonomy"`
+	TaxonomyVersion string              `json:"taxonomy_version"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
+
+// LorenzPoint is one point on a Lorenz curve, both shares in percent
+type LorenzPoint struct {
+	PopulationShare float64 `json:"population_share"`
+	IncomeShare     float64 `json:"income_share"`
+}
+
+// QuantileGroup describes one equal-sized slice of the sorted population
+type QuantileGroup struct {
+	Group  int     `json:"group"`
+	Count  int     `json:"count"`
+	MinPay float64 `json:"min_pay"`
+	MaxPay float64 `json:"max_pay"`
+	AvgPay float64 `json:"avg_pay"`
+	Share  float64 `json:"share"`
+}
+
+// Distribution contains inequality and shape metrics for a location-year
+type Distribution struct {
+	Location      string             `json:"location"`
+	Year          int                `json:"year"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+	EmployeeCount int                `json:"employee_count"`
+	Gini          float64            `json:"gini"`
+	Theil         float64            `json:"theil"`
+	Atkinson      map[string]float64 `json:"atkinson"`
+	PalmaRatio    float64            `json:"palma_ratio"`
+	Ratio90To10   float64            `json:"ratio_90_10"`
+	Ratio90To50   float64            `json:"ratio_90_50"`
+	Ratio50To10   float64            `json:"ratio_50_10"`
+	Skewness      float64            `json:"skewness"`
+	Kurtosis      float64            `json:"kurtosis"`
+	Deciles       []QuantileGroup    `json:"deciles"`
+	Quintiles     []QuantileGroup    `json:"quintiles"`
+	Lorenz        []LorenzPoint      `json:"lorenz"`
+}
+
+// RankCell is a location's value and rank for one metric in one year.
+// RankChange is positive when the location moved up since its previous year.
+type RankCell struct {
+	Year       int     `json:"year"`
+	Value      float64 `json:"value"`
+	Rank       int     `json:"rank"`
+	OutOf      int     `json:"out_of"`
+	RankChange int     `json:"rank_change"`
+}
+
+// LocationRanks is one row of a ranking matrix
+type LocationRanks struct {
+	Location string     `json:"location"`
+	Cells    []RankCell `json:"cells"`
+}
+
+// MetricRanking ranks every location by a single metric across years
+type MetricRanking struct {
+	Metric     string          `json:"metric"`
+	Label      string          `json:"label"`
+	Descending bool            `json:"descending"`
+	Rows       []LocationRanks `json:"rows"`
+}
+
+// Comparison contains campus rankings for a set of metrics
+type Comparison struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Years       []int           `json:"years"`
+	Locations   []string        `json:"locations"`
+	Metrics     []MetricRanking `json:"metrics"`
+}
+
+// CategoryStats contains statistics for a job category
+type CategoryStats struct {
+	Category       string  `json:"category"`
+	Count          int     `json:"count"`
+	HeadcountShare float64 `json:"headcount_share"`
+	TotalPay       float64 `json:"total_pay"`
+	PayrollShare   float64 `json:"payroll_share"`
+	AvgPay         float64 `json:"avg_pay"`
+	MedianPay      float64 `json:"median_pay"`
+	MinPay         float64 `json:"min_pay"`
+	MaxPay         float64 `json:"max_pay"`
+	StdDev         float64 `json:"std_dev"`
+	UniqueTitles   int     `json:"unique_titles"`
+
+	Subcategories []CategoryStats `json:"subcategories,omitempty"`
+}
+
+// CategoryCount is a category's share of a wage bracket
+type CategoryCount struct {
+	Category   string  `json:"category"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+	AvgPay     float64 `json:"avg_pay"`
+}
+
+// CategoryAnalysis contains job category rollups for a location-year.
+// UncategorizedTitles lists the largest titles falling into "Other".
+type CategoryAnalysis struct {
+	Location            string          `json:"location"`
+	Year                int             `json:"year"`
+	GeneratedAt         time.Time       `json:"generated_at"`
+	Taxonomy            string          `json:"taxonomy"`
+	TaxonomyVersion     string          `json:"taxonomy_version"`
+	EmployeeCount       int             `json:"employee_count"`
+	TotalPay            float64         `json:"total_pay"`
+	Categories          []CategoryStats `json:"categories"`
+	UncategorizedTitles []TitleCount    `json:"uncategorized_titles"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
+
+// RuleMatch is a taxonomy rule that matched a title
+type RuleMatch struct {
+	RuleID    string `json:"rule_id"`
+	Category  string `json:"category"`
+	Priority  int    `json:"priority"`
+	MatchType string `json:"match_type"`
+	Matched   string `json:"matched"`
+}
+
+// TitleClassification explains how a title was assigned a category.
+// Path holds every level of the category, top level first.
+type TitleClassification struct {
+	Title      string      `json:"title"`
+	Category   string      `json:"category"`
+	Path       []string    `json:"path"`
+	Rule       *RuleMatch  `json:"rule,omitempty"`
+	Overridden []RuleMatch `json:"overridden,omitempty"`
+	Conflict   bool        `json:"conflict"`
+}
+
+// TitleRuleReport is a title's classification and how often it occurs
+type TitleRuleReport struct {
+	TitleClassification
+	Count     int      `json:"count"`
+	AvgPay    float64  `json:"avg_pay"`
+	Locations []string `json:"locations"`
+}
+
+// RuleUsage counts the titles and records a taxonomy rule decided
+type RuleUsage struct {
+	RuleID     string `json:"rule_id"`
+	Category   string `json:"category"`
+	Priority   int    `json:"priority"`
+	Titles     int    `json:"titles"`
+	Records    int    `json:"records"`
+	Overridden int    `json:"overridden"`
+}
+
+// TaxonomyReport explains how a taxonomy classifies every title in the data
+type TaxonomyReport struct {
+	Taxonomy     string            `json:"taxonomy"`
+	Version      string            `json:"version"`
+	GeneratedAt  time.Time         `json:"generated_at"`
+	Files        int               `json:"files"`
+	Records      int               `json:"records"`
+	UniqueTitles int               `json:"unique_titles"`
+	Categories   []CategoryCount   `json:"categories"`
+	Rules        []RuleUsage       `json:"rules"`
+	UnusedRules  []string          `json:"unused_rules"`
+	Conflicts    int               `json:"conflicts"`
+	Titles       []TitleRuleReport `json:"titles"`
+
+	Suppression *SuppressionPolicy `json:"suppression,omitempty"`
+}
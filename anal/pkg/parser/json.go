@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
 )
@@ -27,6 +28,155 @@ func LoadWageData(filepath string) (*models.WageData, error) {
 	return &data, nil
 }
 
+// LoadJSON decodes any JSON file into v, such as outputs read back by
+// later analyses
+func LoadJSON(filepath string, v interface{}) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("error decoding JSON from %s: %w", filepath, err)
+	}
+
+	return nil
+}
+
+// LoadLocationGroups loads location group definitions from a JSON file
+func LoadLocationGroups(filepath string) ([]models.LocationGroup, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	var groups []models.LocationGroup
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&groups); err != nil {
+		return nil, fmt.Errorf("error decoding JSON from %s: %w", filepath, err)
+	}
+
+	return groups, nil
+}
+
+// LoadBracketScheme loads a pyramid bracket scheme from a JSON file
+func LoadBracketScheme(filepath string) (*models.BracketScheme, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	var scheme models.BracketScheme
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&scheme); err != nil {
+		return nil, fmt.Errorf("error decoding JSON from %s: %w", filepath, err)
+	}
+
+	return &scheme, nil
+}
+
+// LoadPopulationFilter loads a population filter from a JSON file
+func LoadPopulationFilter(filepath string) (*models.PopulationFilter, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	var filter models.PopulationFilter
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&filter); err != nil {
+		return nil, fmt.Errorf("error decoding JSON from %s: %w", filepath, err)
+	}
+
+	return &filter, nil
+}
+
+// OpenPopulationFilter resolves a population filter flag: a .json name is
+// loaded from disk, any other name is looked up in presets, and an empty
+// name selects no filter
+func OpenPopulationFilter(name string, presets map[string]models.PopulationFilter) (*models.PopulationFilter, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if strings.HasSuffix(name, ".json") {
+		return LoadPopulationFilter(name)
+	}
+
+	filter, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown population filter %q", name)
+	}
+	return &filter, nil
+}
+
+// LoadSuppressionPolicy loads a small-cell suppression policy from a JSON file
+func LoadSuppressionPolicy(filepath string) (*models.SuppressionPolicy, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	var policy models.SuppressionPolicy
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("error decoding JSON from %s: %w", filepath, err)
+	}
+
+	return &policy, nil
+}
+
+// OpenSuppressionPolicy resolves a suppression policy flag: a .json name is
+// loaded from disk, any other name is looked up in presets, and an empty
+// name selects no policy
+func OpenSuppressionPolicy(name string, presets map[string]models.SuppressionPolicy) (*models.SuppressionPolicy, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if strings.HasSuffix(name, ".json") {
+		return LoadSuppressionPolicy(name)
+	}
+
+	policy, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown suppression policy %q", name)
+	}
+	return &policy, nil
+}
+
+// LoadPrivacyBudget loads a differential privacy budget ledger from a JSON file
+func LoadPrivacyBudget(filepath string) (*models.PrivacyBudget, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	var budget models.PrivacyBudget
+	decoder := json.NewDecoder(file)
+	if err := decoder.Decode(&budget); err != nil {
+		return nil, fmt.Errorf("error decoding JSON from %s: %w", filepath, err)
+	}
+
+	return &budget, nil
+}
+
+// SplitList splits a comma-separated flag value, dropping blank items
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // ParseCurrency converts currency string to float64
 func ParseCurrency(amount string) float64 {
 	// Remove commas and dollar signs
@@ -64,6 +214,16 @@ func ConvertRecordToFloat(record models.WageRecord) (basePay, overtimePay, adjus
 
 // SaveJSON saves any struct to a JSON file
 func SaveJSON(filepath string, data interface{}) error {
+	return saveJSON(filepath, data, "  ")
+}
+
+// SaveCompactJSON saves any struct to a JSON file without indentation, for
+// outputs made mostly of long numeric arrays
+func SaveCompactJSON(filepath string, data interface{}) error {
+	return saveJSON(filepath, data, "")
+}
+
+func saveJSON(filepath string, data interface{}, indent string) error {
 	// Create directory if it doesn't exist
 	dir := strings.Split(filepath, "/")
 	if len(dir) > 1 {
@@ -80,11 +240,54 @@ func SaveJSON(filepath string, data interface{}) error {
 	defer file.Close()
 
 	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
+	encoder.SetIndent("", indent)
 
 	if err := encoder.Encode(data); err != nil {
 		return fmt.Errorf("error encoding JSON to %s: %w", filepath, err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// ReplaceJSON saves data to a temporary file next to filepath and renames
+// it into place, so a reader never sees a partly written file
+func ReplaceJSON(filepath string, data interface{}) error {
+	temp := fmt.Sprintf("%s.%d.tmp", filepath, os.Getpid())
+	if err := saveJSON(temp, data, "  "); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	if err := os.Rename(temp, filepath); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("error replacing file %s: %w", filepath, err)
+	}
+	return nil
+}
+
+// LockFile takes an exclusive lock on filepath by creating filepath.lock,
+// waiting up to timeout while another process holds it. The returned
+// function releases the lock.
+func LockFile(filepath string, timeout time.Duration) (func(), error) {
+	if i := strings.LastIndex(filepath, "/"); i > 0 {
+		if err := os.MkdirAll(filepath[:i], 0755); err != nil {
+			return nil, fmt.Errorf("error creating directory: %w", err)
+		}
+	}
+
+	lock := filepath + ".lock"
+	deadline := time.Now().Add(timeout)
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("error locking %s: %w", filepath, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock on %s (remove %s if no command is running)", filepath, lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,229 @@
+// Package sketch provides mergeable quantile sketches so percentiles of
+// combined populations can be estimated without holding every value.
+package sketch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultCompression is the compression used for stored summaries.
+//
+// A t-digest with compression δ keeps at most about δ centroids. With the
+// arcsine scale function used here, a centroid around quantile q covers at
+// most 2π·sqrt(q(1−q))/δ of the population, and estimates interpolate
+// between centroid centers, so the rank error of Quantile(q) is bounded by
+// roughly π·sqrt(q(1−q))/δ. For δ = 200 that is under 0.8% of the population
+// at the median, under 0.5% at p90 and under 0.16% at p99. Min and max are
+// tracked exactly. Merging digests preserves the same bound.
+const DefaultCompression = 200
+
+// Centroid is a cluster of values summarized by its mean and weight
+type Centroid struct {
+	Mean  float64
+	Count float64
+}
+
+// MarshalJSON encodes a centroid compactly as [mean, count]
+func (c Centroid) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{c.Mean, c.Count})
+}
+
+// UnmarshalJSON decodes a centroid from [mean, count]
+func (c *Centroid) UnmarshalJSON(data []byte) error {
+	var pair [2]float64
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return fmt.Errorf("error decoding centroid: %w", err)
+	}
+	c.Mean, c.Count = pair[0], pair[1]
+	return nil
+}
+
+// TDigest is a merging t-digest. It is not safe for concurrent use.
+type TDigest struct {
+	Compression float64    `json:"compression"`
+	Count       float64    `json:"count"`
+	Min         float64    `json:"min"`
+	Max         float64    `json:"max"`
+	Centroids   []Centroid `json:"centroids"`
+
+	buffer []Centroid
+}
+
+// NewTDigest creates an empty digest with the given compression
+func NewTDigest(compression float64) *TDigest {
+	return &TDigest{
+		Compression: compression,
+		Centroids:   []Centroid{},
+	}
+}
+
+// MarshalJSON compresses buffered values first, since only centroids are
+// encoded
+func (t *TDigest) MarshalJSON() ([]byte, error) {
+	t.Compress()
+
+	type digest TDigest
+	return json.Marshal((*digest)(t))
+}
+
+// Add inserts a single value
+func (t *TDigest) Add(value float64) {
+	t.AddWeighted(value, 1)
+}
+
+// AddWeighted inserts a value with the given weight
+func (t *TDigest) AddWeighted(value, weight float64) {
+	if weight <= 0 || math.IsNaN(value) {
+		return
+	}
+
+	t.updateRange(value, value)
+	t.buffer = append(t.buffer, Centroid{Mean: value, Count: weight})
+	t.Count += weight
+
+	if len(t.buffer) > int(5*t.Compression) {
+		t.Compress()
+	}
+}
+
+// Merge folds another digest into this one
+func (t *TDigest) Merge(other *TDigest) {
+	if other == nil || other.Count == 0 {
+		return
+	}
+
+	t.updateRange(other.Min, other.Max)
+	t.buffer = append(t.buffer, other.Centroids...)
+	t.buffer = append(t.buffer, other.buffer...)
+	t.Count += other.Count
+
+	t.Compress()
+}
+
+// Compress merges buffered values into the centroid list
+func (t *TDigest) Compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+
+	all := make([]Centroid, 0, len(t.Centroids)+len(t.buffer))
+	all = append(all, t.Centroids...)
+	all = append(all, t.buffer...)
+	t.buffer = nil
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Mean < all[j].Mean
+	})
+
+	merged := []Centroid{all[0]}
+	cumulative := 0.0
+	kLeft := t.scale(0)
+
+	for _, c := range all[1:] {
+		last := &merged[len(merged)-1]
+		proposed := last.Count + c.Count
+
+		// Merge while the centroid spans at most one unit of k
+		if t.scale((cumulative+proposed)/t.Count)-kLeft <= 1 {
+			last.Mean += (c.Mean - last.Mean) * c.Count / proposed
+			last.Count = proposed
+			continue
+		}
+
+		cumulative += last.Count
+		kLeft = t.scale(cumulative / t.Count)
+		merged = append(merged, c)
+	}
+
+	t.Centroids = merged
+}
+
+// Quantile estimates the value at quantile q in [0, 1]
+func (t *TDigest) Quantile(q float64) float64 {
+	t.Compress()
+
+	if len(t.Centroids) == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return t.Min
+	}
+	if q >= 1 {
+		return t.Max
+	}
+
+	rank := q * t.Count
+	cumulative := 0.0
+	prevCenter, prevMean := 0.0, t.Min
+
+	// Interpolate between centroid centers, anchored at min and max
+	for _, c := range t.Centroids {
+		center := cumulative + c.Count/2
+		if rank < center {
+			if center == prevCenter {
+				return c.Mean
+			}
+			return prevMean + (rank-prevCenter)/(center-prevCenter)*(c.Mean-prevMean)
+		}
+		prevCenter, prevMean = center, c.Mean
+		cumulative += c.Count
+	}
+
+	if t.Count == prevCenter {
+		return t.Max
+	}
+	return prevMean + (rank-prevCenter)/(t.Count-prevCenter)*(t.Max-prevMean)
+}
+
+// CDF estimates the fraction of values less than or equal to x
+func (t *TDigest) CDF(x float64) float64 {
+	t.Compress()
+
+	if len(t.Centroids) == 0 || x < t.Min {
+		return 0
+	}
+	if x >= t.Max {
+		return 1
+	}
+
+	cumulative := 0.0
+	prevCenter, prevMean := 0.0, t.Min
+
+	for _, c := range t.Centroids {
+		center := cumulative + c.Count/2
+		if x < c.Mean {
+			if c.Mean == prevMean {
+				return prevCenter / t.Count
+			}
+			rank := prevCenter + (x-prevMean)/(c.Mean-prevMean)*(center-prevCenter)
+			return rank / t.Count
+		}
+		prevCenter, prevMean = center, c.Mean
+		cumulative += c.Count
+	}
+
+	if t.Max == prevMean {
+		return 1
+	}
+	rank := prevCenter + (x-prevMean)/(t.Max-prevMean)*(t.Count-prevCenter)
+	return rank / t.Count
+}
+
+// updateRange widens the exact min and max; an empty digest adopts them
+func (t *TDigest) updateRange(min, max float64) {
+	if t.Count == 0 {
+		t.Min, t.Max = min, max
+		return
+	}
+	t.Min = math.Min(t.Min, min)
+	t.Max = math.Max(t.Max, max)
+}
+
+// scale is the arcsine scale function k(q) = δ/(2π)·asin(2q−1)
+func (t *TDigest) scale(q float64) float64 {
+	q = math.Max(0, math.Min(1, q))
+	return t.Compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
@@ -0,0 +1,210 @@
+package sketch
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+var testQuantiles = []float64{0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99}
+
+// testDistributions are wage-like samples: spread out, skewed, and with
+// the heavy ties of salary steps
+var testDistributions = []struct {
+	name   string
+	sample func(r *rand.Rand) float64
+}{
+	{"uniform", func(r *rand.Rand) float64 { return r.Float64() * 300000 }},
+	{"lognormal", func(r *rand.Rand) float64 { return math.Exp(11 + 0.8*r.NormFloat64()) }},
+	{"steps", func(r *rand.Rand) float64 { return float64(40000 + 5000*r.Intn(20)) }},
+}
+
+func testValues(sample func(r *rand.Rand) float64, n int) []float64 {
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = sample(r)
+	}
+	return values
+}
+
+// rankBound is the documented rank error of Quantile(q)
+func rankBound(compression, q float64) float64 {
+	return math.Pi * math.Sqrt(q*(1-q)) / compression
+}
+
+// rankRange returns the fractions of values below and at or below x
+func rankRange(sorted []float64, x float64) (float64, float64) {
+	below := sort.SearchFloat64s(sorted, x)
+	atOrBelow := sort.Search(len(sorted), func(i int) bool { return sorted[i] > x })
+	n := float64(len(sorted))
+	return float64(below) / n, float64(atOrBelow) / n
+}
+
+// rankError is how far q lies outside the ranks x actually holds
+func rankError(sorted []float64, x, q float64) float64 {
+	below, atOrBelow := rankRange(sorted, x)
+	return math.Max(0, math.Max(below-q, q-atOrBelow))
+}
+
+func checkDigest(t *testing.T, digest *TDigest, sorted []float64) {
+	t.Helper()
+
+	if digest.Count != float64(len(sorted)) {
+		t.Errorf("count = %v, want %d", digest.Count, len(sorted))
+	}
+	if digest.Min != sorted[0] || digest.Max != sorted[len(sorted)-1] {
+		t.Errorf("range = [%v, %v], want [%v, %v]", digest.Min, digest.Max, sorted[0], sorted[len(sorted)-1])
+	}
+
+	for _, q := range testQuantiles {
+		bound := rankBound(digest.Compression, q)
+
+		if err := rankError(sorted, digest.Quantile(q), q); err > bound {
+			t.Errorf("Quantile(%v) rank error %.4f exceeds %.4f", q, err, bound)
+		}
+
+		x := sorted[int(q*float64(len(sorted)))]
+		below, atOrBelow := rankRange(sorted, x)
+		if cdf := digest.CDF(x); cdf < below-bound || cdf > atOrBelow+bound {
+			t.Errorf("CDF(%v) = %.4f, want within %.4f of [%.4f, %.4f]", x, cdf, bound, below, atOrBelow)
+		}
+	}
+}
+
+func TestQuantileAndCDFErrorBounds(t *testing.T) {
+	for _, dist := range testDistributions {
+		for _, compression := range []float64{50, DefaultCompression} {
+			values := testValues(dist.sample, 20000)
+
+			digest := NewTDigest(compression)
+			for _, v := range values {
+				digest.Add(v)
+			}
+
+			sorted := append([]float64(nil), values...)
+			sort.Float64s(sorted)
+
+			t.Run(dist.name, func(t *testing.T) {
+				checkDigest(t, digest, sorted)
+			})
+		}
+	}
+}
+
+func TestMergeOrderInvariance(t *testing.T) {
+	const shards = 8
+
+	orders := []struct {
+		name  string
+		merge func(parts []*TDigest) *TDigest
+	}{
+		{"forward", func(parts []*TDigest) *TDigest {
+			total := NewTDigest(DefaultCompression)
+			for _, part := range parts {
+				total.Merge(part)
+			}
+			return total
+		}},
+		{"reverse", func(parts []*TDigest) *TDigest {
+			total := NewTDigest(DefaultCompression)
+			for i := len(parts) - 1; i >= 0; i-- {
+				total.Merge(parts[i])
+			}
+			return total
+		}},
+		{"shuffled", func(parts []*TDigest) *TDigest {
+			total := NewTDigest(DefaultCompression)
+			for _, i := range rand.New(rand.NewSource(2)).Perm(len(parts)) {
+				total.Merge(parts[i])
+			}
+			return total
+		}},
+		{"pairwise", func(parts []*TDigest) *TDigest {
+			for len(parts) > 1 {
+				var next []*TDigest
+				for i := 0; i < len(parts); i += 2 {
+					pair := NewTDigest(DefaultCompression)
+					pair.Merge(parts[i])
+					if i+1 < len(parts) {
+						pair.Merge(parts[i+1])
+					}
+					next = append(next, pair)
+				}
+				parts = next
+			}
+			return parts[0]
+		}},
+	}
+
+	for _, dist := range testDistributions {
+		values := testValues(dist.sample, 20000)
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+
+		// Shards by position, like locations, and by value, so each shard
+		// covers a disjoint pay range
+		for _, layout := range []struct {
+			name   string
+			values []float64
+		}{{"interleaved", values}, {"disjoint", sorted}} {
+			for _, order := range orders {
+				parts := make([]*TDigest, shards)
+				size := len(layout.values) / shards
+				for i := range parts {
+					part := NewTDigest(DefaultCompression)
+					for _, v := range layout.values[i*size : (i+1)*size] {
+						part.Add(v)
+					}
+
+					// Shards are stored as JSON between runs
+					data, err := json.Marshal(part)
+					if err != nil {
+						t.Fatal(err)
+					}
+					parts[i] = &TDigest{}
+					if err := json.Unmarshal(data, parts[i]); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				t.Run(dist.name+"/"+layout.name+"/"+order.name, func(t *testing.T) {
+					checkDigest(t, order.merge(parts), sorted)
+				})
+			}
+		}
+	}
+}
+
+func TestEmptyAndSingleValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		quantile float64
+		cdfAt    float64
+		cdf      float64
+	}{
+		{"empty", nil, 0, 100, 0},
+		{"single", []float64{50000}, 50000, 50000, 1},
+		{"below min", []float64{50000, 60000}, 55000, 10000, 0},
+		{"ignores NaN", []float64{math.NaN(), 70000}, 70000, 70000, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest := NewTDigest(DefaultCompression)
+			for _, v := range tt.values {
+				digest.Add(v)
+			}
+
+			if got := digest.Quantile(0.5); got != tt.quantile {
+				t.Errorf("Quantile(0.5) = %v, want %v", got, tt.quantile)
+			}
+			if got := digest.CDF(tt.cdfAt); got != tt.cdf {
+				t.Errorf("CDF(%v) = %v, want %v", tt.cdfAt, got, tt.cdf)
+			}
+		})
+	}
+}
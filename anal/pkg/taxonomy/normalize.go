@@ -0,0 +1,381 @@
+package taxonomy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+//go:embed data/titles.json
+var defaultDictionary []byte
+
+var (
+	defaultNormalizerOnce sync.Once
+	defaultNormalizer     *Normalizer
+)
+
+var (
+	parentheticalPattern = regexp.MustCompile(`\(([^)]*)\)`)
+	nonPrefixPattern     = regexp.MustCompile(`\bNON-`)
+	stepPattern          = regexp.MustCompile(`\bSTEP\s*-?\s*(\d+)\b`)
+)
+
+// romanLevels maps roman numeral levels to integers
+var romanLevels = map[string]int{
+	"I": 1, "II": 2, "III": 3, "IV": 4, "V": 5, "VI": 6,
+	"VII": 7, "VIII": 8, "IX": 9, "X": 10, "XI": 11, "XII": 12,
+}
+
+// rankStopWords prevent a leading rank being stripped from titles such as
+// "ASSISTANT TO THE CHANCELLOR" or "ASSOCIATE IN EDUCATION"
+var rankStopWords = map[string]bool{
+	"TO": true, "OF": true, "IN": true, "FOR": true, "AND": true, "OR": true,
+}
+
+// Dictionary is the on-disk form of the title normalization rules.
+// Abbreviations map single tokens or token phrases to their expansion,
+// Qualifiers map appointment suffixes (e.g. "ACADEMIC YEAR") to a short
+// code, Ranks lists the rank words split from a title's family, and Aliases
+// map an expanded title without its level to a canonical title.
+type Dictionary struct {
+	Name          string            `json:"name"`
+	Version       string            `json:"version"`
+	Description   string            `json:"description,omitempty"`
+	Abbreviations map[string]string `json:"abbreviations"`
+	Qualifiers    map[string]string `json:"qualifiers"`
+	Ranks         []Rank            `json:"ranks"`
+	Aliases       map[string]string `json:"aliases"`
+}
+
+// Rank is a seniority word within a title family. Order sorts ranks around
+// the unranked title at zero; Suffix ranks are also recognized at the end
+// of a title (e.g. "CUSTODIAN SR").
+type Rank struct {
+	Name   string `json:"name"`
+	Order  int    `json:"order"`
+	Suffix bool   `json:"suffix,omitempty"`
+}
+
+// Normalizer turns raw payroll titles into canonical titles and families.
+// It is safe for concurrent use.
+type Normalizer struct {
+	name          string
+	version       string
+	abbreviations map[string][]string
+	maxPhrase     int
+	qualifiers    map[string]string
+	ranks         map[string]Rank
+	aliases       map[string]string
+}
+
+// DefaultNormalizer returns the title dictionary bundled with the package
+func DefaultNormalizer() *Normalizer {
+	defaultNormalizerOnce.Do(func() {
+		n, err := decodeDictionary(defaultDictionary, "bundled title dictionary")
+		if err != nil {
+			panic(err)
+		}
+		defaultNormalizer = n
+	})
+	return defaultNormalizer
+}
+
+// LoadNormalizer compiles a title dictionary from a file on disk
+func LoadNormalizer(filepath string) (*Normalizer, error) {
+	raw, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening title dictionary %s: %w", filepath, err)
+	}
+
+	return decodeDictionary(raw, filepath)
+}
+
+// OpenNormalizer returns the bundled dictionary for an empty path and loads
+// the dictionary at path otherwise
+func OpenNormalizer(filepath string) (*Normalizer, error) {
+	if filepath == "" {
+		return DefaultNormalizer(), nil
+	}
+	return LoadNormalizer(filepath)
+}
+
+func decodeDictionary(raw []byte, name string) (*Normalizer, error) {
+	var dictionary Dictionary
+	if err := json.Unmarshal(raw, &dictionary); err != nil {
+		return nil, fmt.Errorf("error decoding title dictionary from %s: %w", name, err)
+	}
+
+	n, err := NewNormalizer(&dictionary)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title dictionary in %s: %w", name, err)
+	}
+
+	return n, nil
+}
+
+// NewNormalizer validates a dictionary and prepares it for lookups
+func NewNormalizer(dictionary *Dictionary) (*Normalizer, error) {
+	if dictionary.Name == "" || dictionary.Version == "" {
+		return nil, fmt.Errorf("title dictionary is missing a name or version")
+	}
+
+	n := &Normalizer{
+		name:          dictionary.Name,
+		version:       dictionary.Version,
+		abbreviations: make(map[string][]string),
+		qualifiers:    make(map[string]string),
+		ranks:         make(map[string]Rank),
+		aliases:       make(map[string]string),
+	}
+
+	for abbreviation, expansion := range dictionary.Abbreviations {
+		key := cleanSegment(abbreviation)
+		words := strings.Fields(strings.ToUpper(expansion))
+		if key == "" || len(words) == 0 {
+			return nil, fmt.Errorf("empty abbreviation %q", abbreviation)
+		}
+		n.abbreviations[key] = words
+		if phrase := len(strings.Fields(key)); phrase > n.maxPhrase {
+			n.maxPhrase = phrase
+		}
+	}
+	for qualifier, code := range dictionary.Qualifiers {
+		n.qualifiers[cleanSegment(qualifier)] = strings.ToUpper(code)
+	}
+	for _, rank := range dictionary.Ranks {
+		rank.Name = strings.ToUpper(rank.Name)
+		if rank.Order == 0 {
+			return nil, fmt.Errorf("rank %q needs a nonzero order", rank.Name)
+		}
+		n.ranks[rank.Name] = rank
+	}
+	for alias, canonical := range dictionary.Aliases {
+		n.aliases[cleanTitle(alias)] = cleanTitle(canonical)
+	}
+
+	return n, nil
+}
+
+// Name returns the dictionary name
+func (n *Normalizer) Name() string {
+	return n.name
+}
+
+// Version returns the dictionary version
+func (n *Normalizer) Version() string {
+	return n.version
+}
+
+// Normalize splits a raw title into its canonical title, family, rank,
+// level, step and appointment qualifiers. For example "ASST PROF-AY-B/E/E"
+// becomes title "ASSISTANT PROFESSOR" in family "PROFESSOR" with rank
+// "ASSISTANT" and qualifiers AY and B/E/E, and "ADMIN ANL PRN 1" becomes
+// "PRINCIPAL ADMINISTRATIVE ANALYST 1" at level 1.
+func (n *Normalizer) Normalize(raw string) *models.NormalizedTitle {
+	result := &models.NormalizedTitle{Raw: raw}
+
+	title := strings.ToUpper(raw)
+	title = strings.TrimLeft(title, "_-.,* ")
+
+	// Parenthetical notes such as "(FUNCTIONAL AREA)" are qualifiers
+	title = parentheticalPattern.ReplaceAllStringFunc(title, func(match string) string {
+		if note := cleanSegment(match[1 : len(match)-1]); note != "" {
+			result.Qualifiers = append(result.Qualifiers, note)
+		}
+		return " "
+	})
+
+	if match := stepPattern.FindStringSubmatch(title); match != nil {
+		result.Step, _ = strconv.Atoi(match[1])
+		title = stepPattern.ReplaceAllString(title, " ")
+	}
+
+	// Hyphenated suffixes are qualifiers when known and part of the title
+	// otherwise
+	title = nonPrefixPattern.ReplaceAllString(title, "NON ")
+	segments := strings.Split(title, "-")
+	base := []string{segments[0]}
+	for _, segment := range segments[1:] {
+		segment = cleanSegment(segment)
+		if segment == "" {
+			continue
+		}
+		if codes, ok := n.qualifierCodes(segment); ok {
+			result.Qualifiers = append(result.Qualifiers, codes...)
+			continue
+		}
+		base = append(base, segment)
+	}
+
+	// Comma-inverted titles read back to front:
+	// "ANALYST, ADMINISTRATIVE, SR" is "SR ADMINISTRATIVE ANALYST"
+	parts := strings.Split(strings.Join(base, " "), ",")
+	var tokens []string
+	for i := len(parts) - 1; i >= 1; i-- {
+		tokens = append(tokens, n.stripSuffixes(tokenize(parts[i]), result)...)
+	}
+	tokens = append(tokens, n.stripSuffixes(tokenize(parts[0]), result)...)
+
+	tokens = n.expand(tokens)
+	tokens = n.extractRank(tokens, result)
+
+	// Aliases apply to the expanded title without its level
+	if canonical, ok := n.aliases[strings.Join(n.withRank(tokens, result), " ")]; ok {
+		result.Rank, result.RankOrder = "", 0
+		tokens = n.extractRank(strings.Fields(canonical), result)
+	}
+
+	result.Family = strings.Join(tokens, " ")
+	result.Title = strings.Join(n.withRank(tokens, result), " ")
+	if result.Level > 0 {
+		result.Title = fmt.Sprintf("%s %d", result.Title, result.Level)
+	}
+
+	return result
+}
+
+// qualifierCodes looks up a suffix, also trying its slash-separated parts
+// ("NON GSHIP/NON REP")
+func (n *Normalizer) qualifierCodes(segment string) ([]string, bool) {
+	if code, ok := n.qualifiers[segment]; ok {
+		return []string{code}, true
+	}
+
+	if !strings.Contains(segment, "/") {
+		return nil, false
+	}
+
+	var codes []string
+	for _, part := range strings.Split(segment, "/") {
+		code, ok := n.qualifiers[cleanSegment(part)]
+		if !ok {
+			return nil, false
+		}
+		codes = append(codes, code)
+	}
+	return codes, true
+}
+
+// stripSuffixes removes trailing qualifier tokens (e.g. "NEX") and a
+// trailing level from a part of a title
+func (n *Normalizer) stripSuffixes(tokens []string, result *models.NormalizedTitle) []string {
+	for len(tokens) > 1 {
+		last := tokens[len(tokens)-1]
+		if code, ok := n.qualifiers[last]; ok {
+			result.Qualifiers = append(result.Qualifiers, code)
+			tokens = tokens[:len(tokens)-1]
+			continue
+		}
+		if level, ok := parseLevel(last); ok && result.Level == 0 {
+			result.Level = level
+			tokens = tokens[:len(tokens)-1]
+			continue
+		}
+		break
+	}
+	return tokens
+}
+
+// expand replaces abbreviations, preferring the longest matching phrase
+func (n *Normalizer) expand(tokens []string) []string {
+	var expanded []string
+	for i := 0; i < len(tokens); {
+		matched := false
+		for length := n.maxPhrase; length >= 1; length-- {
+			if i+length > len(tokens) {
+				continue
+			}
+			if words, ok := n.abbreviations[strings.Join(tokens[i:i+length], " ")]; ok {
+				expanded = append(expanded, words...)
+				i += length
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			expanded = append(expanded, n.expandSlashed(tokens[i]))
+			i++
+		}
+	}
+	return expanded
+}
+
+// expandSlashed expands each part of a slash-joined token such as
+// "ADMIN/COORD/OFFICER"
+func (n *Normalizer) expandSlashed(token string) string {
+	if !strings.Contains(token, "/") {
+		return token
+	}
+
+	parts := strings.Split(token, "/")
+	for i, part := range parts {
+		if words, ok := n.abbreviations[part]; ok && len(words) == 1 {
+			parts[i] = words[0]
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// extractRank splits a leading rank, or a trailing suffix rank, from the
+// family
+func (n *Normalizer) extractRank(tokens []string, result *models.NormalizedTitle) []string {
+	if len(tokens) < 2 {
+		return tokens
+	}
+
+	if rank, ok := n.ranks[tokens[0]]; ok && !rankStopWords[tokens[1]] {
+		result.Rank, result.RankOrder = rank.Name, rank.Order
+		return tokens[1:]
+	}
+
+	if rank, ok := n.ranks[tokens[len(tokens)-1]]; ok && rank.Suffix {
+		result.Rank, result.RankOrder = rank.Name, rank.Order
+		return tokens[:len(tokens)-1]
+	}
+
+	return tokens
+}
+
+// withRank prefixes the family with its rank
+func (n *Normalizer) withRank(tokens []string, result *models.NormalizedTitle) []string {
+	if result.Rank == "" {
+		return tokens
+	}
+	return append([]string{result.Rank}, tokens...)
+}
+
+// parseLevel reads an arabic or roman numeral level
+func parseLevel(token string) (int, bool) {
+	if level, ok := romanLevels[token]; ok {
+		return level, true
+	}
+	level, err := strconv.Atoi(token)
+	if err != nil || level <= 0 || level > 20 {
+		return 0, false
+	}
+	return level, true
+}
+
+// tokenize splits a title part into words without trailing punctuation
+func tokenize(part string) []string {
+	var tokens []string
+	for _, word := range strings.Fields(part) {
+		if word = strings.Trim(word, ".,;:"); word != "" {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// cleanSegment uppercases a title fragment, collapses whitespace and trims
+// surrounding punctuation
+func cleanSegment(segment string) string {
+	return strings.Trim(cleanTitle(segment), " .,;:")
+}
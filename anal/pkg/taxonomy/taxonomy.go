@@ -0,0 +1,358 @@
+// Package taxonomy assigns job titles to hierarchical categories using a
+// versioned rules file instead of hard-coded keyword lists.
+package taxonomy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ucinvestments/uc-wages-analysis/pkg/models"
+)
+
+// Separator joins the levels of a hierarchical category,
+// e.g. "Academic > Ladder Faculty"
+const Separator = " > "
+
+// DefaultCategory is used when a rules file does not name one
+const DefaultCategory = "Other"
+
+// Match types, in precedence order for rules of equal priority
+const (
+	MatchExact   = "exact"
+	MatchPattern = "pattern"
+	MatchKeyword = "keyword"
+)
+
+//go:embed data/default.json
+var defaultRules []byte
+
+var (
+	defaultOnce     sync.Once
+	defaultTaxonomy *Taxonomy
+)
+
+// Rules is the on-disk form of a taxonomy
+type Rules struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	Description     string `json:"description,omitempty"`
+	DefaultCategory string `json:"default_category,omitempty"`
+	Rules           []Rule `json:"rules"`
+}
+
+// Rule assigns a category to titles matching any of its exact titles,
+// regular expressions or keywords, unless an exclusion pattern matches.
+// Exact titles and keywords are compared case-insensitively; patterns and
+// exclusions are case-insensitive regular expressions. When several rules
+// match, the highest priority wins, then exact over pattern over keyword
+// matches, then the rule listed first.
+type Rule struct {
+	ID       string   `json:"id"`
+	Category string   `json:"category"`
+	Priority int      `json:"priority"`
+	Exact    []string `json:"exact,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+	Exclude  []string `json:"exclude,omitempty"`
+	Note     string   `json:"note,omitempty"`
+}
+
+// Taxonomy is a compiled set of rules. It is safe for concurrent use.
+type Taxonomy struct {
+	name            string
+	version         string
+	defaultCategory string
+	rules           []*compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	order    int
+	path     []string
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+	exclude  []*regexp.Regexp
+}
+
+// Default returns the taxonomy bundled with the package
+func Default() *Taxonomy {
+	defaultOnce.Do(func() {
+		t, err := decode(defaultRules, "bundled taxonomy")
+		if err != nil {
+			panic(err)
+		}
+		defaultTaxonomy = t
+	})
+	return defaultTaxonomy
+}
+
+// Load compiles a taxonomy from a rules file on disk
+func Load(filepath string) (*Taxonomy, error) {
+	raw, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening taxonomy file %s: %w", filepath, err)
+	}
+
+	return decode(raw, filepath)
+}
+
+// Open returns the bundled taxonomy for an empty path and loads the rules
+// file at path otherwise
+func Open(filepath string) (*Taxonomy, error) {
+	if filepath == "" {
+		return Default(), nil
+	}
+	return Load(filepath)
+}
+
+func decode(raw []byte, name string) (*Taxonomy, error) {
+	var rules Rules
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, fmt.Errorf("error decoding taxonomy from %s: %w", name, err)
+	}
+
+	t, err := Compile(&rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taxonomy in %s: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// Compile validates rules and prepares them for matching
+func Compile(rules *Rules) (*Taxonomy, error) {
+	if rules.Name == "" || rules.Version == "" {
+		return nil, fmt.Errorf("taxonomy is missing a name or version")
+	}
+
+	t := &Taxonomy{
+		name:            rules.Name,
+		version:         rules.Version,
+		defaultCategory: rules.DefaultCategory,
+	}
+	if t.defaultCategory == "" {
+		t.defaultCategory = DefaultCategory
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range rules.Rules {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("rule %d has no id", i+1)
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("duplicate rule id %q", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		path := SplitCategory(rule.Category)
+		if len(path) == 0 {
+			return nil, fmt.Errorf("rule %q has no category", rule.ID)
+		}
+		if len(rule.Exact)+len(rule.Patterns)+len(rule.Keywords) == 0 {
+			return nil, fmt.Errorf("rule %q has no exact titles, patterns or keywords", rule.ID)
+		}
+
+		compiled := &compiledRule{
+			Rule:  rule,
+			order: i,
+			path:  path,
+			exact: make(map[string]bool),
+		}
+		compiled.Category = strings.Join(path, Separator)
+
+		for _, title := range rule.Exact {
+			compiled.exact[cleanTitle(title)] = true
+		}
+		compiled.Keywords = make([]string, len(rule.Keywords))
+		for j, keyword := range rule.Keywords {
+			compiled.Keywords[j] = strings.ToUpper(keyword)
+		}
+		for _, pattern := range rule.Patterns {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid pattern %q: %w", rule.ID, pattern, err)
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+		for _, pattern := range rule.Exclude {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid exclusion %q: %w", rule.ID, pattern, err)
+			}
+			compiled.exclude = append(compiled.exclude, re)
+		}
+
+		t.rules = append(t.rules, compiled)
+	}
+
+	return t, nil
+}
+
+// Name returns the taxonomy name
+func (t *Taxonomy) Name() string {
+	return t.name
+}
+
+// Version returns the taxonomy version
+func (t *Taxonomy) Version() string {
+	return t.version
+}
+
+// Rules returns the compiled rules in file order
+func (t *Taxonomy) Rules() []Rule {
+	rules := make([]Rule, len(t.rules))
+	for i, rule := range t.rules {
+		rules[i] = rule.Rule
+	}
+	return rules
+}
+
+// Category returns the top-level category for a title
+func (t *Taxonomy) Category(title string) string {
+	return t.Classify(title).Category
+}
+
+// Classify assigns a title to a category and explains the decision. Every
+// other matching rule is listed in Overridden; Conflict is set when one of
+// them assigns a different category with the same priority and match type,
+// so only rule order decided the outcome.
+func (t *Taxonomy) Classify(title string) *models.TitleClassification {
+	cleaned := cleanTitle(title)
+
+	var matches []models.RuleMatch
+	var order []int
+	for _, rule := range t.rules {
+		match, ok := rule.match(cleaned)
+		if !ok {
+			continue
+		}
+		matches = append(matches, match)
+		order = append(order, rule.order)
+	}
+
+	classification := &models.TitleClassification{
+		Title:    title,
+		Category: t.defaultCategory,
+		Path:     []string{t.defaultCategory},
+	}
+	if len(matches) == 0 {
+		return classification
+	}
+
+	// Sort by precedence, keeping file order for ties
+	indexes := make([]int, len(matches))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := matches[indexes[i]], matches[indexes[j]]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if matchRank(a.MatchType) != matchRank(b.MatchType) {
+			return matchRank(a.MatchType) < matchRank(b.MatchType)
+		}
+		return order[indexes[i]] < order[indexes[j]]
+	})
+
+	winner := matches[indexes[0]]
+	path := SplitCategory(winner.Category)
+	classification.Category = path[0]
+	classification.Path = path
+	classification.Rule = &winner
+
+	for _, i := range indexes[1:] {
+		other := matches[i]
+		classification.Overridden = append(classification.Overridden, other)
+
+		if other.Category != winner.Category &&
+			other.Priority == winner.Priority &&
+			other.MatchType == winner.MatchType {
+			classification.Conflict = true
+		}
+	}
+
+	return classification
+}
+
+// match reports whether the rule applies to a cleaned title
+func (r *compiledRule) match(title string) (models.RuleMatch, bool) {
+	match := models.RuleMatch{
+		RuleID:   r.ID,
+		Category: r.Category,
+		Priority: r.Priority,
+	}
+
+	switch {
+	case r.exact[title]:
+		match.MatchType = MatchExact
+		match.Matched = title
+	default:
+		if pattern := firstPattern(r.patterns, title); pattern != nil {
+			match.MatchType = MatchPattern
+			match.Matched = strings.TrimPrefix(pattern.String(), "(?i)")
+		} else if keyword := firstKeyword(r.Keywords, title); keyword != "" {
+			match.MatchType = MatchKeyword
+			match.Matched = keyword
+		} else {
+			return match, false
+		}
+	}
+
+	if firstPattern(r.exclude, title) != nil {
+		return match, false
+	}
+
+	return match, true
+}
+
+// SplitCategory splits a hierarchical category into its levels
+func SplitCategory(category string) []string {
+	var path []string
+	for _, level := range strings.Split(category, ">") {
+		if level = strings.TrimSpace(level); level != "" {
+			path = append(path, level)
+		}
+	}
+	return path
+}
+
+// cleanTitle uppercases a title and collapses whitespace
+func cleanTitle(title string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(title)), " ")
+}
+
+func firstPattern(patterns []*regexp.Regexp, title string) *regexp.Regexp {
+	for _, re := range patterns {
+		if re.MatchString(title) {
+			return re
+		}
+	}
+	return nil
+}
+
+func firstKeyword(keywords []string, title string) string {
+	for _, keyword := range keywords {
+		if strings.Contains(title, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+func matchRank(matchType string) int {
+	switch matchType {
+	case MatchExact:
+		return 0
+	case MatchPattern:
+		return 1
+	default:
+		return 2
+	}
+}
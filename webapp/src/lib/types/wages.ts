@@ -25,6 +25,46 @@ export interface AggregatedWageData {
 	minWage: number;
 }
 
+// Pay distribution for a location-year from the analysis generate_histograms
+// tool. Histogram edges have one more entry than counts; category counts and
+// densities share the overall edges and KDE x grid so they can be overlaid.
+// A category's KDE is left out when small-cell suppression changed its counts.
+// WageChart renders it when given as its histogram prop.
+export interface WageHistogram {
+	location: string;
+	year: number;
+	generated_at: string;
+	count: number;
+	histogram: {
+		scale: 'linear' | 'log';
+		bin_width?: number;
+		bins_per_decade?: number;
+		edges: number[];
+		counts: number[];
+		density: number[];
+		overflow: number;
+	};
+	kde: {
+		kernel: string;
+		scale: 'linear' | 'log';
+		bandwidth: number;
+		x: number[];
+		density: number[];
+	};
+	categories: Array<{
+		category: string;
+		count: number;
+		share: number;
+		counts: number[];
+		density: number[];
+		overflow: number;
+		bandwidth?: number;
+		kde?: number[];
+	}>;
+	taxonomy: string;
+	taxonomy_version: string;
+}
+
 export interface UploadProgress {
 	id: number;
 	location: string;